@@ -1,13 +1,35 @@
 package di
 
 import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/audit"
 	"github.com/PakornBank/go-backend-example/cmd/api/handler/auth"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/job"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/loginhistory"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/session"
 	"github.com/PakornBank/go-backend-example/cmd/api/handler/user"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/webhook"
+	internalAudit "github.com/PakornBank/go-backend-example/internal/audit"
 	internalAuth "github.com/PakornBank/go-backend-example/internal/auth"
+	"github.com/PakornBank/go-backend-example/internal/common/buildinfo"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
 	"github.com/PakornBank/go-backend-example/internal/common/health"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
+	internalJob "github.com/PakornBank/go-backend-example/internal/job"
+	internalLoginHistory "github.com/PakornBank/go-backend-example/internal/loginhistory"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"github.com/PakornBank/go-backend-example/internal/scheduler"
+	internalSession "github.com/PakornBank/go-backend-example/internal/session"
 	internalUser "github.com/PakornBank/go-backend-example/internal/user"
+	internalWebhook "github.com/PakornBank/go-backend-example/internal/webhook"
 	"gorm.io/gorm"
-	"log"
 
 	"github.com/PakornBank/go-backend-example/internal/common/config"
 	"github.com/PakornBank/go-backend-example/internal/common/database"
@@ -15,33 +37,170 @@ import (
 
 // Container holds the dependencies for the application.
 type Container struct {
-	UserHandler   user.Handler
-	AuthHandler   auth.Handler
-	HealthHandler health.Handler
-	Config        *config.Config
-	db            *gorm.DB
+	UserHandler         user.Handler
+	AuthHandler         auth.Handler
+	SessionHandler      session.Handler
+	LoginHistoryHandler loginhistory.Handler
+	AuditHandler        audit.Handler
+	WebhookHandler      webhook.Handler
+	JobHandler          job.Handler
+	HealthHandler       health.Handler
+	BuildHandler        buildinfo.Handler
+	Health              *health.Registry
+	Config              *config.Config
+	ConfigStore         *config.Store
+	Logger              *slog.Logger
+	Metrics             *metrics.Metrics
+	Sessions            internalSession.Service
+	Outbox              *outbox.Dispatcher
+	Webhooks            *internalWebhook.Deliverer
+	Jobs                *internalJob.Runner
+	Scheduler           *scheduler.Scheduler
+	db                  *gorm.DB
 }
 
 // NewContainer creates a new Container with the provided configuration.
 func NewContainer(cfg *config.Config) *Container {
+	store := config.NewStore(cfg)
+	log := logger.NewWithLevel(os.Stdout, cfg.LogFormat, logger.WatchLevel(store))
+
 	db, err := database.NewDataBase(cfg)
 	if err != nil {
-		log.Fatal("failed to initialize database: ", err)
+		log.Error("failed to initialize database", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	info := buildinfo.Get()
+
+	m := metrics.New()
+	if err := m.RegisterBuildInfo(info); err != nil {
+		log.Warn("failed to register build info metric", slog.Any("error", err))
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := m.RegisterDB("primary", sqlDB); err != nil {
+			log.Warn("failed to register database metrics", slog.Any("error", err))
+		}
+	}
+	for _, replica := range database.Replicas(db) {
+		if err := m.RegisterDB(replica.Name, replica.DB); err != nil {
+			log.Warn("failed to register database metrics", slog.String("replica", replica.Name), slog.Any("error", err))
+		}
+	}
+
+	txManager := database.NewTxManager(db, database.TxOptions{
+		Isolation:    cfg.DBTxIsolation,
+		MaxRetries:   cfg.DBTxMaxRetries,
+		RetryBackoff: cfg.DBTxRetryBackoff,
+	})
+
+	passwordPolicy, err := password.NewPolicy(cfg)
+	if err != nil {
+		log.Error("failed to initialize password policy", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if err := internalAudit.Migrate(db); err != nil {
+		log.Error("failed to initialize audit log", slog.Any("error", err))
+		os.Exit(1)
 	}
+	auditService := internalAudit.NewService(internalAudit.NewRepository(db, cfg.RepositoryTimeout), txManager)
 
-	authHandler := auth.NewHandler(internalAuth.NewService(internalAuth.NewRepository(db), cfg))
-	userHandler := user.NewHandler(internalUser.NewService(internalUser.NewRepository(db)))
-	healthHandler := health.NewHandler(db)
+	jobRepository := internalJob.NewRepository(db, cfg.RepositoryTimeout)
+	jobService := internalJob.NewService(jobRepository, txManager, auditService, store)
+	// Handlers of background jobs register with the runner here.
+	jobRunner := internalJob.NewRunner(jobRepository, store, m)
+	internalJob.RegisterMail(jobRunner, mail.NewSender(store))
+
+	outboxRepository := outbox.NewRepository(db, cfg.RepositoryTimeout)
+	events := outbox.NewPublisher(outboxRepository)
+	// Handlers of domain events subscribe to the dispatcher here.
+	dispatcher := outbox.NewDispatcher(outboxRepository, store, m)
+
+	webhookRepository := internalWebhook.NewRepository(db, cfg.RepositoryTimeout)
+	webhookService := internalWebhook.NewService(webhookRepository, txManager, auditService)
+	for _, eventType := range internalWebhook.EventTypes {
+		dispatcher.Subscribe(eventType, "webhooks", webhookService.Enqueue)
+	}
+
+	sessionService := internalSession.NewService(internalSession.NewRepository(db, cfg.RepositoryTimeout), txManager, auditService)
+	loginHistoryService := internalLoginHistory.NewService(internalLoginHistory.NewRepository(db, cfg.RepositoryTimeout), internalJob.NewMailSender(jobService), store)
+	userRepository := internalUser.NewRepository(db, cfg.RepositoryTimeout)
+	retention := internalUser.NewRetention(userRepository, txManager, internalJob.NewMailSender(jobService), auditService, events, store)
+
+	// Periodic tasks register with the scheduler here.
+	taskScheduler := scheduler.NewScheduler(scheduler.NewRepository(db, cfg.RepositoryTimeout), store, m)
+	if err := taskScheduler.Register("sessions.purge", cfg.SessionPurgeSchedule, sessionService.PurgeInactive); err != nil {
+		log.Error("failed to initialize scheduler", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if err := taskScheduler.Register("users.retention", cfg.RetentionSchedule, retention.Run); err != nil {
+		log.Error("failed to initialize scheduler", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if err := taskScheduler.Register("outbox.purge", cfg.OutboxPurgeSchedule, dispatcher.Purge); err != nil {
+		log.Error("failed to initialize scheduler", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if err := taskScheduler.Register("jobs.purge", cfg.JobPurgeSchedule, jobRunner.Purge); err != nil {
+		log.Error("failed to initialize scheduler", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	authHandler := auth.NewHandler(internalAuth.NewService(internalAuth.NewRepository(db, cfg.RepositoryTimeout), txManager, email.NewNormalizer(cfg), passwordPolicy, password.NewHasher(cfg), sessionService, loginHistoryService, auditService, events, store, m))
+	userHandler := user.NewHandler(internalUser.NewService(userRepository, txManager, email.NewNormalizer(cfg), password.NewHasher(cfg), auditService, events))
+	healthRegistry := health.NewRegistry()
+	healthRegistry.Register(health.Check{
+		Name:   "database",
+		Func:   health.DatabaseCheck(db),
+		Scopes: health.Readiness | health.Startup,
+	})
+	healthRegistry.Register(health.Check{
+		Name:   "migrations",
+		Func:   health.MigrationsCheck(db, database.Models...),
+		Scopes: health.Startup,
+	})
+	if cfg.SMTPHost != "" {
+		// Emails are queued as jobs and retried, so an unreachable mail server is
+		// reported without taking the instance out of rotation.
+		healthRegistry.Register(health.Check{
+			Name:     "mailer",
+			Func:     health.MailerCheck(store),
+			Scopes:   health.Readiness,
+			CacheTTL: 30 * time.Second,
+			Optional: true,
+		})
+	}
+	healthHandler := health.NewHandler(healthRegistry)
 
 	return &Container{
-		AuthHandler:   authHandler,
-		UserHandler:   userHandler,
-		HealthHandler: healthHandler,
-		Config:        cfg,
-		db:            db,
+		AuthHandler:         authHandler,
+		UserHandler:         userHandler,
+		SessionHandler:      session.NewHandler(sessionService),
+		LoginHistoryHandler: loginhistory.NewHandler(loginHistoryService),
+		AuditHandler:        audit.NewHandler(auditService),
+		WebhookHandler:      webhook.NewHandler(webhookService),
+		JobHandler:          job.NewHandler(jobService),
+		HealthHandler:       healthHandler,
+		BuildHandler:        buildinfo.NewHandler(info),
+		Health:              healthRegistry,
+		Config:              cfg,
+		ConfigStore:         store,
+		Logger:              log,
+		Metrics:             m,
+		Sessions:            sessionService,
+		Outbox:              dispatcher,
+		Webhooks:            internalWebhook.NewDeliverer(webhookRepository, store, m),
+		Jobs:                jobRunner,
+		Scheduler:           taskScheduler,
+		db:                  db,
 	}
 }
 
+// Workers returns the background workers of the application, which run until their context is done.
+func (c *Container) Workers() []func(ctx context.Context) {
+	return []func(ctx context.Context){c.Outbox.Run, c.Webhooks.Run, c.Jobs.Run, c.Scheduler.Run}
+}
+
 // GetDB returns the database instance
 func (c *Container) GetDB() (*gorm.DB, error) {
 	return c.db, nil
@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Number of events returned when the request does not set a limit, and the most it may ask for.
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+//go:generate mockgen -destination=./handler_mock.go -package=audit github.com/PakornBank/go-backend-example/cmd/api/handler/audit Handler
+
+// Handler defines the interface for audit log HTTP requests.
+type Handler interface {
+	List(c *gin.Context)
+	Export(c *gin.Context)
+	Verify(c *gin.Context)
+}
+
+// handler handles audit log HTTP requests.
+type handler struct {
+	service audit.Service
+}
+
+// NewHandler creates a new instance of handler with the provided service.
+func NewHandler(s audit.Service) Handler {
+	return &handler{service: s}
+}
+
+// List handles the request to query the audit log, newest first. The
+// actor_id, action, target_type, target_id, from, to, before_seq and limit
+// query parameters filter and page the events.
+func (h *handler) List(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.record(c, audit.ActionAuditQueried, filter) {
+		return
+	}
+
+	events, err := h.service.Query(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to query audit log")
+		return
+	}
+
+	res := make([]audit.ExportedEvent, len(events))
+	for i := range events {
+		res[i] = audit.NewExportedEvent(&events[i])
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Export handles the request to download the audit log as JSON lines, oldest
+// first. It accepts the filters of List except before_seq and limit.
+func (h *handler) Export(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.BeforeSeq, filter.Limit = 0, 0
+
+	if !h.record(c, audit.ActionAuditExported, filter) {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-events.jsonl"`)
+	c.Status(http.StatusOK)
+
+	// The status is sent with the first line, a failure can only cut the export short.
+	if err := h.service.Export(c.Request.Context(), filter, c.Writer); err != nil {
+		logger.FromContext(c.Request.Context()).Error("audit export interrupted", slog.Any("error", err))
+	}
+}
+
+// Verify handles the request to check the hash chain of the audit log.
+func (h *handler) Verify(c *gin.Context) {
+	result, err := h.service.Verify(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to verify audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// record adds the access of the authenticated admin to the audit log. It
+// answers the request and returns false when that fails.
+func (h *handler) record(c *gin.Context, action string, filter audit.Filter) bool {
+	err := h.service.Record(c.Request.Context(), audit.Event{
+		ActorID:    c.GetString("user_id"),
+		Action:     action,
+		TargetType: audit.TargetAudit,
+		Metadata:   filterMetadata(filter),
+	})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to record audit event")
+		return false
+	}
+	return true
+}
+
+// parseFilter reads the filter of the request from its query parameters.
+func parseFilter(c *gin.Context) (audit.Filter, error) {
+	filter := audit.Filter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Limit:      defaultLimit,
+	}
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		if _, err := uuid.Parse(actorID); err != nil {
+			return filter, errors.New("actor_id must be a UUID")
+		}
+		filter.ActorID = actorID
+	}
+	for key, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := c.Query(key); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, errors.New(key + " must be an RFC 3339 time")
+			}
+			*t = parsed
+		}
+	}
+	if raw := c.Query("before_seq"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			return filter, errors.New("before_seq must be a positive integer")
+		}
+		filter.BeforeSeq = n
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxLimit {
+			return filter, errors.New("limit must be between 1 and " + strconv.Itoa(maxLimit))
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+// filterMetadata returns the fields of filter that are set, to record with the access.
+func filterMetadata(filter audit.Filter) map[string]interface{} {
+	metadata := map[string]interface{}{}
+	for key, value := range map[string]string{
+		"actor_id":    filter.ActorID,
+		"action":      filter.Action,
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+	} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	if !filter.From.IsZero() {
+		metadata["from"] = filter.From.UTC().Format(time.RFC3339)
+	}
+	if !filter.To.IsZero() {
+		metadata["to"] = filter.To.UTC().Format(time.RFC3339)
+	}
+	return metadata
+}
@@ -0,0 +1,286 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var adminID = uuid.New().String()
+
+func setupHandlerTest(ctrl *gomock.Controller) (*gin.Engine, *audit.MockService) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := audit.NewMockService(ctrl)
+	auditHandler := &handler{service: mockService}
+
+	router := gin.New()
+	group := router.Group("/api/admin")
+	group.Use(func(c *gin.Context) {
+		c.Set("user_id", adminID)
+	})
+	{
+		group.GET("/audit-events", auditHandler.List)
+		group.GET("/audit-events/export", auditHandler.Export)
+		group.GET("/audit-events/verify", auditHandler.Verify)
+	}
+
+	return router, mockService
+}
+
+func TestNewHandler(t *testing.T) {
+	mockService := new(audit.MockService)
+	auditHandler := NewHandler(mockService)
+
+	assert.NotNil(t, auditHandler)
+	assert.Equal(t, mockService, auditHandler.(*handler).service)
+}
+
+func Test_handler_List(t *testing.T) {
+	actorID := uuid.New().String()
+	events := []model.AuditEvent{
+		{Seq: 2, Action: audit.ActionLoginFailed, Metadata: `{"reason":"wrong_password"}`, CreatedAt: time.Now()},
+		{Seq: 1, Action: audit.ActionUserRegistered, Metadata: "{}", CreatedAt: time.Now()},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		mockFn      func(*audit.MockService)
+		wantCode    int
+		errContains string
+	}{
+		{
+			name: "default filter",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Record(gomock.Any(), audit.Event{
+					ActorID:    adminID,
+					Action:     audit.ActionAuditQueried,
+					TargetType: audit.TargetAudit,
+					Metadata:   map[string]interface{}{},
+				}).Return(nil)
+				ms.EXPECT().Query(gomock.Any(), audit.Filter{Limit: defaultLimit}).Return(events, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:  "every filter",
+			query: "?actor_id=" + actorID + "&action=user.login_failed&target_type=user&target_id=t&from=2026-01-01T00:00:00Z&to=2026-01-02T00:00:00Z&before_seq=10&limit=5",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Record(gomock.Any(), audit.Event{
+					ActorID:    adminID,
+					Action:     audit.ActionAuditQueried,
+					TargetType: audit.TargetAudit,
+					Metadata: map[string]interface{}{
+						"actor_id":    actorID,
+						"action":      audit.ActionLoginFailed,
+						"target_type": audit.TargetUser,
+						"target_id":   "t",
+						"from":        "2026-01-01T00:00:00Z",
+						"to":          "2026-01-02T00:00:00Z",
+					},
+				}).Return(nil)
+				ms.EXPECT().Query(gomock.Any(), audit.Filter{
+					ActorID:    actorID,
+					Action:     audit.ActionLoginFailed,
+					TargetType: audit.TargetUser,
+					TargetID:   "t",
+					From:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:         time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+					BeforeSeq:  10,
+					Limit:      5,
+				}).Return(events, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "invalid actor",
+			query:       "?actor_id=admin",
+			wantCode:    http.StatusBadRequest,
+			errContains: "actor_id must be a UUID",
+		},
+		{
+			name:        "invalid time",
+			query:       "?from=yesterday",
+			wantCode:    http.StatusBadRequest,
+			errContains: "from must be an RFC 3339 time",
+		},
+		{
+			name:        "invalid before_seq",
+			query:       "?before_seq=0",
+			wantCode:    http.StatusBadRequest,
+			errContains: "before_seq must be a positive integer",
+		},
+		{
+			name:        "limit too large",
+			query:       "?limit=5000",
+			wantCode:    http.StatusBadRequest,
+			errContains: "limit must be between 1 and 1000",
+		},
+		{
+			name: "record error",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to record audit event",
+		},
+		{
+			name: "query error",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+				ms.EXPECT().Query(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to query audit log",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t))
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-events"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.errContains != "" {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Contains(t, res["error"], tt.errContains)
+				return
+			}
+
+			var res []map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Len(t, res, len(events))
+			assert.Equal(t, float64(2), res[0]["seq"])
+			assert.Equal(t, map[string]interface{}{"reason": "wrong_password"}, res[0]["metadata"])
+		})
+	}
+}
+
+func Test_handler_Export(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		mockFn   func(*audit.MockService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:  "events exported",
+			query: "?action=user.registered&limit=5&before_seq=3",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Record(gomock.Any(), audit.Event{
+					ActorID:    adminID,
+					Action:     audit.ActionAuditExported,
+					TargetType: audit.TargetAudit,
+					Metadata:   map[string]interface{}{"action": audit.ActionUserRegistered},
+				}).Return(nil)
+				ms.EXPECT().Export(gomock.Any(), audit.Filter{Action: audit.ActionUserRegistered}, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ audit.Filter, w io.Writer) error {
+						_, err := io.WriteString(w, "{\"seq\":1}\n{\"seq\":2}\n")
+						return err
+					})
+			},
+			wantCode: http.StatusOK,
+			wantBody: "{\"seq\":1}\n{\"seq\":2}\n",
+		},
+		{
+			name:     "invalid filter",
+			query:    "?to=tomorrow",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "record error",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t))
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-events/export"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+				assert.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment"))
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_handler_Verify(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockFn   func(*audit.MockService)
+		wantCode int
+	}{
+		{
+			name: "chain verified",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Verify(gomock.Any()).Return(&audit.Verification{Valid: false, Events: 4, HeadSeq: 4, BrokenSeq: 5}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "service error",
+			mockFn: func(ms *audit.MockService) {
+				ms.EXPECT().Verify(gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t))
+			tt.mockFn(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-events/verify", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, false, res["valid"])
+				assert.Equal(t, float64(5), res["broken_seq"])
+			}
+		})
+	}
+}
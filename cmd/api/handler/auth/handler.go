@@ -1,8 +1,14 @@
 package auth
 
 import (
+	"errors"
+
 	"github.com/PakornBank/go-backend-example/cmd/api/model"
 	"github.com/PakornBank/go-backend-example/internal/auth"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/session"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -13,6 +19,7 @@ import (
 type Handler interface {
 	Register(c *gin.Context)
 	Login(c *gin.Context)
+	ChangePassword(c *gin.Context)
 }
 
 // handler handles authentication-related HTTP requests.
@@ -30,13 +37,13 @@ func NewHandler(s auth.Service) Handler {
 func (h *handler) Register(c *gin.Context) {
 	var input model.RegisterInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	user, err := h.service.Register(c.Request.Context(), input.Email, input.Password, input.FullName)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.fail(c, err, "failed to register")
 		return
 	}
 
@@ -55,15 +62,57 @@ func (h *handler) Register(c *gin.Context) {
 func (h *handler) Login(c *gin.Context) {
 	var input model.LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	token, err := h.service.Login(c.Request.Context(), input.Email, input.Password)
+	client := session.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+	token, err := h.service.Login(c.Request.Context(), input.Email, input.Password, client)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.fail(c, err, "failed to log in")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// ChangePassword handles the request to change the password of the authenticated user.
+func (h *handler) ChangePassword(c *gin.Context) {
+	id, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input model.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.service.ChangePassword(c.Request.Context(), id.(string), input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		h.fail(c, err, "failed to change password")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// fail answers the request with the status matching err, or a server error with msg.
+// A password policy error lists the violated rules.
+func (h *handler) fail(c *gin.Context, err error, msg string) {
+	var policyErr *password.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		response.ErrorWithDetails(c, http.StatusBadRequest, "password does not meet the policy", policyErr.Violations)
+	case errors.Is(err, email.ErrInvalid), errors.Is(err, auth.ErrWrongPassword), errors.Is(err, auth.ErrInvalidCredentials):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrUserNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, auth.ErrEmailTaken):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, msg)
+	}
+}
@@ -40,6 +40,18 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockHandler) ChangePassword(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ChangePassword", c)
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockHandlerMockRecorder) ChangePassword(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockHandler)(nil).ChangePassword), c)
+}
+
 // Login mocks base method.
 func (m *MockHandler) Login(c *gin.Context) {
 	m.ctrl.T.Helper()
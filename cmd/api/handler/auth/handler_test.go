@@ -11,12 +11,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
 	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/PakornBank/go-backend-example/internal/session"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
+// testUserHeader carries the ID of the authenticated user in tests, in place of the Auth middleware.
+const testUserHeader = "X-Test-User-ID"
+
 func setupHandlerTest(t *testing.T) (*gin.Engine, *auth.MockService) {
 	gin.SetMode(gin.TestMode)
 	ctrl := gomock.NewController(t)
@@ -28,6 +34,11 @@ func setupHandlerTest(t *testing.T) (*gin.Engine, *auth.MockService) {
 	{
 		group.POST("/register", authHandler.Register)
 		group.POST("/login", authHandler.Login)
+		group.POST("/password", func(c *gin.Context) {
+			if id := c.GetHeader(testUserHeader); id != "" {
+				c.Set("user_id", id)
+			}
+		}, authHandler.ChangePassword)
 	}
 
 	return router, mockService
@@ -75,8 +86,51 @@ func Test_handler_Register(t *testing.T) {
 				ms.EXPECT().Register(gomock.Any(), user.Email, "password", user.FullName).
 					Return(nil, errors.New("auth_service error"))
 			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to register",
+		},
+		{
+			name: "invalid email",
+			input: model.RegisterInput{
+				Email:    user.Email,
+				Password: "password",
+				FullName: user.FullName,
+			},
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().Register(gomock.Any(), user.Email, "password", user.FullName).Return(nil, email.ErrInvalid)
+			},
 			wantCode:    http.StatusBadRequest,
-			errContains: "auth_service error",
+			errContains: email.ErrInvalid.Error(),
+		},
+		{
+			name: "email already registered",
+			input: model.RegisterInput{
+				Email:    user.Email,
+				Password: "password",
+				FullName: user.FullName,
+			},
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().Register(gomock.Any(), user.Email, "password", user.FullName).
+					Return(nil, auth.ErrEmailTaken)
+			},
+			wantCode:    http.StatusConflict,
+			errContains: "email already registered",
+		},
+		{
+			name: "password violates the policy",
+			input: model.RegisterInput{
+				Email:    user.Email,
+				Password: "password",
+				FullName: user.FullName,
+			},
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().Register(gomock.Any(), user.Email, "password", user.FullName).
+					Return(nil, &password.PolicyError{Violations: []password.Violation{
+						{Rule: password.RuleBreached, Message: "has appeared in a data breach"},
+					}})
+			},
+			wantCode:    http.StatusBadRequest,
+			errContains: "password does not meet the policy",
 		},
 		{
 			name: "invalid email",
@@ -148,9 +202,10 @@ func Test_handler_Register(t *testing.T) {
 
 func Test_handler_Login(t *testing.T) {
 	const (
-		testToken    = "test-token"
-		testEmail    = "test@example.com"
-		testPassword = "password"
+		testToken     = "test-token"
+		testEmail     = "test@example.com"
+		testPassword  = "password"
+		testUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) Chrome/129.0"
 	)
 
 	tests := []struct {
@@ -167,22 +222,34 @@ func Test_handler_Login(t *testing.T) {
 				Password: testPassword,
 			},
 			mockFn: func(ms *auth.MockService) {
-
-				ms.EXPECT().Login(gomock.Any(), testEmail, testPassword).Return(testToken, nil)
+				client := session.Client{UserAgent: testUserAgent, IP: "192.0.2.1"}
+				ms.EXPECT().Login(gomock.Any(), testEmail, testPassword, client).Return(testToken, nil)
 			},
 			wantCode: http.StatusOK,
 		},
 		{
-			name: "auth_service error",
+			name: "invalid credentials",
 			input: model.LoginInput{
 				Email:    testEmail,
 				Password: testPassword,
 			},
 			mockFn: func(ms *auth.MockService) {
-				ms.EXPECT().Login(gomock.Any(), testEmail, testPassword).Return("", errors.New("auth_service error"))
+				ms.EXPECT().Login(gomock.Any(), testEmail, testPassword, gomock.Any()).Return("", auth.ErrInvalidCredentials)
 			},
 			wantCode:    http.StatusBadRequest,
-			errContains: "auth_service error",
+			errContains: "invalid credentials",
+		},
+		{
+			name: "auth_service error",
+			input: model.LoginInput{
+				Email:    testEmail,
+				Password: testPassword,
+			},
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().Login(gomock.Any(), testEmail, testPassword, gomock.Any()).Return("", errors.New("failed to create session"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to log in",
 		},
 		{
 			name: "invalid email",
@@ -214,6 +281,7 @@ func Test_handler_Login(t *testing.T) {
 			body, _ := json.Marshal(tt.input)
 			req := httptest.NewRequest(http.MethodPost, "/api/login", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", testUserAgent)
 			w := httptest.NewRecorder()
 
 			router.ServeHTTP(w, req)
@@ -234,3 +302,124 @@ func Test_handler_Login(t *testing.T) {
 		})
 	}
 }
+
+func Test_handler_ChangePassword(t *testing.T) {
+	user := testutil.NewMockUser()
+	input := model.ChangePasswordInput{CurrentPassword: "password", NewPassword: "correct horse battery"}
+
+	tests := []struct {
+		name        string
+		userID      string
+		input       model.ChangePasswordInput
+		mockFn      func(*auth.MockService)
+		wantCode    int
+		errContains string
+		wantDetails []interface{}
+	}{
+		{
+			name:   "successful change",
+			userID: user.ID.String(),
+			input:  input,
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().ChangePassword(gomock.Any(), user.ID.String(), input.CurrentPassword, input.NewPassword).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:   "wrong current password",
+			userID: user.ID.String(),
+			input:  input,
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().ChangePassword(gomock.Any(), user.ID.String(), input.CurrentPassword, input.NewPassword).
+					Return(auth.ErrWrongPassword)
+			},
+			wantCode:    http.StatusBadRequest,
+			errContains: auth.ErrWrongPassword.Error(),
+		},
+		{
+			name:   "user not found",
+			userID: user.ID.String(),
+			input:  input,
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().ChangePassword(gomock.Any(), user.ID.String(), input.CurrentPassword, input.NewPassword).
+					Return(auth.ErrUserNotFound)
+			},
+			wantCode:    http.StatusNotFound,
+			errContains: auth.ErrUserNotFound.Error(),
+		},
+		{
+			name:   "service error",
+			userID: user.ID.String(),
+			input:  input,
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().ChangePassword(gomock.Any(), user.ID.String(), input.CurrentPassword, input.NewPassword).
+					Return(errors.New("connection refused"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to change password",
+		},
+		{
+			name:   "new password violates the policy",
+			userID: user.ID.String(),
+			input:  input,
+			mockFn: func(ms *auth.MockService) {
+				ms.EXPECT().ChangePassword(gomock.Any(), user.ID.String(), input.CurrentPassword, input.NewPassword).
+					Return(&password.PolicyError{Violations: []password.Violation{
+						{Rule: password.RuleMinLength, Message: "must be at least 12 characters long"},
+						{Rule: password.RuleDigit, Message: "must contain a digit"},
+					}})
+			},
+			wantCode:    http.StatusBadRequest,
+			errContains: "password does not meet the policy",
+			wantDetails: []interface{}{
+				map[string]interface{}{"rule": "min_length", "message": "must be at least 12 characters long"},
+				map[string]interface{}{"rule": "digit", "message": "must contain a digit"},
+			},
+		},
+		{
+			name:        "missing new password",
+			userID:      user.ID.String(),
+			input:       model.ChangePasswordInput{CurrentPassword: "password"},
+			wantCode:    http.StatusBadRequest,
+			errContains: "Error:Field validation for 'NewPassword' failed",
+		},
+		{
+			name:        "no user_id in context",
+			input:       input,
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(t)
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			body, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPost, "/api/password", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.userID != "" {
+				req.Header.Set(testUserHeader, tt.userID)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusNoContent {
+				assert.Empty(t, w.Body.Bytes())
+				return
+			}
+
+			var res map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Contains(t, res["error"], tt.errContains)
+			if tt.wantDetails != nil {
+				assert.Equal(t, tt.wantDetails, res["details"])
+			}
+		})
+	}
+}
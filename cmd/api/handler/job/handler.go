@@ -0,0 +1,137 @@
+package job
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	internalModel "github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/job"
+	"github.com/gin-gonic/gin"
+)
+
+// Number of jobs returned when the request does not set a limit, and the most it may ask for.
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// statuses are the values accepted by the status filter of List.
+var statuses = []string{job.StatusPending, job.StatusSucceeded, job.StatusFailed}
+
+//go:generate mockgen -destination=./handler_mock.go -package=job github.com/PakornBank/go-backend-example/cmd/api/handler/job Handler
+
+// Handler defines the interface for background job HTTP requests.
+type Handler interface {
+	List(c *gin.Context)
+	Stats(c *gin.Context)
+	Get(c *gin.Context)
+	Retry(c *gin.Context)
+}
+
+// handler handles background job HTTP requests.
+type handler struct {
+	service job.Service
+}
+
+// NewHandler creates a new instance of handler with the provided service.
+func NewHandler(s job.Service) Handler {
+	return &handler{service: s}
+}
+
+// List handles the request to list jobs, most recently updated first. The
+// type, status and limit query parameters filter them.
+func (h *handler) List(c *gin.Context) {
+	filter := job.Filter{Type: c.Query("type"), Status: c.Query("status"), Limit: defaultLimit}
+	if filter.Status != "" && !slices.Contains(statuses, filter.Status) {
+		response.Error(c, http.StatusBadRequest, "status must be one of pending, succeeded or failed")
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxLimit {
+			response.Error(c, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+			return
+		}
+		filter.Limit = n
+	}
+
+	jobs, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list jobs")
+		return
+	}
+
+	res := make([]model.Job, len(jobs))
+	for i := range jobs {
+		res[i] = toJob(&jobs[i])
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Stats handles the request to count the jobs by type and status.
+func (h *handler) Stats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to count jobs")
+		return
+	}
+	if stats == nil {
+		stats = []job.Stat{}
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Get handles the request to show a job with its payload.
+func (h *handler) Get(c *gin.Context) {
+	j, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err, "failed to get job")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.JobDetail{Job: toJob(j), Payload: []byte(j.Payload)})
+}
+
+// Retry handles the request to run a failed job again.
+func (h *handler) Retry(c *gin.Context) {
+	if err := h.service.Retry(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		h.fail(c, err, "failed to retry job")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// fail answers the request with the status matching err, or a server error with msg.
+func (h *handler) fail(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, job.ErrNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, job.ErrNotFailed), errors.Is(err, job.ErrDuplicate):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// toJob converts a job to its response, without its payload.
+func toJob(j *internalModel.Job) model.Job {
+	return model.Job{
+		ID:          j.ID,
+		Type:        j.Type,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		UniqueKey:   j.UniqueKey,
+		LastError:   j.LastError,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+	}
+}
@@ -0,0 +1,191 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	internalModel "github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/job"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var adminID = uuid.New().String()
+
+func setupHandlerTest(ctrl *gomock.Controller) (*gin.Engine, *job.MockService) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := job.NewMockService(ctrl)
+	jobHandler := &handler{service: mockService}
+
+	router := gin.New()
+	group := router.Group("/api/admin")
+	group.Use(func(c *gin.Context) {
+		c.Set("user_id", adminID)
+	})
+	{
+		group.GET("/jobs", jobHandler.List)
+		group.GET("/jobs/stats", jobHandler.Stats)
+		group.GET("/jobs/:id", jobHandler.Get)
+		group.POST("/jobs/:id/retry", jobHandler.Retry)
+	}
+
+	return router, mockService
+}
+
+func TestNewHandler(t *testing.T) {
+	mockService := new(job.MockService)
+	jobHandler := NewHandler(mockService)
+
+	assert.NotNil(t, jobHandler)
+	assert.Equal(t, mockService, jobHandler.(*handler).service)
+}
+
+func Test_handler_List(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		mockFn   func(*job.MockService)
+		wantCode int
+		wantLen  int
+	}{
+		{
+			name: "default filter",
+			mockFn: func(ms *job.MockService) {
+				ms.EXPECT().List(gomock.Any(), job.Filter{Limit: defaultLimit}).
+					Return([]internalModel.Job{{ID: uuid.New(), Type: "mail.send", Status: job.StatusPending}}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantLen:  1,
+		},
+		{
+			name:  "type, status and limit",
+			query: "?type=mail.send&status=failed&limit=5",
+			mockFn: func(ms *job.MockService) {
+				ms.EXPECT().List(gomock.Any(), job.Filter{Type: "mail.send", Status: job.StatusFailed, Limit: 5}).Return(nil, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unknown status",
+			query:    "?status=dead",
+			mockFn:   func(*job.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "limit too high",
+			query:    "?limit=501",
+			mockFn:   func(*job.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			mockFn: func(ms *job.MockService) {
+				ms.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			tt.mockFn(mockService)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/jobs"+tt.query, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				var res []model.Job
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Len(t, res, tt.wantLen)
+			}
+		})
+	}
+}
+
+func Test_handler_Stats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	router, mockService := setupHandlerTest(ctrl)
+	mockService.EXPECT().Stats(gomock.Any()).Return([]job.Stat{{Type: "mail.send", Status: job.StatusFailed, Count: 2}}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/jobs/stats", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"type":"mail.send","status":"failed","count":2}]`, w.Body.String())
+}
+
+func Test_handler_Get(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, mockService := setupHandlerTest(ctrl)
+		mockService.EXPECT().Get(gomock.Any(), id.String()).Return(&internalModel.Job{
+			ID:        id,
+			Type:      "mail.send",
+			Payload:   `{"To":"user@example.com"}`,
+			Status:    job.StatusFailed,
+			LastError: "connection refused",
+		}, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/jobs/"+id.String(), nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var res model.JobDetail
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, id, res.ID)
+		assert.Equal(t, "connection refused", res.LastError)
+		assert.JSONEq(t, `{"To":"user@example.com"}`, string(res.Payload))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, mockService := setupHandlerTest(ctrl)
+		mockService.EXPECT().Get(gomock.Any(), "x").Return(nil, job.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/jobs/x", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func Test_handler_Retry(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "rescheduled", wantCode: http.StatusAccepted},
+		{name: "not found", err: job.ErrNotFound, wantCode: http.StatusNotFound},
+		{name: "not failed", err: job.ErrNotFailed, wantCode: http.StatusConflict},
+		{name: "unique key held", err: job.ErrDuplicate, wantCode: http.StatusConflict},
+		{name: "service error", err: errors.New("database error"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			mockService.EXPECT().Retry(gomock.Any(), adminID, id).Return(tt.err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/jobs/"+id+"/retry", nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package loginhistory
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/loginhistory"
+	"github.com/gin-gonic/gin"
+)
+
+// Number of login attempts returned when the request does not set a limit, and the most it may ask for.
+const (
+	defaultLimit = 50
+	maxLimit     = 100
+)
+
+//go:generate mockgen -destination=./handler_mock.go -package=loginhistory github.com/PakornBank/go-backend-example/cmd/api/handler/loginhistory Handler
+
+// Handler defines the interface for login history HTTP requests.
+type Handler interface {
+	List(c *gin.Context)
+}
+
+// handler handles login history HTTP requests.
+type handler struct {
+	service loginhistory.Service
+}
+
+// NewHandler creates a new instance of handler with the provided service.
+func NewHandler(s loginhistory.Service) Handler {
+	return &handler{service: s}
+}
+
+// List handles the request to list the most recent login attempts of the
+// authenticated user. The optional limit query parameter sets how many.
+func (h *handler) List(c *gin.Context) {
+	id, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxLimit {
+			response.Error(c, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+			return
+		}
+		limit = n
+	}
+
+	attempts, err := h.service.List(c.Request.Context(), id.(string), limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list login history")
+		return
+	}
+
+	res := make([]model.LoginAttempt, len(attempts))
+	for i, a := range attempts {
+		res[i] = model.LoginAttempt{
+			ID:        a.ID,
+			Success:   a.Success,
+			Reason:    a.Reason,
+			IP:        a.IP,
+			Device:    a.Device,
+			UserAgent: a.UserAgent,
+			CreatedAt: a.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,143 @@
+package loginhistory
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/loginhistory"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupHandlerTest(ctrl *gomock.Controller, middleware gin.HandlerFunc) (*gin.Engine, *loginhistory.MockService) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := loginhistory.NewMockService(ctrl)
+	historyHandler := &handler{service: mockService}
+
+	router := gin.New()
+	group := router.Group("/api")
+	if middleware != nil {
+		group.Use(middleware)
+	}
+	{
+		group.GET("/login-history", historyHandler.List)
+	}
+
+	return router, mockService
+}
+
+func TestNewHandler(t *testing.T) {
+	mockService := new(loginhistory.MockService)
+	historyHandler := NewHandler(mockService)
+
+	assert.NotNil(t, historyHandler)
+	assert.Equal(t, mockService, historyHandler.(*handler).service)
+}
+
+func Test_handler_List(t *testing.T) {
+	userID := uuid.New().String()
+	now := time.Now().UTC().Truncate(time.Second)
+	attempts := []model.LoginAttempt{
+		{ID: uuid.New(), Success: true, IP: "192.0.2.1", Device: "Chrome on macOS", CreatedAt: now},
+		{ID: uuid.New(), Reason: loginhistory.ReasonWrongPassword, IP: "203.0.113.9", Device: "curl", CreatedAt: now.Add(-time.Hour)},
+	}
+	authenticated := func(c *gin.Context) {
+		c.Set("user_id", userID)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		middleware  gin.HandlerFunc
+		mockFn      func(*loginhistory.MockService)
+		wantCode    int
+		errContains string
+	}{
+		{
+			name:       "default limit",
+			middleware: authenticated,
+			mockFn: func(ms *loginhistory.MockService) {
+				ms.EXPECT().List(gomock.Any(), userID, defaultLimit).Return(attempts, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "custom limit",
+			query:      "?limit=10",
+			middleware: authenticated,
+			mockFn: func(ms *loginhistory.MockService) {
+				ms.EXPECT().List(gomock.Any(), userID, 10).Return(attempts, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "limit too large",
+			query:       "?limit=1000",
+			middleware:  authenticated,
+			wantCode:    http.StatusBadRequest,
+			errContains: "limit must be between 1 and 100",
+		},
+		{
+			name:        "limit not a number",
+			query:       "?limit=ten",
+			middleware:  authenticated,
+			wantCode:    http.StatusBadRequest,
+			errContains: "limit must be between 1 and 100",
+		},
+		{
+			name:       "service error",
+			middleware: authenticated,
+			mockFn: func(ms *loginhistory.MockService) {
+				ms.EXPECT().List(gomock.Any(), userID, defaultLimit).Return(nil, errors.New("db error"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to list login history",
+		},
+		{
+			name:        "no user_id in context",
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t), tt.middleware)
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/login-history"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.errContains != "" {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Contains(t, res["error"], tt.errContains)
+				return
+			}
+
+			var res []map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Len(t, res, len(attempts))
+			assert.Equal(t, attempts[0].ID.String(), res[0]["id"])
+			assert.Equal(t, true, res[0]["success"])
+			assert.NotContains(t, res[0], "reason")
+			assert.Equal(t, false, res[1]["success"])
+			assert.Equal(t, loginhistory.ReasonWrongPassword, res[1]["reason"])
+			assert.Equal(t, "203.0.113.9", res[1]["ip"])
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate mockgen -destination=./handler_mock.go -package=session github.com/PakornBank/go-backend-example/cmd/api/handler/session Handler
+
+// Handler defines the interface for session-related HTTP requests.
+type Handler interface {
+	List(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+// handler handles session-related HTTP requests.
+type handler struct {
+	service session.Service
+}
+
+// NewHandler creates a new instance of handler with the provided service.
+func NewHandler(s session.Service) Handler {
+	return &handler{service: s}
+}
+
+// List handles the request to list the active sessions of the authenticated user,
+// marking the session of the request as current.
+func (h *handler) List(c *gin.Context) {
+	id, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessions, err := h.service.List(c.Request.Context(), id.(string))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	current := c.GetString("session_id")
+	res := make([]model.Session, len(sessions))
+	for i, s := range sessions {
+		res[i] = model.Session{
+			ID:         s.ID,
+			Device:     s.Device,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID.String() == current,
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Revoke handles the request to revoke a session of the authenticated user.
+func (h *handler) Revoke(c *gin.Context) {
+	id, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	err := h.service.Revoke(c.Request.Context(), id.(string), c.Param("id"))
+	if errors.Is(err, session.ErrNotFound) {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,199 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupHandlerTest(ctrl *gomock.Controller, middleware gin.HandlerFunc) (*gin.Engine, *session.MockService) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := session.NewMockService(ctrl)
+	sessionHandler := &handler{service: mockService}
+
+	router := gin.New()
+	group := router.Group("/api")
+	if middleware != nil {
+		group.Use(middleware)
+	}
+	{
+		group.GET("/sessions", sessionHandler.List)
+		group.DELETE("/sessions/:id", sessionHandler.Revoke)
+	}
+
+	return router, mockService
+}
+
+func TestNewHandler(t *testing.T) {
+	mockService := new(session.MockService)
+	sessionHandler := NewHandler(mockService)
+
+	assert.NotNil(t, sessionHandler)
+	assert.Equal(t, mockService, sessionHandler.(*handler).service)
+}
+
+func Test_handler_List(t *testing.T) {
+	userID := uuid.New().String()
+	now := time.Now().UTC().Truncate(time.Second)
+	sessions := []model.Session{
+		{ID: uuid.New(), Device: "Chrome on macOS", IP: "192.0.2.1", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: uuid.New(), Device: "Firefox on Linux", IP: "192.0.2.2", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		middleware  gin.HandlerFunc
+		mockFn      func(*session.MockService)
+		wantCode    int
+		wantCurrent []bool
+		errContains string
+	}{
+		{
+			name: "current session marked",
+			middleware: func(c *gin.Context) {
+				c.Set("user_id", userID)
+				c.Set("session_id", sessions[1].ID.String())
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().List(gomock.Any(), userID).Return(sessions, nil)
+			},
+			wantCode:    http.StatusOK,
+			wantCurrent: []bool{false, true},
+		},
+		{
+			name: "service error",
+			middleware: func(c *gin.Context) {
+				c.Set("user_id", userID)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().List(gomock.Any(), userID).Return(nil, errors.New("db error"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to list sessions",
+		},
+		{
+			name:        "no user_id in context",
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t), tt.middleware)
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.errContains != "" {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Contains(t, res["error"], tt.errContains)
+				return
+			}
+
+			var res []map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Len(t, res, len(sessions))
+			for i, s := range res {
+				assert.Equal(t, sessions[i].ID.String(), s["id"])
+				assert.Equal(t, sessions[i].Device, s["device"])
+				assert.Equal(t, sessions[i].IP, s["ip"])
+				assert.Equal(t, tt.wantCurrent[i], s["current"])
+			}
+		})
+	}
+}
+
+func Test_handler_Revoke(t *testing.T) {
+	userID := uuid.New().String()
+	sessionID := uuid.New().String()
+
+	tests := []struct {
+		name        string
+		middleware  gin.HandlerFunc
+		mockFn      func(*session.MockService)
+		wantCode    int
+		errContains string
+	}{
+		{
+			name: "session revoked",
+			middleware: func(c *gin.Context) {
+				c.Set("user_id", userID)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Revoke(gomock.Any(), userID, sessionID).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name: "session not found",
+			middleware: func(c *gin.Context) {
+				c.Set("user_id", userID)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Revoke(gomock.Any(), userID, sessionID).Return(session.ErrNotFound)
+			},
+			wantCode:    http.StatusNotFound,
+			errContains: "session not found",
+		},
+		{
+			name: "service error",
+			middleware: func(c *gin.Context) {
+				c.Set("user_id", userID)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Revoke(gomock.Any(), userID, sessionID).Return(errors.New("db error"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to revoke session",
+		},
+		{
+			name:        "no user_id in context",
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t), tt.middleware)
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/sessions/"+sessionID, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.errContains != "" {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Contains(t, res["error"], tt.errContains)
+			} else {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
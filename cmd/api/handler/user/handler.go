@@ -1,9 +1,14 @@
 package user
 
 import (
-	"github.com/PakornBank/go-backend-example/internal/user"
+	"errors"
 	"net/http"
 
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/user"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,6 +17,8 @@ import (
 // Handler defines the interface for user-related HTTP requests.
 type Handler interface {
 	GetProfile(c *gin.Context)
+	ChangeEmail(c *gin.Context)
+	DeleteAccount(c *gin.Context)
 }
 
 // handler handles user-related HTTP requests.
@@ -29,15 +36,82 @@ func NewHandler(s user.Service) Handler {
 func (h *handler) GetProfile(c *gin.Context) {
 	id, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	res, err := h.service.GetUserByID(c.Request.Context(), id.(string))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		h.fail(c, err, "failed to get profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ChangeEmail handles the request to change the email address of the authenticated user.
+func (h *handler) ChangeEmail(c *gin.Context) {
+	id, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input model.ChangeEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	u, err := h.service.ChangeEmail(c.Request.Context(), id.(string), input.Password, input.Email)
+	if err != nil {
+		h.fail(c, err, "failed to change email")
 		return
 	}
 
+	res := model.User{
+		ID:        u.ID,
+		Email:     u.Email,
+		FullName:  u.FullName,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+
 	c.JSON(http.StatusOK, res)
 }
+
+// DeleteAccount handles the request to delete the account of the authenticated user.
+func (h *handler) DeleteAccount(c *gin.Context) {
+	id, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input model.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id.(string), input.Password); err != nil {
+		h.fail(c, err, "failed to delete account")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// fail answers the request with the status matching err, or a server error with msg.
+func (h *handler) fail(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, email.ErrInvalid), errors.Is(err, user.ErrWrongPassword):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, user.ErrEmailTaken):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, msg)
+	}
+}
@@ -40,6 +40,30 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 	return m.recorder
 }
 
+// ChangeEmail mocks base method.
+func (m *MockHandler) ChangeEmail(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ChangeEmail", c)
+}
+
+// ChangeEmail indicates an expected call of ChangeEmail.
+func (mr *MockHandlerMockRecorder) ChangeEmail(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeEmail", reflect.TypeOf((*MockHandler)(nil).ChangeEmail), c)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockHandler) DeleteAccount(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteAccount", c)
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockHandlerMockRecorder) DeleteAccount(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockHandler)(nil).DeleteAccount), c)
+}
+
 // GetProfile mocks base method.
 func (m *MockHandler) GetProfile(c *gin.Context) {
 	m.ctrl.T.Helper()
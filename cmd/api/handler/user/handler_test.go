@@ -1,6 +1,7 @@
 package user
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"github.com/PakornBank/go-backend-example/internal/user"
@@ -29,6 +30,8 @@ func setupHandlerTest(ctrl *gomock.Controller, middleware gin.HandlerFunc) (*gin
 	}
 	{
 		group.GET("/profile", userHandler.GetProfile)
+		group.DELETE("/profile", userHandler.DeleteAccount)
+		group.PUT("/email", userHandler.ChangeEmail)
 	}
 
 	return router, mockService
@@ -70,8 +73,19 @@ func Test_handler_GetProfile(t *testing.T) {
 			mockFn: func(ms *user.MockService) {
 				ms.EXPECT().GetUserByID(gomock.Any(), mockUser.ID.String()).Return(nil, errors.New("user_service error"))
 			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to get profile",
+		},
+		{
+			name: "user not found",
+			middleware: func(c *gin.Context) {
+				c.Set("user_id", mockUser.ID.String())
+			},
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().GetUserByID(gomock.Any(), mockUser.ID.String()).Return(nil, user.ErrNotFound)
+			},
 			wantCode:    http.StatusNotFound,
-			errContains: "user_service error",
+			errContains: user.ErrNotFound.Error(),
 		},
 		{
 			name:        "no user_id input context",
@@ -116,3 +130,194 @@ func Test_handler_GetProfile(t *testing.T) {
 		})
 	}
 }
+
+func Test_handler_ChangeEmail(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	authenticated := func(c *gin.Context) {
+		c.Set("user_id", mockUser.ID.String())
+	}
+
+	tests := []struct {
+		name        string
+		body        interface{}
+		middleware  gin.HandlerFunc
+		mockFn      func(*user.MockService)
+		wantCode    int
+		errContains string
+	}{
+		{
+			name:       "email changed",
+			body:       map[string]string{"email": "new@example.com", "password": "password"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				changed := mockUser
+				changed.Email = "new@example.com"
+				ms.EXPECT().ChangeEmail(gomock.Any(), mockUser.ID.String(), "password", "new@example.com").Return(&changed, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "invalid input",
+			body:        map[string]string{"email": "not an email"},
+			middleware:  authenticated,
+			wantCode:    http.StatusBadRequest,
+			errContains: "Email",
+		},
+		{
+			name:       "email taken",
+			body:       map[string]string{"email": "new@example.com", "password": "password"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().ChangeEmail(gomock.Any(), mockUser.ID.String(), "password", "new@example.com").Return(nil, user.ErrEmailTaken)
+			},
+			wantCode:    http.StatusConflict,
+			errContains: user.ErrEmailTaken.Error(),
+		},
+		{
+			name:       "wrong password",
+			body:       map[string]string{"email": "new@example.com", "password": "wrong"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().ChangeEmail(gomock.Any(), mockUser.ID.String(), "wrong", "new@example.com").Return(nil, user.ErrWrongPassword)
+			},
+			wantCode:    http.StatusBadRequest,
+			errContains: user.ErrWrongPassword.Error(),
+		},
+		{
+			name:       "service error",
+			body:       map[string]string{"email": "new@example.com", "password": "password"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().ChangeEmail(gomock.Any(), mockUser.ID.String(), "password", "new@example.com").
+					Return(nil, errors.New("connection refused"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to change email",
+		},
+		{
+			name:        "no user_id in context",
+			body:        map[string]string{"email": "new@example.com", "password": "password"},
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t), tt.middleware)
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			body, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPut, "/api/email", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			var res map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			if tt.errContains != "" {
+				assert.Contains(t, res["error"], tt.errContains)
+			} else {
+				assert.Equal(t, "new@example.com", res["email"])
+			}
+		})
+	}
+}
+
+func Test_handler_DeleteAccount(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	authenticated := func(c *gin.Context) {
+		c.Set("user_id", mockUser.ID.String())
+	}
+
+	tests := []struct {
+		name        string
+		body        interface{}
+		middleware  gin.HandlerFunc
+		mockFn      func(*user.MockService)
+		wantCode    int
+		errContains string
+	}{
+		{
+			name:       "account deleted",
+			body:       map[string]string{"password": "password"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().Delete(gomock.Any(), mockUser.ID.String(), "password").Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:        "missing password",
+			body:        map[string]string{},
+			middleware:  authenticated,
+			wantCode:    http.StatusBadRequest,
+			errContains: "Password",
+		},
+		{
+			name:       "wrong password",
+			body:       map[string]string{"password": "wrong"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().Delete(gomock.Any(), mockUser.ID.String(), "wrong").Return(user.ErrWrongPassword)
+			},
+			wantCode:    http.StatusBadRequest,
+			errContains: user.ErrWrongPassword.Error(),
+		},
+		{
+			name:       "user not found",
+			body:       map[string]string{"password": "password"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().Delete(gomock.Any(), mockUser.ID.String(), "password").Return(user.ErrNotFound)
+			},
+			wantCode:    http.StatusNotFound,
+			errContains: user.ErrNotFound.Error(),
+		},
+		{
+			name:       "service error",
+			body:       map[string]string{"password": "password"},
+			middleware: authenticated,
+			mockFn: func(ms *user.MockService) {
+				ms.EXPECT().Delete(gomock.Any(), mockUser.ID.String(), "password").Return(errors.New("connection refused"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to delete account",
+		},
+		{
+			name:        "no user_id in context",
+			body:        map[string]string{"password": "password"},
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupHandlerTest(gomock.NewController(t), tt.middleware)
+			if tt.mockFn != nil {
+				tt.mockFn(mockService)
+			}
+
+			body, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodDelete, "/api/profile", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.errContains != "" {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Contains(t, res["error"], tt.errContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	internalModel "github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// Number of deliveries returned when the request does not set a limit, and the most it may ask for.
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// statuses are the values accepted by the status filter of ListDeliveries.
+var statuses = []string{webhook.StatusPending, webhook.StatusSucceeded, webhook.StatusFailed}
+
+//go:generate mockgen -destination=./handler_mock.go -package=webhook github.com/PakornBank/go-backend-example/cmd/api/handler/webhook Handler
+
+// Handler defines the interface for webhook HTTP requests.
+type Handler interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	ListDeliveries(c *gin.Context)
+	GetDelivery(c *gin.Context)
+	Redeliver(c *gin.Context)
+}
+
+// handler handles webhook HTTP requests.
+type handler struct {
+	service webhook.Service
+}
+
+// NewHandler creates a new instance of handler with the provided service.
+func NewHandler(s webhook.Service) Handler {
+	return &handler{service: s}
+}
+
+// Create handles the request to subscribe an endpoint to domain events. The
+// response holds the secret of the subscription, which is not shown again.
+func (h *handler) Create(c *gin.Context) {
+	var input model.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, err := h.service.Create(c.Request.Context(), c.GetString("user_id"), toInput(input))
+	if err != nil {
+		h.fail(c, err, "failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CreatedWebhook{Webhook: toWebhook(sub), Secret: sub.Secret})
+}
+
+// List handles the request to list every webhook subscription.
+func (h *handler) List(c *gin.Context) {
+	subs, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+
+	res := make([]model.Webhook, len(subs))
+	for i := range subs {
+		res[i] = toWebhook(&subs[i])
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Get handles the request to show a webhook subscription.
+func (h *handler) Get(c *gin.Context) {
+	sub, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err, "failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhook(sub))
+}
+
+// Update handles the request to replace the settings of a webhook subscription.
+func (h *handler) Update(c *gin.Context) {
+	var input model.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, err := h.service.Update(c.Request.Context(), c.GetString("user_id"), c.Param("id"), toInput(input))
+	if err != nil {
+		h.fail(c, err, "failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhook(sub))
+}
+
+// Delete handles the request to remove a webhook subscription and its deliveries.
+func (h *handler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		h.fail(c, err, "failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles the request to list the deliveries of a webhook
+// subscription, newest first. The status and limit query parameters filter them.
+func (h *handler) ListDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !slices.Contains(statuses, status) {
+		response.Error(c, http.StatusBadRequest, "status must be one of pending, succeeded or failed")
+		return
+	}
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxLimit {
+			response.Error(c, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.service.Deliveries(c.Request.Context(), c.Param("id"), status, limit)
+	if err != nil {
+		h.fail(c, err, "failed to list webhook deliveries")
+		return
+	}
+
+	res := make([]model.WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		res[i] = toDelivery(&deliveries[i])
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetDelivery handles the request to show a delivery of a webhook subscription with its log of attempts.
+func (h *handler) GetDelivery(c *gin.Context) {
+	delivery, attempts, err := h.service.Delivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		h.fail(c, err, "failed to get webhook delivery")
+		return
+	}
+
+	res := model.WebhookDeliveryDetail{
+		WebhookDelivery: toDelivery(delivery),
+		Payload:         []byte(delivery.Payload),
+		Log:             make([]model.WebhookAttempt, len(attempts)),
+	}
+	for i, a := range attempts {
+		res.Log[i] = model.WebhookAttempt{
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMS: a.DurationMS,
+			CreatedAt:  a.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Redeliver handles the request to send a delivery of a webhook subscription again.
+func (h *handler) Redeliver(c *gin.Context) {
+	err := h.service.Redeliver(c.Request.Context(), c.GetString("user_id"), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		h.fail(c, err, "failed to redeliver webhook")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// fail answers the request with the status matching err, or a server error with msg.
+func (h *handler) fail(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, webhook.ErrNotFound), errors.Is(err, webhook.ErrDeliveryNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, webhook.ErrInvalidURL), errors.Is(err, webhook.ErrForbiddenURL), errors.Is(err, webhook.ErrInvalidEventTypes):
+		response.Error(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, webhook.ErrDeliveryPending):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// toInput converts the request body to the input of the service.
+func toInput(input model.WebhookInput) webhook.Input {
+	return webhook.Input{
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Secret:     input.Secret,
+		Active:     input.Active == nil || *input.Active,
+	}
+}
+
+// toWebhook converts a subscription to its response, without its secret.
+func toWebhook(sub *internalModel.WebhookSubscription) model.Webhook {
+	return model.Webhook{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: webhook.EventTypesOf(sub),
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+		UpdatedAt:  sub.UpdatedAt,
+	}
+}
+
+// toDelivery converts a delivery to its response.
+func toDelivery(d *internalModel.WebhookDelivery) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:            d.ID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+		DeliveredAt:   d.DeliveredAt,
+	}
+}
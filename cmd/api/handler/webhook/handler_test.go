@@ -0,0 +1,422 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/cmd/api/model"
+	internalModel "github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var adminID = uuid.New().String()
+
+func setupHandlerTest(ctrl *gomock.Controller) (*gin.Engine, *webhook.MockService) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := webhook.NewMockService(ctrl)
+	webhookHandler := &handler{service: mockService}
+
+	router := gin.New()
+	group := router.Group("/api/admin")
+	group.Use(func(c *gin.Context) {
+		c.Set("user_id", adminID)
+	})
+	{
+		group.POST("/webhooks", webhookHandler.Create)
+		group.GET("/webhooks", webhookHandler.List)
+		group.GET("/webhooks/:id", webhookHandler.Get)
+		group.PUT("/webhooks/:id", webhookHandler.Update)
+		group.DELETE("/webhooks/:id", webhookHandler.Delete)
+		group.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+		group.GET("/webhooks/:id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+		group.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+	}
+
+	return router, mockService
+}
+
+func TestNewHandler(t *testing.T) {
+	mockService := new(webhook.MockService)
+	webhookHandler := NewHandler(mockService)
+
+	assert.NotNil(t, webhookHandler)
+	assert.Equal(t, mockService, webhookHandler.(*handler).service)
+}
+
+func Test_handler_Create(t *testing.T) {
+	sub := &internalModel.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        "https://example.com/hooks",
+		EventTypes: "user.registered,user.deleted",
+		Secret:     "generated",
+		Active:     true,
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		mockFn   func(*webhook.MockService)
+		wantCode int
+	}{
+		{
+			name: "created active by default",
+			body: `{"url":"https://example.com/hooks","event_types":["user.registered","user.deleted"]}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Create(gomock.Any(), adminID, webhook.Input{
+					URL:        "https://example.com/hooks",
+					EventTypes: []string{"user.registered", "user.deleted"},
+					Active:     true,
+				}).Return(sub, nil)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name: "created inactive",
+			body: `{"url":"https://example.com/hooks","event_types":["user.registered"],"secret":"0123456789abcdef","active":false}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Create(gomock.Any(), adminID, webhook.Input{
+					URL:        "https://example.com/hooks",
+					EventTypes: []string{"user.registered"},
+					Secret:     "0123456789abcdef",
+				}).Return(sub, nil)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "missing url",
+			body:     `{"event_types":["user.registered"]}`,
+			mockFn:   func(*webhook.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "short secret",
+			body:     `{"url":"https://example.com","event_types":["user.registered"],"secret":"short"}`,
+			mockFn:   func(*webhook.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid event types",
+			body: `{"url":"https://example.com","event_types":["unknown"]}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Create(gomock.Any(), adminID, gomock.Any()).Return(nil, webhook.ErrInvalidEventTypes)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: `{"url":"https://example.com","event_types":["user.registered"]}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Create(gomock.Any(), adminID, gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			tt.mockFn(mockService)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/webhooks", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusCreated {
+				var res model.CreatedWebhook
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, sub.ID, res.ID)
+				assert.Equal(t, []string{"user.registered", "user.deleted"}, res.EventTypes)
+				assert.Equal(t, "generated", res.Secret)
+			}
+		})
+	}
+}
+
+func Test_handler_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	router, mockService := setupHandlerTest(ctrl)
+	mockService.EXPECT().List(gomock.Any()).Return([]internalModel.WebhookSubscription{
+		{ID: uuid.New(), URL: "https://example.com", EventTypes: "user.deleted", Secret: "secret", Active: true},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/webhooks", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+	var res []model.Webhook
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res, 1)
+	assert.Equal(t, []string{"user.deleted"}, res[0].EventTypes)
+}
+
+func Test_handler_Get(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		mockFn   func(*webhook.MockService)
+		wantCode int
+	}{
+		{
+			name: "found",
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Get(gomock.Any(), id.String()).Return(&internalModel.WebhookSubscription{ID: id, Secret: "secret"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "not found",
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Get(gomock.Any(), id.String()).Return(nil, webhook.ErrNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			tt.mockFn(mockService)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/"+id.String(), nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.NotContains(t, w.Body.String(), "secret")
+		})
+	}
+}
+
+func Test_handler_Update(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		body     string
+		mockFn   func(*webhook.MockService)
+		wantCode int
+	}{
+		{
+			name: "updated",
+			body: `{"url":"https://example.com","event_types":["user.registered"],"active":false}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Update(gomock.Any(), adminID, id.String(), webhook.Input{
+					URL:        "https://example.com",
+					EventTypes: []string{"user.registered"},
+				}).Return(&internalModel.WebhookSubscription{ID: id, Secret: "secret"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "not found",
+			body: `{"url":"https://example.com","event_types":["user.registered"]}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Update(gomock.Any(), adminID, id.String(), gomock.Any()).Return(nil, webhook.ErrNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "invalid url",
+			body: `{"url":"example.com","event_types":["user.registered"]}`,
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Update(gomock.Any(), adminID, id.String(), gomock.Any()).Return(nil, webhook.ErrInvalidURL)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid body",
+			body:     `{"url":`,
+			mockFn:   func(*webhook.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			tt.mockFn(mockService)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/webhooks/"+id.String(), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.NotContains(t, w.Body.String(), "secret")
+		})
+	}
+}
+
+func Test_handler_Delete(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "deleted", wantCode: http.StatusNoContent},
+		{name: "not found", err: webhook.ErrNotFound, wantCode: http.StatusNotFound},
+		{name: "service error", err: errors.New("database error"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			mockService.EXPECT().Delete(gomock.Any(), adminID, id).Return(tt.err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/admin/webhooks/"+id, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func Test_handler_ListDeliveries(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := []struct {
+		name     string
+		query    string
+		mockFn   func(*webhook.MockService)
+		wantCode int
+	}{
+		{
+			name: "default filter",
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Deliveries(gomock.Any(), id, "", defaultLimit).
+					Return([]internalModel.WebhookDelivery{{ID: uuid.New(), Status: webhook.StatusSucceeded}}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:  "status and limit",
+			query: "?status=failed&limit=5",
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Deliveries(gomock.Any(), id, webhook.StatusFailed, 5).Return(nil, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unknown status",
+			query:    "?status=dead",
+			mockFn:   func(*webhook.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "limit too high",
+			query:    "?limit=501",
+			mockFn:   func(*webhook.MockService) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown subscription",
+			mockFn: func(ms *webhook.MockService) {
+				ms.EXPECT().Deliveries(gomock.Any(), id, "", defaultLimit).Return(nil, webhook.ErrNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			tt.mockFn(mockService)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/"+id+"/deliveries"+tt.query, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func Test_handler_GetDelivery(t *testing.T) {
+	id, deliveryID := uuid.New().String(), uuid.New()
+	now := time.Now()
+	delivery := &internalModel.WebhookDelivery{
+		ID:        deliveryID,
+		EventType: "user.deleted",
+		Payload:   `{"id":"1","type":"user.deleted"}`,
+		Status:    webhook.StatusPending,
+		Attempts:  1,
+		LastError: "unexpected response status 503",
+	}
+	attempts := []internalModel.WebhookAttempt{{StatusCode: 503, Error: "unexpected response status 503", DurationMS: 20, CreatedAt: now}}
+
+	t.Run("found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, mockService := setupHandlerTest(ctrl)
+		mockService.EXPECT().Delivery(gomock.Any(), id, deliveryID.String()).Return(delivery, attempts, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/"+id+"/deliveries/"+deliveryID.String(), nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var res model.WebhookDeliveryDetail
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, deliveryID, res.ID)
+		assert.JSONEq(t, delivery.Payload, string(res.Payload))
+		assert.Len(t, res.Log, 1)
+		assert.Equal(t, 503, res.Log[0].StatusCode)
+		assert.Equal(t, 503, res.Log[0].StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, mockService := setupHandlerTest(ctrl)
+		mockService.EXPECT().Delivery(gomock.Any(), id, "x").Return(nil, nil, webhook.ErrDeliveryNotFound)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/"+id+"/deliveries/x", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func Test_handler_Redeliver(t *testing.T) {
+	id, deliveryID := uuid.New().String(), uuid.New().String()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "rescheduled", wantCode: http.StatusAccepted},
+		{name: "not found", err: webhook.ErrDeliveryNotFound, wantCode: http.StatusNotFound},
+		{name: "pending", err: webhook.ErrDeliveryPending, wantCode: http.StatusConflict},
+		{name: "service error", err: errors.New("database error"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			router, mockService := setupHandlerTest(ctrl)
+			mockService.EXPECT().Redeliver(gomock.Any(), adminID, id, deliveryID).Return(tt.err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/webhooks/"+id+"/deliveries/"+deliveryID+"/redeliver", nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
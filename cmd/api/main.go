@@ -2,68 +2,315 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
-	"log"
-
 	"github.com/PakornBank/go-backend-example/cmd/api/di"
 	"github.com/PakornBank/go-backend-example/cmd/api/routes"
+	"github.com/PakornBank/go-backend-example/internal/auth"
+	"github.com/PakornBank/go-backend-example/internal/common/buildinfo"
 	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/job"
+	"github.com/PakornBank/go-backend-example/internal/user"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	cfg, err := config.LoadConfig()
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	showVersion := flags.Bool("version", false, "print version information and exit")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration with secrets masked and exit")
+	migrateEmails := flags.String("migrate-emails", "", "normalize stored email addresses and exit: report or apply")
+	listJobs := flags.String("jobs", "", "print background jobs and exit: stats, pending, failed or succeeded")
+	retentionReport := flags.Bool("retention-report", false, "print the accounts the next run of the retention policy handles and exit")
+	loader := config.NewLoader(flags)
+	_ = flags.Parse(os.Args[1:])
+
+	if *showVersion {
+		fmt.Println(buildinfo.Get())
+		return
+	}
+
+	cfg, err := loader.Load()
 	if err != nil {
 		log.Fatal("failed to load config: ", err)
 	}
 
+	if *printConfig {
+		if err := cfg.Dump(os.Stdout); err != nil {
+			log.Fatal("failed to print config: ", err)
+		}
+		return
+	}
+
+	if *migrateEmails != "" {
+		if err := runEmailMigration(cfg, *migrateEmails); err != nil {
+			log.Fatal("email migration: ", err)
+		}
+		return
+	}
+
+	if *listJobs != "" {
+		if err := runJobReport(cfg, *listJobs); err != nil {
+			log.Fatal("jobs: ", err)
+		}
+		return
+	}
+
+	if *retentionReport {
+		if err := runRetentionReport(cfg); err != nil {
+			log.Fatal("retention report: ", err)
+		}
+		return
+	}
+
 	container := di.NewContainer(cfg)
+	logger := container.Logger
+	slog.SetDefault(logger)
+
+	info := buildinfo.Get()
+	logger.Info("starting go-auth-api",
+		slog.String("version", info.Version),
+		slog.String("commit_sha", info.CommitSHA),
+		slog.String("build_date", info.BuildDate),
+		slog.String("go_version", info.GoVersion),
+	)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logger.Error("failed to set up tracing", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Pick up rotated secrets and, when enabled, configuration file changes without a restart
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go loader.Watch(watchCtx, container.ConfigStore, cfg.SecretsRefreshInterval, logger)
+
+	// Reload the configuration on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logger.Info("received SIGHUP, reloading configuration")
+			loader.Reload(container.ConfigStore, logger)
+		}
+	}()
+
+	// Run the background workers, such as the delivery of domain events
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	for _, run := range container.Workers() {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
 
 	gin.SetMode(cfg.GinMode)
 
-	r := gin.Default()
+	r := gin.New()
 	routes.SetupRoutes(r, container)
 
 	srv := &http.Server{
-		Addr:    ":" + cfg.ServerPort,
-		Handler: r,
+		Addr:     ":" + cfg.ServerPort,
+		Handler:  r,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
 	// Start the server in a goroutine
 	go func() {
-		log.Printf("Server running on port %s\n", cfg.ServerPort)
+		logger.Info("server running", slog.String("port", cfg.ServerPort))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("failed to start server: %v", err)
+			logger.Error("failed to start server", slog.Any("error", err))
+			os.Exit(1)
 		}
 	}()
 
+	// Serve metrics on a separate admin port when configured
+	var adminSrv *http.Server
+	if cfg.MetricsPort != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/metrics", container.Metrics.Handler())
+		adminSrv = &http.Server{
+			Addr:     ":" + cfg.MetricsPort,
+			Handler:  adminMux,
+			ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		}
+
+		go func() {
+			logger.Info("metrics server running", slog.String("port", cfg.MetricsPort))
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("failed to start metrics server", slog.Any("error", err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shut down the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	logger.Info("shutting down server")
+	stopWatching()
+	signal.Stop(hup)
+
+	// Fail readiness first so load balancers stop routing new requests
+	container.Health.SetShuttingDown()
+	time.Sleep(cfg.ShutdownDrainDelay)
 
 	// Create a deadline to wait for
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	// Shut down the server
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown: ", err)
+		logger.Error("server forced to shutdown", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			logger.Error("metrics server forced to shutdown", slog.Any("error", err))
+		}
+	}
+
+	// Let the workers finish what they are doing
+	stopWorkers()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		logger.Error("background workers forced to stop")
+	}
+
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("failed to shut down tracing", slog.Any("error", err))
 	}
 
 	// Close database connections
 	if db, err := container.GetDB(); err == nil {
-		sqlDB, err := db.DB()
-		if err == nil {
-			sqlDB.Close()
+		if err := database.Close(db); err != nil {
+			logger.Error("failed to close database", slog.Any("error", err))
+		}
+	}
+
+	logger.Info("server exiting")
+}
+
+// runEmailMigration normalizes the stored email addresses. The database is
+// opened without running the schema migration, because the case-insensitive
+// unique index cannot be created while colliding addresses remain.
+func runEmailMigration(cfg *config.Config, mode string) error {
+	if mode != "report" && mode != "apply" {
+		return fmt.Errorf("mode must be report or apply, got %q", mode)
+	}
+	apply := mode == "apply"
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	report, err := auth.NormalizeEmails(context.Background(), db, email.NewNormalizer(cfg), apply)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(os.Stdout, apply); err != nil {
+		return err
+	}
+	if len(report.Collisions) > 0 || len(report.Invalid) > 0 {
+		return fmt.Errorf("%d collisions and %d invalid addresses need manual resolution",
+			len(report.Collisions), len(report.Invalid))
+	}
+	return nil
+}
+
+// runJobReport prints the number of jobs by type and status, or the most
+// recently updated jobs in the given status.
+func runJobReport(cfg *config.Config, mode string) error {
+	if mode != "stats" && !slices.Contains([]string{job.StatusPending, job.StatusFailed, job.StatusSucceeded}, mode) {
+		return fmt.Errorf("mode must be stats, pending, failed or succeeded, got %q", mode)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	repository := job.NewRepository(db, cfg.RepositoryTimeout)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if mode == "stats" {
+		stats, err := repository.Stats(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "TYPE\tSTATUS\tCOUNT")
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", s.Type, s.Status, s.Count)
 		}
+		return w.Flush()
+	}
+
+	jobs, err := repository.List(context.Background(), job.Filter{Status: mode, Limit: 100})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "ID\tTYPE\tATTEMPTS\tRUN AT\tLAST ERROR")
+	for _, j := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", j.ID, j.Type, j.Attempts, j.MaxAttempts, j.RunAt.UTC().Format(time.RFC3339), j.LastError)
+	}
+	return w.Flush()
+}
+
+// runRetentionReport prints the inactive accounts that the next run of the
+// retention policy handles and what it does to them, without changing them.
+func runRetentionReport(cfg *config.Config) error {
+	if cfg.RetentionInactiveMonths == 0 {
+		return fmt.Errorf("retention_inactive_months is not set, accounts are kept forever")
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
 	}
+	defer database.Close(db)
 
-	log.Println("Server exiting")
+	retention := user.NewRetention(user.NewRepository(db, cfg.RepositoryTimeout), nil, nil, nil, nil, config.NewStore(cfg))
+	candidates, err := retention.Candidates(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tLAST ACTIVE\tNOTIFIED\tACTION")
+	for _, c := range candidates {
+		notified := "-"
+		if c.RetentionNoticeAt != nil {
+			notified = c.RetentionNoticeAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.ID, c.Email, c.LastActiveAt.UTC().Format(time.RFC3339), notified, c.Action)
+	}
+	return w.Flush()
 }
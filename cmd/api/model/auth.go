@@ -3,7 +3,7 @@ package model
 // RegisterInput is a struct that contains the input fields for the Register method.
 type RegisterInput struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
 	FullName string `json:"full_name" binding:"required"`
 }
 
@@ -12,3 +12,20 @@ type LoginInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
+
+// ChangePasswordInput is a struct that contains the input fields for the ChangePassword method.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailInput is a struct that contains the input fields for the ChangeEmail method.
+type ChangeEmailInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccountInput is a struct that contains the input fields for the DeleteAccount method.
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
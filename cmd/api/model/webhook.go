@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookInput is a struct that contains the input fields for the Create and Update methods of webhooks.
+type WebhookInput struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	// Secret signs the deliveries. It is generated when omitted on creation and kept when omitted on update.
+	Secret string `json:"secret" binding:"omitempty,min=16,max=255"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+// Webhook represents a webhook subscription data response.
+type Webhook struct {
+	ID         uuid.UUID `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CreatedWebhook represents a newly created webhook subscription data response, the only one holding its secret.
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery represents a webhook delivery data response.
+type WebhookDelivery struct {
+	ID            uuid.UUID  `json:"id"`
+	EventID       uuid.UUID  `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryDetail represents a webhook delivery data response with its payload and log of attempts.
+type WebhookDeliveryDetail struct {
+	WebhookDelivery
+	Payload json.RawMessage  `json:"payload"`
+	Log     []WebhookAttempt `json:"log"`
+}
+
+// WebhookAttempt represents a logged webhook request data response.
+type WebhookAttempt struct {
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/audit"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/job"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// registerAdminRoutes registers the admin routes with the provided gin routes group, handlers,
+// authentication middleware and admin authorization middleware.
+func registerAdminRoutes(r *gin.RouterGroup, h audit.Handler, wh webhook.Handler, jh job.Handler, authenticate, requireAdmin gin.HandlerFunc) {
+	adminRoutes := r.Group("/admin")
+	adminRoutes.Use(authenticate, requireAdmin)
+	{
+		adminRoutes.GET("/audit-events", h.List)
+		adminRoutes.GET("/audit-events/export", h.Export)
+		adminRoutes.GET("/audit-events/verify", h.Verify)
+
+		adminRoutes.POST("/webhooks", wh.Create)
+		adminRoutes.GET("/webhooks", wh.List)
+		adminRoutes.GET("/webhooks/:id", wh.Get)
+		adminRoutes.PUT("/webhooks/:id", wh.Update)
+		adminRoutes.DELETE("/webhooks/:id", wh.Delete)
+		adminRoutes.GET("/webhooks/:id/deliveries", wh.ListDeliveries)
+		adminRoutes.GET("/webhooks/:id/deliveries/:delivery_id", wh.GetDelivery)
+		adminRoutes.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", wh.Redeliver)
+
+		adminRoutes.GET("/jobs", jh.List)
+		adminRoutes.GET("/jobs/stats", jh.Stats)
+		adminRoutes.GET("/jobs/:id", jh.Get)
+		adminRoutes.POST("/jobs/:id/retry", jh.Retry)
+	}
+}
@@ -5,11 +5,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// registerAuthRoutes registers the auth routes with the provided gin routes group and handler.
-func registerAuthRoutes(r *gin.RouterGroup, h auth.Handler) {
+// registerAuthRoutes registers the auth routes with the provided gin routes group, handler and
+// authentication middleware.
+func registerAuthRoutes(r *gin.RouterGroup, h auth.Handler, authenticate gin.HandlerFunc) {
 	authRoutes := r.Group("/auth")
 	{
 		authRoutes.POST("/register", h.Register)
 		authRoutes.POST("/login", h.Login)
+
+		protected := authRoutes.Group("")
+		protected.Use(authenticate)
+		{
+			protected.POST("/password", h.ChangePassword)
+		}
 	}
 }
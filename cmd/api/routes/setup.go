@@ -2,14 +2,35 @@ package routes
 
 import (
 	"github.com/PakornBank/go-backend-example/cmd/api/di"
+	"github.com/PakornBank/go-backend-example/internal/common/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes call functions to register routes on gin routes.
+// SetupRoutes registers global middleware and calls functions to register routes on gin routes.
 func SetupRoutes(router *gin.Engine, container *di.Container) {
-	router.GET("/health", container.HealthHandler.Check)
+	router.Use(
+		gin.Recovery(),
+		middleware.RequestID(),
+		middleware.ClientIP(),
+		middleware.Tracing(),
+		middleware.Logger(container.Logger),
+		middleware.Metrics(container.Metrics),
+		middleware.ReadYourWrites(),
+	)
+
+	router.GET("/livez", container.HealthHandler.Live)
+	router.GET("/readyz", container.HealthHandler.Ready)
+	router.GET("/startupz", container.HealthHandler.Started)
+	router.GET("/health", container.HealthHandler.Ready)
+	router.GET("/version", container.BuildHandler.Version)
+	if container.Config.MetricsPort == "" {
+		router.GET("/metrics", gin.WrapH(container.Metrics.Handler()))
+	}
+
+	authenticate := middleware.Auth(container.ConfigStore, container.Sessions)
 
 	group := router.Group("/api")
-	registerAuthRoutes(group, container.AuthHandler)
-	registerUserRoutes(group, container.UserHandler, container.Config)
+	registerAuthRoutes(group, container.AuthHandler, authenticate)
+	registerUserRoutes(group, container.UserHandler, container.SessionHandler, container.LoginHistoryHandler, authenticate)
+	registerAdminRoutes(group, container.AuditHandler, container.WebhookHandler, container.JobHandler, authenticate, middleware.RequireAdmin(container.ConfigStore))
 }
@@ -1,20 +1,26 @@
 package routes
 
 import (
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/loginhistory"
+	"github.com/PakornBank/go-backend-example/cmd/api/handler/session"
 	"github.com/PakornBank/go-backend-example/cmd/api/handler/user"
-	"github.com/PakornBank/go-backend-example/internal/common/config"
-	"github.com/PakornBank/go-backend-example/internal/common/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-// registerUserRoutes registers the user routes with the provided gin routes group and handler.
-func registerUserRoutes(r *gin.RouterGroup, h user.Handler, cfg *config.Config) {
+// registerUserRoutes registers the user routes with the provided gin routes group, handlers and
+// authentication middleware.
+func registerUserRoutes(r *gin.RouterGroup, h user.Handler, sh session.Handler, lh loginhistory.Handler, authenticate gin.HandlerFunc) {
 	userRoutes := r.Group("/user")
 	{
 		protected := userRoutes.Group("")
-		protected.Use(middleware.Auth(cfg.JWTSecret))
+		protected.Use(authenticate)
 		{
 			protected.GET("/profile", h.GetProfile)
+			protected.DELETE("/profile", h.DeleteAccount)
+			protected.PUT("/email", h.ChangeEmail)
+			protected.GET("/sessions", sh.List)
+			protected.DELETE("/sessions/:id", sh.Revoke)
+			protected.GET("/login-history", lh.List)
 		}
 	}
 }
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionUserRegistered  = "user.registered"
+	ActionLoginSucceeded  = "user.login_succeeded"
+	ActionLoginFailed     = "user.login_failed"
+	ActionPasswordChanged = "user.password_changed"
+	ActionEmailChanged    = "user.email_changed"
+	ActionUserDeleted     = "user.deleted"
+	ActionUserDisabled    = "user.disabled"
+	ActionRetentionNotice = "user.retention_notified"
+	ActionSessionRevoked  = "session.revoked"
+	ActionAuditQueried    = "audit.queried"
+	ActionAuditExported   = "audit.exported"
+	ActionWebhookCreated  = "webhook.created"
+	ActionWebhookUpdated  = "webhook.updated"
+	ActionWebhookDeleted  = "webhook.deleted"
+	ActionWebhookResent   = "webhook.redelivered"
+	ActionJobRetried      = "job.retried"
+)
+
+// Types of the targets of actions.
+const (
+	TargetUser    = "user"
+	TargetSession = "session"
+	TargetAudit   = "audit"
+	TargetWebhook = "webhook"
+	TargetJob     = "job"
+)
+
+// genesisHash is the previous hash of the first event.
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Event describes an action to record. The client IP and request ID are taken from the context.
+type Event struct {
+	// ActorID is the ID of the user performing the action, empty when unauthenticated.
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Metadata   map[string]interface{}
+}
+
+// Filter selects audit events. Zero fields match every event.
+type Filter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	// From and To bound the creation time of events, From inclusive and To exclusive.
+	From time.Time
+	To   time.Time
+	// BeforeSeq only matches events older than the event with this sequence number, for paging.
+	BeforeSeq int64
+	// Limit is the maximum number of events returned by Query.
+	Limit int
+}
+
+// hashInput is the canonical form of an event that its hash is computed over.
+type hashInput struct {
+	Seq        int64  `json:"seq"`
+	PrevHash   string `json:"prev_hash"`
+	ActorID    string `json:"actor_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	IP         string `json:"ip"`
+	RequestID  string `json:"request_id"`
+	Metadata   string `json:"metadata"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Hash returns the hex SHA-256 hash of the canonical JSON encoding of e,
+// which includes the hash of the previous event but not e.Hash itself.
+func Hash(e *model.AuditEvent) string {
+	input := hashInput{
+		Seq:        e.Seq,
+		PrevHash:   e.PrevHash,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         e.IP,
+		RequestID:  e.RequestID,
+		Metadata:   e.Metadata,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if e.ActorID != nil {
+		input.ActorID = e.ActorID.String()
+	}
+
+	// Marshaling a struct of strings and an integer cannot fail.
+	data, _ := json.Marshal(input)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
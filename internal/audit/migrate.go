@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// appendOnlySQL installs a trigger rejecting updates, deletes and truncation
+// of the audit_events table. The owner of the table can still drop the
+// trigger, which the hash chain then exposes.
+const appendOnlySQL = `
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+`
+
+// Migrate makes the audit_events table, created by database.NewDataBase, append-only.
+func Migrate(db *gorm.DB) error {
+	if err := db.Exec(appendOnlySQL).Error; err != nil {
+		return fmt.Errorf("failed to make audit log append-only: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+)
+
+// appendLockKey is the key of the advisory lock serializing appends, so that
+// every event is chained to the one appended before it.
+const appendLockKey = 0x61756469746c6f67 // "auditlog"
+
+// batchSize is the number of events read at once by Each.
+const batchSize = 500
+
+//go:generate mockgen -destination=./repository_mock.go -package=audit github.com/PakornBank/go-backend-example/internal/audit Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	LockAppend(ctx context.Context) error
+	Last(ctx context.Context) (*model.AuditEvent, error)
+	Create(ctx context.Context, event *model.AuditEvent) error
+	Find(ctx context.Context, filter Filter) ([]model.AuditEvent, error)
+	Each(ctx context.Context, filter Filter, fn func([]model.AuditEvent) error) error
+}
+
+// repository is a struct that provides methods to interact with the audit events in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// LockAppend takes the lock serializing appends until the end of the
+// transaction carried by ctx. It must be called within a transaction.
+func (r *repository) LockAppend(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", int64(appendLockKey)).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to lock audit log", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// Last retrieves the most recent audit event from the primary, or nil when there is none.
+func (r *repository) Last(ctx context.Context) (*model.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var event model.AuditEvent
+
+	err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).Order("seq DESC").First(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find last audit event", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// Create inserts a new audit event record into the database.
+func (r *repository) Create(ctx context.Context, event *model.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(event).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to create audit event", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// Find retrieves the audit events matching filter, newest first, at most filter.Limit of them.
+func (r *repository) Find(ctx context.Context, filter Filter) ([]model.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var events []model.AuditEvent
+
+	query := applyFilter(database.Conn(ctx, r.db).WithContext(ctx), filter)
+	if err := query.Order("seq DESC").Limit(filter.Limit).Find(&events).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to find audit events", slog.Any("error", err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Each calls fn with the audit events matching filter, oldest first, in
+// batches. filter.Limit is ignored. Every batch is read with its own timeout.
+func (r *repository) Each(ctx context.Context, filter Filter, fn func([]model.AuditEvent) error) error {
+	var afterSeq int64
+	for {
+		events, err := r.batch(ctx, filter, afterSeq)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		if err := fn(events); err != nil {
+			return err
+		}
+		if len(events) < batchSize {
+			return nil
+		}
+		afterSeq = events[len(events)-1].Seq
+	}
+}
+
+// batch retrieves the next batch of audit events matching filter after the event with sequence number afterSeq.
+func (r *repository) batch(ctx context.Context, filter Filter, afterSeq int64) ([]model.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var events []model.AuditEvent
+
+	query := applyFilter(database.Conn(ctx, r.db).WithContext(ctx), filter)
+	if err := query.Where("seq > ?", afterSeq).Order("seq").Limit(batchSize).Find(&events).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to read audit events", slog.Any("error", err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// applyFilter adds the conditions of filter to query.
+func applyFilter(query *gorm.DB, filter Filter) *gorm.DB {
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.BeforeSeq > 0 {
+		query = query.Where("seq < ?", filter.BeforeSeq)
+	}
+	return query
+}
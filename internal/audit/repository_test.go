@@ -0,0 +1,281 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	auditRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, auditRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	auditRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, auditRepo)
+	assert.Equal(t, gormDB, auditRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, auditRepo.(*repository).timeout)
+}
+
+func Test_repository_LockAppend(t *testing.T) {
+	sqlMock, auditRepo := setupRepositoryTest(t)
+	sqlMock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+		WithArgs(int64(appendLockKey)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, auditRepo.LockAppend(context.Background()))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_Last(t *testing.T) {
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantSeq int64
+		errType error
+	}{
+		{
+			name: "last event found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events" ORDER BY seq DESC,"audit_events"."seq" LIMIT \$1`).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"seq", "hash"}).AddRow(7, "hash"))
+			},
+			wantSeq: 7,
+		},
+		{
+			name: "empty log",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events"`).WillReturnRows(sqlmock.NewRows([]string{"seq"}))
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, auditRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := auditRepo.Last(context.Background())
+
+			switch {
+			case tt.errType != nil:
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			case tt.wantSeq == 0:
+				assert.NoError(t, err)
+				assert.Nil(t, got)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantSeq, got.Seq)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Create(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "event created",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`INSERT INTO "audit_events"`).
+					WithArgs(int64(1), nil, ActionUserRegistered, TargetUser, "target", "192.0.2.1", "request", "{}", now, genesisHash, "hash").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`INSERT INTO "audit_events"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, auditRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := auditRepo.Create(context.Background(), &model.AuditEvent{
+				Seq:        1,
+				Action:     ActionUserRegistered,
+				TargetType: TargetUser,
+				TargetID:   "target",
+				IP:         "192.0.2.1",
+				RequestID:  "request",
+				Metadata:   "{}",
+				CreatedAt:  now,
+				PrevHash:   genesisHash,
+				Hash:       "hash",
+			})
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Find(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		mockFn  func(sqlmock.Sqlmock)
+		wantLen int
+		errType error
+	}{
+		{
+			name:   "no filter",
+			filter: Filter{Limit: 10},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events" ORDER BY seq DESC LIMIT \$1`).
+					WithArgs(10).
+					WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(2).AddRow(1))
+			},
+			wantLen: 2,
+		},
+		{
+			name: "every filter",
+			filter: Filter{
+				ActorID:    "actor",
+				Action:     ActionLoginFailed,
+				TargetType: TargetUser,
+				TargetID:   "target",
+				From:       from,
+				To:         to,
+				BeforeSeq:  50,
+				Limit:      10,
+			},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events" WHERE actor_id = \$1 AND action = \$2 AND target_type = \$3 AND target_id = \$4 AND created_at >= \$5 AND created_at < \$6 AND seq < \$7 ORDER BY seq DESC LIMIT \$8`).
+					WithArgs("actor", ActionLoginFailed, TargetUser, "target", from, to, int64(50), 10).
+					WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(49))
+			},
+			wantLen: 1,
+		},
+		{
+			name:   "database error",
+			filter: Filter{Limit: 10},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, auditRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := auditRepo.Find(context.Background(), tt.filter)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Each(t *testing.T) {
+	fullBatch := sqlmock.NewRows([]string{"seq"})
+	for seq := 1; seq <= batchSize; seq++ {
+		fullBatch.AddRow(seq)
+	}
+
+	tests := []struct {
+		name        string
+		mockFn      func(sqlmock.Sqlmock)
+		wantBatches int
+		errType     error
+	}{
+		{
+			name: "one partial batch",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events" WHERE action = \$1 AND seq > \$2 ORDER BY seq LIMIT \$3`).
+					WithArgs(ActionLoginFailed, int64(0), batchSize).
+					WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(1).AddRow(2))
+			},
+			wantBatches: 1,
+		},
+		{
+			name: "full batch followed by an empty one",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events"`).
+					WithArgs(ActionLoginFailed, int64(0), batchSize).
+					WillReturnRows(fullBatch)
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events"`).
+					WithArgs(ActionLoginFailed, int64(batchSize), batchSize).
+					WillReturnRows(sqlmock.NewRows([]string{"seq"}))
+			},
+			wantBatches: 1,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "audit_events"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, auditRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			batches := 0
+			err := auditRepo.Each(context.Background(), Filter{Action: ActionLoginFailed}, func([]model.AuditEvent) error {
+				batches++
+				return nil
+			})
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantBatches, batches)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
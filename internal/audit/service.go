@@ -0,0 +1,226 @@
+// Package audit keeps a tamper-evident, append-only log of security-relevant events.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/clientip"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errChainBroken stops Verify at the first broken link.
+var errChainBroken = errors.New("audit chain broken")
+
+// ExportedEvent is the JSON form of an audit event written by Export, one per
+// line. It holds every field the hash covers, so the chain can be verified
+// from an export alone.
+type ExportedEvent struct {
+	Seq        int64           `json:"seq"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	Metadata   json.RawMessage `json:"metadata"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// Verification is the result of checking the hash chain.
+type Verification struct {
+	Valid    bool   `json:"valid"`
+	Events   int64  `json:"events"`
+	HeadSeq  int64  `json:"head_seq"`
+	HeadHash string `json:"head_hash"`
+	// BrokenSeq is the sequence number at which the chain breaks, and Problem describes how.
+	BrokenSeq int64  `json:"broken_seq,omitempty"`
+	Problem   string `json:"problem,omitempty"`
+}
+
+//go:generate mockgen -destination=./service_mock.go -package=audit github.com/PakornBank/go-backend-example/internal/audit Service
+
+// Service defines the methods that a service must implement.
+type Service interface {
+	Record(ctx context.Context, event Event) error
+	Query(ctx context.Context, filter Filter) ([]model.AuditEvent, error)
+	Export(ctx context.Context, filter Filter, w io.Writer) error
+	Verify(ctx context.Context) (*Verification, error)
+}
+
+// service is a struct that provides methods to interact with the audit service.
+type service struct {
+	repository Repository
+	tx         database.TxManager
+}
+
+// NewService creates a new instance of service with the provided repository and transaction manager.
+func NewService(repository Repository, tx database.TxManager) Service {
+	return &service{repository: repository, tx: tx}
+}
+
+// Record appends event to the audit log, chained to the last event. When ctx
+// carries a transaction the event is only kept if the transaction commits.
+func (s *service) Record(ctx context.Context, event Event) error {
+	ctx, span := tracing.Start(ctx, "audit.Service.Record")
+	defer span.End()
+	span.SetAttributes(attribute.String("audit.action", event.Action))
+
+	entry := &model.AuditEvent{
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		IP:         clientip.FromContext(ctx),
+		RequestID:  requestid.FromContext(ctx),
+		Metadata:   "{}",
+	}
+	if event.ActorID != "" {
+		actorID, err := uuid.Parse(event.ActorID)
+		if err != nil {
+			return fmt.Errorf("invalid audit actor %q: %w", event.ActorID, err)
+		}
+		entry.ActorID = &actorID
+	}
+	if len(event.Metadata) > 0 {
+		metadata, err := json.Marshal(event.Metadata)
+		if err != nil {
+			return fmt.Errorf("invalid audit metadata: %w", err)
+		}
+		entry.Metadata = string(metadata)
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.LockAppend(ctx); err != nil {
+			return err
+		}
+		last, err := s.repository.Last(ctx)
+		if err != nil {
+			return err
+		}
+
+		entry.Seq, entry.PrevHash = 1, genesisHash
+		if last != nil {
+			entry.Seq, entry.PrevHash = last.Seq+1, last.Hash
+		}
+		// Postgres keeps microseconds, the hash must cover the stored time.
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		entry.Hash = Hash(entry)
+		return s.repository.Create(ctx, entry)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// Query returns the events matching filter, newest first.
+func (s *service) Query(ctx context.Context, filter Filter) ([]model.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "audit.Service.Query")
+	defer span.End()
+
+	events, err := s.repository.Find(ctx, filter)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Export writes the events matching filter to w as JSON lines, oldest first.
+func (s *service) Export(ctx context.Context, filter Filter, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "audit.Service.Export")
+	defer span.End()
+
+	enc := json.NewEncoder(w)
+	err := s.repository.Each(ctx, filter, func(events []model.AuditEvent) error {
+		for i := range events {
+			if err := enc.Encode(NewExportedEvent(&events[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// Verify walks the whole log, oldest first, and checks that sequence numbers
+// have no gaps, that every event points at the hash of the previous one and
+// that every hash matches its event. Deleting the most recent events cannot
+// be detected this way; compare HeadSeq and HeadHash with an earlier result.
+func (s *service) Verify(ctx context.Context) (*Verification, error) {
+	ctx, span := tracing.Start(ctx, "audit.Service.Verify")
+	defer span.End()
+
+	result := &Verification{Valid: true, HeadHash: genesisHash}
+	err := s.repository.Each(ctx, Filter{}, func(events []model.AuditEvent) error {
+		for i := range events {
+			e := &events[i]
+			switch {
+			case e.Seq != result.HeadSeq+1:
+				result.Problem = fmt.Sprintf("events %d to %d are missing", result.HeadSeq+1, e.Seq-1)
+			case e.PrevHash != result.HeadHash:
+				result.Problem = "previous hash does not match the previous event"
+			case Hash(e) != e.Hash:
+				result.Problem = "hash does not match the event"
+			default:
+				result.Events++
+				result.HeadSeq, result.HeadHash = e.Seq, e.Hash
+				continue
+			}
+			result.Valid, result.BrokenSeq = false, e.Seq
+			return errChainBroken
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Bool("audit.valid", result.Valid))
+	return result, nil
+}
+
+// NewExportedEvent returns the exported form of e. Metadata that is not valid
+// JSON, which only a tampered event can hold, is exported as a JSON string.
+func NewExportedEvent(e *model.AuditEvent) ExportedEvent {
+	exported := ExportedEvent{
+		Seq:        e.Seq,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         e.IP,
+		RequestID:  e.RequestID,
+		CreatedAt:  e.CreatedAt.UTC(),
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+	if e.ActorID != nil {
+		exported.ActorID = e.ActorID.String()
+	}
+	if json.Valid([]byte(e.Metadata)) {
+		exported.Metadata = json.RawMessage(e.Metadata)
+	} else {
+		exported.Metadata, _ = json.Marshal(e.Metadata)
+	}
+	return exported
+}
@@ -0,0 +1,335 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/clientip"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupServiceTest(t *testing.T) (Service, *MockRepository) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockRepository(ctrl)
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	return &service{repository: mockRepo, tx: mockTx}, mockRepo
+}
+
+// chain returns n events correctly chained to each other.
+func chain(n int) []model.AuditEvent {
+	events := make([]model.AuditEvent, n)
+	prev := genesisHash
+	for i := range events {
+		events[i] = model.AuditEvent{
+			Seq:        int64(i + 1),
+			Action:     ActionLoginSucceeded,
+			TargetType: TargetUser,
+			TargetID:   strconv.Itoa(i),
+			Metadata:   "{}",
+			CreatedAt:  time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+			PrevHash:   prev,
+		}
+		events[i].Hash = Hash(&events[i])
+		prev = events[i].Hash
+	}
+	return events
+}
+
+func TestNewService(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTx := new(database.MockTxManager)
+	auditService := NewService(mockRepo, mockTx)
+
+	assert.NotNil(t, auditService)
+	assert.Equal(t, mockRepo, auditService.(*service).repository)
+	assert.Equal(t, mockTx, auditService.(*service).tx)
+}
+
+func TestHash(t *testing.T) {
+	base := chain(1)[0]
+	assert.Len(t, base.Hash, 64)
+	assert.Equal(t, base.Hash, Hash(&base))
+
+	actorID := uuid.New()
+	changes := map[string]func(*model.AuditEvent){
+		"seq":        func(e *model.AuditEvent) { e.Seq++ },
+		"prev hash":  func(e *model.AuditEvent) { e.PrevHash = strings.Repeat("1", 64) },
+		"actor":      func(e *model.AuditEvent) { e.ActorID = &actorID },
+		"action":     func(e *model.AuditEvent) { e.Action = ActionLoginFailed },
+		"target":     func(e *model.AuditEvent) { e.TargetID = "other" },
+		"ip":         func(e *model.AuditEvent) { e.IP = "192.0.2.1" },
+		"request id": func(e *model.AuditEvent) { e.RequestID = "request" },
+		"metadata":   func(e *model.AuditEvent) { e.Metadata = `{"a":1}` },
+		"created at": func(e *model.AuditEvent) { e.CreatedAt = e.CreatedAt.Add(time.Microsecond) },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			e := base
+			change(&e)
+			assert.NotEqual(t, base.Hash, Hash(&e))
+		})
+	}
+}
+
+func Test_service_Record(t *testing.T) {
+	actorID := uuid.New()
+	last := chain(3)[2]
+
+	tests := []struct {
+		name         string
+		event        Event
+		mockFn       func(*MockRepository, *model.AuditEvent)
+		wantSeq      int64
+		wantPrevHash string
+		wantErr      bool
+	}{
+		{
+			name:  "first event chained to genesis",
+			event: Event{ActorID: actorID.String(), Action: ActionUserRegistered, TargetType: TargetUser, TargetID: actorID.String()},
+			mockFn: func(mr *MockRepository, created *model.AuditEvent) {
+				mr.EXPECT().LockAppend(gomock.Any()).Return(nil)
+				mr.EXPECT().Last(gomock.Any()).Return(nil, nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *model.AuditEvent) error {
+					*created = *e
+					return nil
+				})
+			},
+			wantSeq:      1,
+			wantPrevHash: genesisHash,
+		},
+		{
+			name:  "event chained to the last one",
+			event: Event{Action: ActionLoginFailed, TargetType: TargetUser, TargetID: "id", Metadata: map[string]interface{}{"reason": "wrong_password"}},
+			mockFn: func(mr *MockRepository, created *model.AuditEvent) {
+				mr.EXPECT().LockAppend(gomock.Any()).Return(nil)
+				mr.EXPECT().Last(gomock.Any()).Return(&last, nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *model.AuditEvent) error {
+					*created = *e
+					return nil
+				})
+			},
+			wantSeq:      4,
+			wantPrevHash: last.Hash,
+		},
+		{
+			name:    "invalid actor",
+			event:   Event{ActorID: "not-a-uuid", Action: ActionUserRegistered},
+			mockFn:  func(*MockRepository, *model.AuditEvent) {},
+			wantErr: true,
+		},
+		{
+			name:  "lock error",
+			event: Event{Action: ActionUserRegistered},
+			mockFn: func(mr *MockRepository, _ *model.AuditEvent) {
+				mr.EXPECT().LockAppend(gomock.Any()).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:  "create error",
+			event: Event{Action: ActionUserRegistered},
+			mockFn: func(mr *MockRepository, _ *model.AuditEvent) {
+				mr.EXPECT().LockAppend(gomock.Any()).Return(nil)
+				mr.EXPECT().Last(gomock.Any()).Return(nil, nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditService, mockRepo := setupServiceTest(t)
+			var created model.AuditEvent
+			tt.mockFn(mockRepo, &created)
+
+			ctx := requestid.WithContext(clientip.WithContext(context.Background(), "192.0.2.1"), "request-id")
+			err := auditService.Record(ctx, tt.event)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSeq, created.Seq)
+			assert.Equal(t, tt.wantPrevHash, created.PrevHash)
+			assert.Equal(t, Hash(&created), created.Hash)
+			assert.Equal(t, tt.event.Action, created.Action)
+			assert.Equal(t, "192.0.2.1", created.IP)
+			assert.Equal(t, "request-id", created.RequestID)
+
+			var metadata map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(created.Metadata), &metadata))
+			assert.Equal(t, len(tt.event.Metadata), len(metadata))
+		})
+	}
+}
+
+func Test_service_Query(t *testing.T) {
+	filter := Filter{Action: ActionLoginFailed, Limit: 10}
+
+	tests := []struct {
+		name    string
+		mockFn  func(*MockRepository)
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name: "events found",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Find(gomock.Any(), filter).Return(chain(2), nil)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "database error",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Find(gomock.Any(), filter).Return(nil, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditService, mockRepo := setupServiceTest(t)
+			tt.mockFn(mockRepo)
+
+			got, err := auditService.Query(context.Background(), filter)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, got, tt.wantLen)
+		})
+	}
+}
+
+func Test_service_Export(t *testing.T) {
+	events := chain(3)
+	events[2].Metadata = "not json"
+
+	auditService, mockRepo := setupServiceTest(t)
+	mockRepo.EXPECT().Each(gomock.Any(), Filter{}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ Filter, fn func([]model.AuditEvent) error) error {
+			if err := fn(events[:2]); err != nil {
+				return err
+			}
+			return fn(events[2:])
+		})
+
+	var buf bytes.Buffer
+	assert.NoError(t, auditService.Export(context.Background(), Filter{}, &buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	for i, line := range lines {
+		var exported ExportedEvent
+		assert.NoError(t, json.Unmarshal([]byte(line), &exported))
+		assert.Equal(t, events[i].Seq, exported.Seq)
+		assert.Equal(t, events[i].Hash, exported.Hash)
+	}
+	assert.Contains(t, lines[2], `"metadata":"not json"`)
+}
+
+func Test_service_Verify(t *testing.T) {
+	tests := []struct {
+		name          string
+		events        func() []model.AuditEvent
+		want          Verification
+		wantErr       bool
+		problemPrefix string
+	}{
+		{
+			name:   "empty log",
+			events: func() []model.AuditEvent { return nil },
+			want:   Verification{Valid: true, HeadHash: genesisHash},
+		},
+		{
+			name:   "valid chain",
+			events: func() []model.AuditEvent { return chain(3) },
+			want:   Verification{Valid: true, Events: 3, HeadSeq: 3, HeadHash: chain(3)[2].Hash},
+		},
+		{
+			name: "missing event",
+			events: func() []model.AuditEvent {
+				events := chain(3)
+				return append(events[:1], events[2])
+			},
+			want:          Verification{Events: 1, HeadSeq: 1, BrokenSeq: 3},
+			problemPrefix: "events 2 to 2 are missing",
+		},
+		{
+			name: "previous hash mismatch",
+			events: func() []model.AuditEvent {
+				events := chain(3)
+				events[1].PrevHash = strings.Repeat("1", 64)
+				events[1].Hash = Hash(&events[1])
+				return events
+			},
+			want:          Verification{Events: 1, HeadSeq: 1, BrokenSeq: 2},
+			problemPrefix: "previous hash",
+		},
+		{
+			name: "tampered event",
+			events: func() []model.AuditEvent {
+				events := chain(3)
+				events[2].Action = ActionPasswordChanged
+				return events
+			},
+			want:          Verification{Events: 2, HeadSeq: 2, BrokenSeq: 3},
+			problemPrefix: "hash does not match",
+		},
+		{
+			name:    "database error",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditService, mockRepo := setupServiceTest(t)
+			mockRepo.EXPECT().Each(gomock.Any(), Filter{}, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ Filter, fn func([]model.AuditEvent) error) error {
+					if tt.wantErr {
+						return errors.New("db error")
+					}
+					return fn(tt.events())
+				})
+
+			got, err := auditService.Verify(context.Background())
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Valid, got.Valid)
+			assert.Equal(t, tt.want.Events, got.Events)
+			assert.Equal(t, tt.want.HeadSeq, got.HeadSeq)
+			assert.Equal(t, tt.want.BrokenSeq, got.BrokenSeq)
+			assert.True(t, strings.HasPrefix(got.Problem, tt.problemPrefix))
+			if tt.want.HeadHash != "" {
+				assert.Equal(t, tt.want.HeadHash, got.HeadHash)
+			}
+		})
+	}
+}
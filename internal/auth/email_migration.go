@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+)
+
+// emailMigrationBatchSize is the number of users loaded at a time.
+const emailMigrationBatchSize = 500
+
+// EmailChange describes the stored and normalized email address of a user.
+type EmailChange struct {
+	UserID string
+	Old    string
+	New    string
+}
+
+// EmailCollision lists users whose addresses normalize to the same mailbox.
+type EmailCollision struct {
+	Normalized string
+	Users      []EmailChange
+}
+
+// EmailReport is the outcome of NormalizeEmails.
+type EmailReport struct {
+	Scanned    int
+	Updated    []EmailChange
+	Collisions []EmailCollision
+	Invalid    []EmailChange
+}
+
+// NormalizeEmails rewrites every stored email address into its normalized
+// form. Addresses of users that would collide with another user after
+// normalization, and addresses that cannot be normalized, are left untouched
+// and reported for manual resolution. Nothing is written unless apply is set.
+func NormalizeEmails(ctx context.Context, db *gorm.DB, emails *email.Normalizer, apply bool) (*EmailReport, error) {
+	report := &EmailReport{}
+	groups := make(map[string][]EmailChange)
+
+	// Read from the primary so that the report matches what is updated.
+	ctx = database.UsePrimary(ctx)
+
+	var batch []model.User
+	err := db.WithContext(ctx).Select("id", "email").Order("id").
+		FindInBatches(&batch, emailMigrationBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, u := range batch {
+				report.Scanned++
+				normalized, err := emails.Normalize(u.Email)
+				change := EmailChange{UserID: u.ID.String(), Old: u.Email, New: normalized}
+				if err != nil {
+					report.Invalid = append(report.Invalid, change)
+					continue
+				}
+				key := strings.ToLower(normalized)
+				groups[key] = append(groups[key], change)
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		users := groups[key]
+		if len(users) > 1 {
+			report.Collisions = append(report.Collisions, EmailCollision{Normalized: key, Users: users})
+			continue
+		}
+		if users[0].Old != users[0].New {
+			report.Updated = append(report.Updated, users[0])
+		}
+	}
+
+	if !apply || len(report.Updated) == 0 {
+		return report, nil
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, c := range report.Updated {
+			err := tx.Model(&model.User{}).
+				Where("id = ?", c.UserID).Update("email", c.New).Error
+			if err != nil {
+				return fmt.Errorf("failed to update user %s: %w", c.UserID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Write prints the report in a human readable form.
+func (r *EmailReport) Write(w io.Writer, applied bool) error {
+	verb := "would update"
+	if applied {
+		verb = "updated"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "scanned %d users, %s %d, %d collisions, %d invalid\n",
+		r.Scanned, verb, len(r.Updated), len(r.Collisions), len(r.Invalid))
+	for _, c := range r.Updated {
+		fmt.Fprintf(&b, "%s user %s: %q -> %q\n", verb, c.UserID, c.Old, c.New)
+	}
+	for _, c := range r.Collisions {
+		fmt.Fprintf(&b, "collision on %q:\n", c.Normalized)
+		for _, u := range c.Users {
+			fmt.Fprintf(&b, "  user %s: %q\n", u.UserID, u.Old)
+		}
+	}
+	for _, c := range r.Invalid {
+		fmt.Fprintf(&b, "invalid address of user %s: %q\n", c.UserID, c.Old)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
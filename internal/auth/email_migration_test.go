@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmails(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	userRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email"}).
+			AddRow(ids[0], "Alice@Example.COM").
+			AddRow(ids[1], "bob@example.com").
+			AddRow(ids[2], " Carol@example.com").
+			AddRow(ids[3], "carol@EXAMPLE.com").
+			AddRow(ids[4], "not-an-email")
+	}
+
+	tests := []struct {
+		name   string
+		apply  bool
+		mockFn func(sqlmock.Sqlmock)
+	}{
+		{
+			name: "report only",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT "id","email" FROM "users"`).WillReturnRows(userRows())
+			},
+		},
+		{
+			name:  "apply updates non-colliding users",
+			apply: true,
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT "id","email" FROM "users"`).WillReturnRows(userRows())
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users" SET "email"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+					WithArgs("Alice@example.com", sqlmock.AnyArg(), ids[0].String()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gormDB, sqlMock := testutil.DBMock(t)
+			tt.mockFn(sqlMock)
+
+			emails := email.NewNormalizer(&config.Config{EmailPunycodeDomain: true})
+			report, err := NormalizeEmails(context.Background(), gormDB, emails, tt.apply)
+
+			require.NoError(t, err)
+			assert.Equal(t, 5, report.Scanned)
+			assert.Equal(t, []EmailChange{
+				{UserID: ids[0].String(), Old: "Alice@Example.COM", New: "Alice@example.com"},
+			}, report.Updated)
+			assert.Equal(t, []EmailCollision{{
+				Normalized: "carol@example.com",
+				Users: []EmailChange{
+					{UserID: ids[2].String(), Old: " Carol@example.com", New: "Carol@example.com"},
+					{UserID: ids[3].String(), Old: "carol@EXAMPLE.com", New: "carol@example.com"},
+				},
+			}}, report.Collisions)
+			assert.Equal(t, []EmailChange{{UserID: ids[4].String(), Old: "not-an-email"}}, report.Invalid)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+			var out bytes.Buffer
+			require.NoError(t, report.Write(&out, tt.apply))
+			assert.Contains(t, out.String(), "scanned 5 users")
+			assert.Contains(t, out.String(), `collision on "carol@example.com"`)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package auth
+
+import "errors"
+
+var (
+	// ErrEmailTaken is returned when registering an email address that is already in use.
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrWrongPassword is returned when the current password given to change a password is incorrect.
+	ErrWrongPassword = errors.New("current password is incorrect")
+	// ErrInvalidCredentials is returned when logging in with an unknown email address, a wrong password or a disabled account.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUserNotFound is returned when changing the password of a user that does not exist.
+	ErrUserNotFound = errors.New("user not found")
+)
@@ -2,9 +2,14 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +19,12 @@ import (
 type Repository interface {
 	Create(ctx context.Context, user *model.User) error
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
+	ReplacePasswordHash(ctx context.Context, id, oldHash, newHash string) error
+	AddPasswordHistory(ctx context.Context, userID, passwordHash string) error
+	RecentPasswordHashes(ctx context.Context, userID string, limit int) ([]string, error)
+	PrunePasswordHistory(ctx context.Context, userID string, keep int) error
 }
 
 // repository is a struct that provides methods to interact with the user data in the database.
@@ -22,29 +33,147 @@ type repository struct {
 	timeout time.Duration
 }
 
-// NewRepository creates a new instance of repository with the provided gorm.DB connection.
-func NewRepository(db *gorm.DB) Repository {
-	return &repository{db: db, timeout: 5 * time.Second}
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
 }
 
-// Create inserts a new user record into the database.
+// Create inserts a new user record into the database. It returns ErrEmailTaken
+// when another user already has the email address, compared case-insensitively.
 func (r *repository) Create(ctx context.Context, user *model.User) error {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	return r.db.WithContext(ctx).Create(user).Error
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(user).Error; err != nil {
+		if database.IsUniqueViolation(err, model.EmailIndex, model.LegacyEmailIndex) {
+			return ErrEmailTaken
+		}
+		logger.FromContext(ctx).Error("failed to create user", slog.Any("error", err))
+		return err
+	}
+
+	return nil
 }
 
-// FindByEmail retrieves a user from the database by their email address.
+// FindByEmail retrieves a user from the database by their email address, ignoring case.
 func (r *repository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	var user model.User
 
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Where("lower(email) = lower(?)", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.FromContext(ctx).Error("failed to find user", slog.String("by", "email"), slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// FindByID retrieves a user from the database by their ID.
+func (r *repository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var user model.User
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.FromContext(ctx).Error("failed to find user", slog.String("by", "id"), slog.Any("error", err))
+		}
 		return nil, err
 	}
 
 	return &user, nil
 }
+
+// UpdatePassword replaces the password hash of the user with the given ID.
+func (r *repository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", id).Update("password_hash", passwordHash)
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to update password", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ReplacePasswordHash replaces the password hash of the user with the given ID
+// when it still equals oldHash, so that a password changed in the meantime is
+// not overwritten. It does nothing otherwise.
+func (r *repository) ReplacePasswordHash(ctx context.Context, id, oldHash, newHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND password_hash = ?", id, oldHash).Update("password_hash", newHash).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to replace password hash", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// AddPasswordHistory records passwordHash as a password the user has had.
+func (r *repository) AddPasswordHistory(ctx context.Context, userID, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	entry := &model.PasswordHistory{UserID: id, PasswordHash: passwordHash}
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(entry).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to add password history", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// RecentPasswordHashes returns the hashes of the most recent passwords of the user, newest first.
+func (r *repository) RecentPasswordHashes(ctx context.Context, userID string, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var hashes []string
+	err := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.PasswordHistory{}).
+		Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to load password history", slog.Any("error", err))
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// PrunePasswordHistory deletes all but the keep most recent password history entries of the user.
+func (r *repository) PrunePasswordHistory(ctx context.Context, userID string, keep int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := database.Conn(ctx, r.db).WithContext(ctx)
+	recent := db.Model(&model.PasswordHistory{}).Select("id").
+		Where("user_id = ?", userID).Order("created_at DESC").Limit(keep)
+	err := db.Where("user_id = ? AND id NOT IN (?)", userID, recent).Delete(&model.PasswordHistory{}).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to prune password history", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
@@ -4,25 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
 	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
 func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
 	_, gormDB, sqlMock := testutil.DBMock(t)
-	userRepo := NewRepository(gormDB)
+	userRepo := NewRepository(gormDB, 5*time.Second)
 	return sqlMock, userRepo
 }
 
 func TestNewRepository(t *testing.T) {
 	_, gormDB, _ := testutil.DBMock(t)
-	userRepo := NewRepository(gormDB)
+	userRepo := NewRepository(gormDB, 5*time.Second)
 	assert.NotNil(t, userRepo)
 	assert.Equal(t, gormDB, userRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, userRepo.(*repository).timeout)
 }
 
 func Test_repository_Create(t *testing.T) {
@@ -47,7 +51,7 @@ func Test_repository_Create(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
 					AddRow(mockUser.ID, mockUser.CreatedAt, mockUser.UpdatedAt)
 				sqlMock.ExpectQuery(`INSERT INTO "users"`).
-					WithArgs(mockUser.Email, mockUser.PasswordHash, mockUser.FullName).
+					WithArgs(mockUser.Email, mockUser.PasswordHash, mockUser.FullName, nil, nil).
 					WillReturnRows(rows)
 				sqlMock.ExpectCommit()
 			},
@@ -63,13 +67,46 @@ func Test_repository_Create(t *testing.T) {
 			mockFn: func(sqlMock sqlmock.Sqlmock) {
 				sqlMock.ExpectBegin()
 				sqlMock.ExpectQuery(`INSERT INTO "users"`).
-					WithArgs(mockUser.Email, mockUser.PasswordHash, mockUser.FullName).
+					WithArgs(mockUser.Email, mockUser.PasswordHash, mockUser.FullName, nil, nil).
 					WillReturnError(sql.ErrConnDone)
 				sqlMock.ExpectRollback()
 			},
 			wantErr: true,
 			errType: sql.ErrConnDone,
 		},
+		{
+			name: "duplicate email",
+			user: &model.User{
+				Email:        mockUser.Email,
+				PasswordHash: mockUser.PasswordHash,
+				FullName:     mockUser.FullName,
+			},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "users"`).
+					WithArgs(mockUser.Email, mockUser.PasswordHash, mockUser.FullName, nil, nil).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: model.EmailIndex})
+				sqlMock.ExpectRollback()
+			},
+			wantErr: true,
+			errType: ErrEmailTaken,
+		},
+		{
+			name: "duplicate email on the legacy index",
+			user: &model.User{
+				Email:        mockUser.Email,
+				PasswordHash: mockUser.PasswordHash,
+				FullName:     mockUser.FullName,
+			},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "users"`).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: model.LegacyEmailIndex})
+				sqlMock.ExpectRollback()
+			},
+			wantErr: true,
+			errType: ErrEmailTaken,
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,7 +146,7 @@ func Test_repository_FindByEmail(t *testing.T) {
 			mockFn: func(sqlMock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "full_name", "created_at", "updated_at"}).
 					AddRow(mockUser.ID, mockUser.Email, mockUser.PasswordHash, mockUser.FullName, mockUser.CreatedAt, mockUser.UpdatedAt)
-				sqlMock.ExpectQuery(`SELECT .* FROM "users" WHERE email = \$1 (.+) LIMIT \$2`).
+				sqlMock.ExpectQuery(`SELECT .* FROM "users" WHERE lower\(email\) = lower\(\$1\) (.+) LIMIT \$2`).
 					WithArgs(mockUser.Email, 1).
 					WillReturnRows(rows)
 			},
@@ -120,7 +157,7 @@ func Test_repository_FindByEmail(t *testing.T) {
 			name: "user not found",
 			mockFn: func(sqlMock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "full_name", "created_at", "updated_at"})
-				sqlMock.ExpectQuery(`SELECT .* FROM "users" WHERE email = \$1 (.+) LIMIT \$2`).
+				sqlMock.ExpectQuery(`SELECT .* FROM "users" WHERE lower\(email\) = lower\(\$1\) (.+) LIMIT \$2`).
 					WithArgs(mockUser.Email, 1).
 					WillReturnRows(rows)
 			},
@@ -150,3 +187,192 @@ func Test_repository_FindByEmail(t *testing.T) {
 		})
 	}
 }
+
+func Test_repository_FindByID(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name     string
+		mockFn   func(sqlmock.Sqlmock)
+		wantUser *model.User
+		errType  error
+	}{
+		{
+			name: "user found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "full_name", "created_at", "updated_at"}).
+					AddRow(mockUser.ID, mockUser.Email, mockUser.PasswordHash, mockUser.FullName, mockUser.CreatedAt, mockUser.UpdatedAt)
+				sqlMock.ExpectQuery(`SELECT .* FROM "users" WHERE id = \$1 (.+) LIMIT \$2`).
+					WithArgs(mockUser.ID.String(), 1).
+					WillReturnRows(rows)
+			},
+			wantUser: &mockUser,
+		},
+		{
+			name: "user not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT .* FROM "users" WHERE id = \$1 (.+) LIMIT \$2`).
+					WithArgs(mockUser.ID.String(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, userRepo := setupRepositoryTest(t)
+
+			tt.mockFn(sqlMock)
+			got, err := userRepo.FindByID(context.Background(), mockUser.ID.String())
+
+			assert.Equal(t, tt.errType, err)
+			assert.Equal(t, tt.wantUser, got)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_UpdatePassword(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "password updated",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users" SET "password_hash"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+					WithArgs("new-hash", sqlmock.AnyArg(), mockUser.ID.String()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "user not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, userRepo := setupRepositoryTest(t)
+
+			tt.mockFn(sqlMock)
+			err := userRepo.UpdatePassword(context.Background(), mockUser.ID.String(), "new-hash")
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ReplacePasswordHash(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "hash replaced",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users" SET "password_hash"=\$1,"updated_at"=\$2 WHERE id = \$3 AND password_hash = \$4`).
+					WithArgs("new-hash", sqlmock.AnyArg(), mockUser.ID.String(), "old-hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "password changed in the meantime",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, userRepo := setupRepositoryTest(t)
+
+			tt.mockFn(sqlMock)
+			err := userRepo.ReplacePasswordHash(context.Background(), mockUser.ID.String(), "old-hash", "new-hash")
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_AddPasswordHistory(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	sqlMock, userRepo := setupRepositoryTest(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`INSERT INTO "password_histories" \("user_id","password_hash"\)`).
+		WithArgs(mockUser.ID, "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), time.Now()))
+	sqlMock.ExpectCommit()
+
+	assert.NoError(t, userRepo.AddPasswordHistory(context.Background(), mockUser.ID.String(), "hash"))
+	assert.Error(t, userRepo.AddPasswordHistory(context.Background(), "not-a-uuid", "hash"))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_RecentPasswordHashes(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	sqlMock, userRepo := setupRepositoryTest(t)
+
+	sqlMock.ExpectQuery(`SELECT "password_hash" FROM "password_histories" WHERE user_id = \$1 ORDER BY created_at DESC LIMIT \$2`).
+		WithArgs(mockUser.ID.String(), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"password_hash"}).AddRow("newest").AddRow("older"))
+
+	got, err := userRepo.RecentPasswordHashes(context.Background(), mockUser.ID.String(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"newest", "older"}, got)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_PrunePasswordHistory(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	sqlMock, userRepo := setupRepositoryTest(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`DELETE FROM "password_histories" WHERE user_id = \$1 AND id NOT IN \(SELECT "id" FROM "password_histories" WHERE user_id = \$2 ORDER BY created_at DESC LIMIT \$3\)`).
+		WithArgs(mockUser.ID.String(), mockUser.ID.String(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	assert.NoError(t, userRepo.PrunePasswordHistory(context.Background(), mockUser.ID.String(), 3))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
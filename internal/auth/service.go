@@ -3,12 +3,25 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/audit"
 	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/loginhistory"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"github.com/PakornBank/go-backend-example/internal/session"
 	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
 //go:generate mockgen -destination=./service_mock.go -package=auth github.com/PakornBank/go-backend-example/internal/auth Service
@@ -16,72 +29,389 @@ import (
 // Service defines the methods that a service must implement.
 type Service interface {
 	Register(ctx context.Context, email, password, fullName string) (*model.User, error)
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password string, client session.Client) (string, error)
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error
 }
 
 // service is a struct that provides methods to interact with the authentication service.
 type service struct {
-	repository  Repository
-	jwtSecret   []byte
-	tokenExpiry time.Duration
+	repository Repository
+	tx         database.TxManager
+	emails     *email.Normalizer
+	passwords  *password.Policy
+	hasher     password.Hasher
+	sessions   session.Service
+	history    loginhistory.Service
+	auditLog   audit.Service
+	events     outbox.Publisher
+	config     *config.Store
+	metrics    *metrics.Metrics
 }
 
-// NewService creates a new instance of service with the provided repository and configuration.
-func NewService(repository Repository, config *config.Config) Service {
+// NewService creates a new instance of service with the provided repository, transaction manager,
+// email normalizer, password policy and hasher, session, login history and audit services, event publisher, configuration
+// and metrics. The signing secret is read from the store on every call so that rotated secrets take effect immediately.
+func NewService(repository Repository, tx database.TxManager, emails *email.Normalizer, passwords *password.Policy, hasher password.Hasher, sessions session.Service, history loginhistory.Service, auditLog audit.Service, events outbox.Publisher, config *config.Store, m *metrics.Metrics) Service {
 	return &service{
-		repository:  repository,
-		jwtSecret:   []byte(config.JWTSecret),
-		tokenExpiry: config.TokenExpiryDur,
+		repository: repository,
+		tx:         tx,
+		emails:     emails,
+		passwords:  passwords,
+		hasher:     hasher,
+		sessions:   sessions,
+		history:    history,
+		auditLog:   auditLog,
+		events:     events,
+		config:     config,
+		metrics:    m,
 	}
 }
 
 // Register handles the user registration process.
-func (s *service) Register(ctx context.Context, email, password, fullName string) (*model.User, error) {
-	existingUser, _ := s.repository.FindByEmail(ctx, email)
-	if existingUser != nil {
-		return nil, errors.New("email already registered")
+func (s *service) Register(ctx context.Context, address, newPassword, fullName string) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Register")
+	defer span.End()
+
+	log := logger.FromContext(ctx)
+
+	address, err := s.emails.Normalize(address)
+	if err != nil {
+		return nil, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := s.checkPassword(ctx, newPassword, address, fullName); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.hashPassword(ctx, newPassword)
 	if err != nil {
-		return nil, errors.New("failed to hash password")
+		tracing.RecordError(span, err)
+		return nil, err
 	}
 
 	user := &model.User{
-		Email:        email,
-		PasswordHash: string(hashedPassword),
+		Email:        address,
+		PasswordHash: hashedPassword,
 		FullName:     fullName,
 	}
 
-	if err := s.repository.Create(ctx, user); err != nil {
+	historySize := s.config.Get().PasswordHistorySize
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		existingUser, _ := s.repository.FindByEmail(ctx, address)
+		if existingUser != nil {
+			return ErrEmailTaken
+		}
+		if err := s.repository.Create(ctx, user); err != nil {
+			return err
+		}
+		if historySize > 0 {
+			if err := s.repository.AddPasswordHistory(ctx, user.ID.String(), hashedPassword); err != nil {
+				return err
+			}
+		}
+		err := s.auditLog.Record(ctx, audit.Event{
+			ActorID:    user.ID.String(),
+			Action:     audit.ActionUserRegistered,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.String(),
+			Metadata:   map[string]interface{}{"email": address},
+		})
+		if err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, outbox.EventUserRegistered, outbox.UserRegistered{
+			UserID:   user.ID.String(),
+			Email:    address,
+			FullName: fullName,
+		})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.String("user_id", user.ID.String()))
+	log.Info("user registered", slog.String("user_id", user.ID.String()))
 	return user, nil
 }
 
 // Login handles the user login process.
-func (s *service) Login(ctx context.Context, email, password string) (string, error) {
-	user, err := s.repository.FindByEmail(ctx, email)
+func (s *service) Login(ctx context.Context, address, password string, client session.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Login")
+	defer span.End()
+
+	log := logger.FromContext(ctx)
+
+	normalized, err := s.emails.Normalize(address)
+	if err != nil {
+		log.Warn("login failed", slog.String("reason", "invalid email"))
+		s.recordAttempt(ctx, loginhistory.Attempt{Email: address, Client: client, Reason: loginhistory.ReasonInvalidEmail})
+		span.SetAttributes(attribute.String("auth.result", metrics.LoginInvalidCredentials))
+		s.metrics.ObserveLogin(metrics.LoginInvalidCredentials)
+		return "", ErrInvalidCredentials
+	}
+	address = normalized
+
+	user, err := s.repository.FindByEmail(ctx, address)
+	if err != nil {
+		log.Warn("login failed", slog.String("reason", "unknown email"))
+		s.recordAttempt(ctx, loginhistory.Attempt{Email: address, Client: client, Reason: loginhistory.ReasonUnknownEmail})
+		span.SetAttributes(attribute.String("auth.result", metrics.LoginInvalidCredentials))
+		s.metrics.ObserveLogin(metrics.LoginInvalidCredentials)
+		return "", ErrInvalidCredentials
+	}
+
+	span.SetAttributes(attribute.String("user_id", user.ID.String()))
+
+	if !s.verifyPassword(ctx, password, user.PasswordHash) {
+		log.Warn("login failed", slog.String("reason", "wrong password"), slog.String("user_id", user.ID.String()))
+		s.recordAttempt(ctx, loginhistory.Attempt{Email: address, User: user, Client: client, Reason: loginhistory.ReasonWrongPassword})
+		span.SetAttributes(attribute.String("auth.result", metrics.LoginInvalidCredentials))
+		s.metrics.ObserveLogin(metrics.LoginInvalidCredentials)
+		return "", ErrInvalidCredentials
+	}
+
+	// Checked after the password, so that only the owner learns that the account is disabled.
+	if user.DisabledAt != nil {
+		log.Warn("login failed", slog.String("reason", "account disabled"), slog.String("user_id", user.ID.String()))
+		s.recordAttempt(ctx, loginhistory.Attempt{Email: address, User: user, Client: client, Reason: loginhistory.ReasonDisabled})
+		span.SetAttributes(attribute.String("auth.result", metrics.LoginInvalidCredentials))
+		s.metrics.ObserveLogin(metrics.LoginInvalidCredentials)
+		return "", ErrInvalidCredentials
+	}
+
+	s.upgradeHash(ctx, user, password)
+
+	cfg := s.config.Get()
+	sess, err := s.sessions.Create(ctx, user.ID, client, time.Now().Add(cfg.TokenExpiryDur))
+	if err != nil {
+		tracing.RecordError(span, err)
+		s.recordAttempt(ctx, loginhistory.Attempt{Email: address, User: user, Client: client, Reason: loginhistory.ReasonError})
+		s.metrics.ObserveLogin(metrics.LoginError)
+		return "", errors.New("failed to create session")
+	}
+
+	token, err := s.generateToken(user, sess)
+	if err != nil {
+		tracing.RecordError(span, err)
+		s.recordAttempt(ctx, loginhistory.Attempt{Email: address, User: user, Client: client, Reason: loginhistory.ReasonError})
+		s.metrics.ObserveLogin(metrics.LoginError)
+		return "", err
+	}
+
+	s.recordAttempt(ctx, loginhistory.Attempt{Email: address, User: user, Client: client})
+	span.SetAttributes(attribute.String("auth.result", metrics.LoginSuccess))
+	log.Info("user logged in", slog.String("user_id", user.ID.String()), slog.String("session_id", sess.ID.String()))
+	s.metrics.ObserveLogin(metrics.LoginSuccess)
+	return token, nil
+}
+
+// ChangePassword replaces the password of the user after verifying the current one.
+func (s *service) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.ChangePassword")
+	defer span.End()
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	log := logger.FromContext(ctx)
+
+	user, err := s.repository.FindByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	if !s.verifyPassword(ctx, currentPassword, user.PasswordHash) {
+		log.Warn("password change failed", slog.String("reason", "wrong password"), slog.String("user_id", userID))
+		return ErrWrongPassword
+	}
+
+	if err := s.checkPassword(ctx, newPassword, user.Email, user.FullName); err != nil {
+		return err
+	}
+
+	historySize := s.config.Get().PasswordHistorySize
+	if err := s.checkReuse(ctx, user, newPassword, historySize); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hashPassword(ctx, newPassword)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+			return err
+		}
+		if historySize > 0 {
+			if err := s.repository.AddPasswordHistory(ctx, userID, hashedPassword); err != nil {
+				return err
+			}
+			if err := s.repository.PrunePasswordHistory(ctx, userID, historySize); err != nil {
+				return err
+			}
+		}
+		return s.auditLog.Record(ctx, audit.Event{
+			ActorID:    userID,
+			Action:     audit.ActionPasswordChanged,
+			TargetType: audit.TargetUser,
+			TargetID:   userID,
+		})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	log.Info("password changed", slog.String("user_id", userID))
+	return nil
+}
+
+// recordAttempt adds the login attempt to the login history and, unless its
+// address is unknown, the audit log, and publishes failed attempts of known
+// users. Failing to do so does not fail the login.
+func (s *service) recordAttempt(ctx context.Context, attempt loginhistory.Attempt) {
+	log := logger.FromContext(ctx)
+	if err := s.history.Record(ctx, attempt); err != nil {
+		log.Warn("failed to record login attempt", slog.Any("error", err))
+	}
+	// Anyone can make attempts on unknown addresses: they are only kept in the
+	// login history, rather than taking the global lock of the audit log and
+	// filling the outbox.
+	if attempt.User == nil {
+		return
+	}
+
+	event := audit.Event{
+		Action:     audit.ActionLoginSucceeded,
+		TargetType: audit.TargetUser,
+		TargetID:   attempt.User.ID.String(),
+		Metadata:   map[string]interface{}{"email": attempt.Email},
+	}
+	if attempt.Reason != "" {
+		event.Action = audit.ActionLoginFailed
+		event.Metadata["reason"] = attempt.Reason
+	} else {
+		event.ActorID = event.TargetID
+	}
+	if err := s.auditLog.Record(ctx, event); err != nil {
+		log.Warn("failed to record audit event", slog.Any("error", err))
+	}
+
+	if attempt.Reason == "" {
+		return
+	}
+	err := s.events.Publish(ctx, outbox.EventLoginFailed, outbox.LoginFailed{
+		UserID: event.TargetID,
+		Email:  attempt.Email,
+		Reason: attempt.Reason,
+		IP:     attempt.Client.IP,
+	})
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		log.Warn("failed to publish login failure", slog.Any("error", err))
 	}
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("invalid credentials")
+// checkPassword applies the password policy to a password chosen by the user
+// with the given email address and name.
+func (s *service) checkPassword(ctx context.Context, newPassword, address, fullName string) error {
+	err := s.passwords.Check(newPassword, address, fullName)
+	var policyErr *password.PolicyError
+	if err != nil && !errors.As(err, &policyErr) {
+		logger.FromContext(ctx).Error("failed to check password", slog.Any("error", err))
+		return errors.New("failed to check password")
 	}
+	return err
+}
 
-	return s.generateToken(user)
+// checkReuse returns a *password.PolicyError when newPassword matches the
+// current password of user or one of the historySize most recent ones.
+func (s *service) checkReuse(ctx context.Context, user *model.User, newPassword string, historySize int) error {
+	if historySize == 0 {
+		return nil
+	}
+
+	hashes, err := s.repository.RecentPasswordHashes(ctx, user.ID.String(), historySize)
+	if err != nil {
+		return errors.New("failed to check password")
+	}
+	// Users registered before the history was kept only have their current hash.
+	if len(hashes) == 0 || hashes[0] != user.PasswordHash {
+		hashes = append([]string{user.PasswordHash}, hashes...)
+	}
+
+	for _, hash := range hashes {
+		if s.verifyPassword(ctx, newPassword, hash) {
+			return &password.PolicyError{Violations: []password.Violation{{
+				Rule:    password.RuleReused,
+				Message: fmt.Sprintf("must not match one of your last %d passwords", historySize),
+			}}}
+		}
+	}
+	return nil
+}
+
+// verifyPassword reports whether password matches the stored hash.
+func (s *service) verifyPassword(ctx context.Context, password, hash string) bool {
+	_, span := tracing.Start(ctx, "password.Verify")
+	ok, err := s.hasher.Verify(password, hash)
+	span.End()
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to verify password", slog.Any("error", err))
+	}
+	return ok
+}
+
+// upgradeHash replaces the stored hash of user, after the password was
+// verified, when it uses another algorithm or weaker parameters than the
+// configured ones. Failing to do so does not fail the login.
+func (s *service) upgradeHash(ctx context.Context, user *model.User, password string) {
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	log := logger.FromContext(ctx)
+
+	hashedPassword, err := s.hashPassword(ctx, password)
+	if err != nil {
+		return
+	}
+	if err := s.repository.ReplacePasswordHash(ctx, user.ID.String(), user.PasswordHash, hashedPassword); err != nil {
+		log.Warn("failed to upgrade password hash", slog.String("user_id", user.ID.String()), slog.Any("error", err))
+		return
+	}
+
+	user.PasswordHash = hashedPassword
+	log.Info("password hash upgraded", slog.String("user_id", user.ID.String()))
+}
+
+// hashPassword returns the hash of password created with the configured algorithm.
+func (s *service) hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "password.Hash")
+	hashedPassword, err := s.hasher.Hash(password)
+	span.End()
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to hash password", slog.Any("error", err))
+		return "", errors.New("failed to hash password")
+	}
+	return hashedPassword, nil
 }
 
-// generateToken generates a JWT token for the given user.
-func (s *service) generateToken(user *model.User) (string, error) {
+// generateToken generates a JWT token for the given user, bound to the session and expiring with it.
+func (s *service) generateToken(user *model.User, sess *model.Session) (string, error) {
+	cfg := s.config.Get()
 	claims := jwt.MapClaims{
 		"user_id": user.ID.String(),
 		"email":   user.Email,
-		"exp":     time.Now().Add(s.tokenExpiry).Unix(),
+		"sid":     sess.ID.String(),
+		"exp":     sess.ExpiresAt.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return token.SignedString([]byte(cfg.JWTSecret))
 }
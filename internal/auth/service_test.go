@@ -2,14 +2,27 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/audit"
 	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
 	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/PakornBank/go-backend-example/internal/loginhistory"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"github.com/PakornBank/go-backend-example/internal/session"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -30,25 +43,115 @@ func setupServiceTest(t *testing.T) (Service, *MockRepository) {
 	ctrl := gomock.NewController(t)
 	mockRepo := NewMockRepository(ctrl)
 	authService := &service{
-		repository:  mockRepo,
-		jwtSecret:   []byte("test-secret"),
-		tokenExpiry: time.Hour * 24,
+		repository: mockRepo,
+		tx:         passThroughTx(ctrl),
+		emails:     email.NewNormalizer(&config.Config{EmailPunycodeDomain: true}),
+		passwords:  testPolicy(t),
+		hasher:     testHasher(),
+		sessions:   testSessions(ctrl),
+		history:    testHistory(ctrl),
+		auditLog:   testAudit(ctrl),
+		events:     testEvents(ctrl),
+		config: config.NewStore(&config.Config{
+			JWTSecret:      "test-secret",
+			TokenExpiryDur: time.Hour * 24,
+		}),
+		metrics: metrics.New(),
 	}
 	return authService, mockRepo
 }
 
+// testPolicy returns the default password policy.
+func testPolicy(t *testing.T) *password.Policy {
+	policy, err := password.NewPolicy(&config.Config{
+		PasswordMinLength:        8,
+		PasswordMaxBytes:         72,
+		PasswordDisallowPersonal: true,
+		PasswordMinEntropy:       30,
+	})
+	require.NoError(t, err)
+	return policy
+}
+
+// testHasher returns a hasher creating bcrypt hashes of the lowest cost, which
+// verifies the hashes used in the tests without upgrading them.
+func testHasher() password.Hasher {
+	return password.NewHasher(&config.Config{
+		PasswordHashAlgorithm: password.AlgorithmBcrypt,
+		PasswordBcryptCost:    bcrypt.MinCost,
+	})
+}
+
+// testSessions returns a session service that creates sessions expiring in a day.
+func testSessions(ctrl *gomock.Controller) *session.MockService {
+	mockSessions := session.NewMockService(ctrl)
+	mockSessions.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, userID uuid.UUID, _ session.Client, expiresAt time.Time) (*model.Session, error) {
+			return &model.Session{ID: uuid.New(), UserID: userID, ExpiresAt: expiresAt}, nil
+		}).AnyTimes()
+	return mockSessions
+}
+
+// testHistory returns a login history service that records every attempt.
+func testHistory(ctrl *gomock.Controller) *loginhistory.MockService {
+	mockHistory := loginhistory.NewMockService(ctrl)
+	mockHistory.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return mockHistory
+}
+
+// testAudit returns an audit service that records every event.
+func testAudit(ctrl *gomock.Controller) *audit.MockService {
+	mockAudit := audit.NewMockService(ctrl)
+	mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return mockAudit
+}
+
+// testEvents returns a publisher that accepts every event.
+func testEvents(ctrl *gomock.Controller) *outbox.MockPublisher {
+	mockEvents := outbox.NewMockPublisher(ctrl)
+	mockEvents.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return mockEvents
+}
+
+// passThroughTx returns a transaction manager that runs functions directly.
+func passThroughTx(ctrl *gomock.Controller) *database.MockTxManager {
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	return mockTx
+}
+
 func TestNewService(t *testing.T) {
 	mockRepo := new(MockRepository)
-	cfg := &config.Config{
+	mockTx := new(database.MockTxManager)
+	emails := email.NewNormalizer(&config.Config{})
+	policy := testPolicy(t)
+	hasher := testHasher()
+	sessions := new(session.MockService)
+	history := new(loginhistory.MockService)
+	auditLog := new(audit.MockService)
+	events := new(outbox.MockPublisher)
+	store := config.NewStore(&config.Config{
 		JWTSecret:      "test-secret",
 		TokenExpiryDur: time.Hour * 24,
-	}
-	authService := NewService(mockRepo, cfg)
+	})
+	m := metrics.New()
+	authService := NewService(mockRepo, mockTx, emails, policy, hasher, sessions, history, auditLog, events, store, m)
 
 	assert.NotNil(t, authService)
 	assert.Equal(t, mockRepo, authService.(*service).repository)
-	assert.Equal(t, []byte(cfg.JWTSecret), authService.(*service).jwtSecret)
-	assert.Equal(t, cfg.TokenExpiryDur, authService.(*service).tokenExpiry)
+	assert.Equal(t, mockTx, authService.(*service).tx)
+	assert.Equal(t, emails, authService.(*service).emails)
+	assert.Equal(t, policy, authService.(*service).passwords)
+	assert.Equal(t, hasher, authService.(*service).hasher)
+	assert.Equal(t, sessions, authService.(*service).sessions)
+	assert.Equal(t, history, authService.(*service).history)
+	assert.Equal(t, auditLog, authService.(*service).auditLog)
+	assert.Equal(t, events, authService.(*service).events)
+	assert.Equal(t, store, authService.(*service).config)
+	assert.Equal(t, m, authService.(*service).metrics)
 }
 
 func Test_service_Register(t *testing.T) {
@@ -60,6 +163,7 @@ func Test_service_Register(t *testing.T) {
 		mockFn      func(*MockRepository)
 		wantErr     bool
 		errContains string
+		wantEmail   string
 	}{
 		{
 			name: "successful registration",
@@ -87,6 +191,69 @@ func Test_service_Register(t *testing.T) {
 			wantErr:     true,
 			errContains: "email already registered",
 		},
+		{
+			name: "create fails",
+			input: registerInput{
+				Email:    mockUser.Email,
+				Password: "password",
+				FullName: mockUser.FullName,
+			},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&model.User{})).Return(gorm.ErrInvalidDB)
+			},
+			wantErr:     true,
+			errContains: gorm.ErrInvalidDB.Error(),
+		},
+		{
+			name: "email is normalized",
+			input: registerInput{
+				Email:    "  Test.User@EXAMPLE.com ",
+				Password: "password",
+				FullName: mockUser.FullName,
+			},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), "Test.User@example.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&model.User{})).Return(nil)
+			},
+			wantEmail: "Test.User@example.com",
+		},
+		{
+			name: "invalid email",
+			input: registerInput{
+				Email:    "user@",
+				Password: "password",
+				FullName: mockUser.FullName,
+			},
+			mockFn:      func(*MockRepository) {},
+			wantErr:     true,
+			errContains: email.ErrInvalid.Error(),
+		},
+		{
+			name: "concurrent registration with the same email",
+			input: registerInput{
+				Email:    mockUser.Email,
+				Password: "password",
+				FullName: mockUser.FullName,
+			},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&model.User{})).Return(ErrEmailTaken)
+			},
+			wantErr:     true,
+			errContains: ErrEmailTaken.Error(),
+		},
+		{
+			name: "password violates the policy",
+			input: registerInput{
+				Email:    mockUser.Email,
+				Password: "test1234",
+				FullName: mockUser.FullName,
+			},
+			mockFn:      func(*MockRepository) {},
+			wantErr:     true,
+			errContains: "password does not meet the policy: must not contain your email address or name",
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,7 +269,11 @@ func Test_service_Register(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, user)
-				assert.Equal(t, tt.input.Email, user.Email)
+				wantEmail := tt.input.Email
+				if tt.wantEmail != "" {
+					wantEmail = tt.wantEmail
+				}
+				assert.Equal(t, wantEmail, user.Email)
 				assert.Equal(t, tt.input.FullName, user.FullName)
 			}
 		})
@@ -119,6 +290,7 @@ func Test_service_Login(t *testing.T) {
 		mockFn      func(*MockRepository)
 		wantErr     bool
 		errContains string
+		wantResult  string
 	}{
 		{
 			name: "successful login",
@@ -130,7 +302,8 @@ func Test_service_Login(t *testing.T) {
 				mockUser.PasswordHash = string(hashedPassword)
 				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&mockUser, nil)
 			},
-			wantErr: false,
+			wantErr:    false,
+			wantResult: metrics.LoginSuccess,
 		},
 		{
 			name: "invalid credentials",
@@ -144,6 +317,24 @@ func Test_service_Login(t *testing.T) {
 			},
 			wantErr:     true,
 			errContains: "invalid credentials",
+			wantResult:  metrics.LoginInvalidCredentials,
+		},
+		{
+			name: "disabled account",
+			input: loginInput{
+				Email:    mockUser.Email,
+				Password: "password",
+			},
+			mockFn: func(mr *MockRepository) {
+				disabledAt := time.Now()
+				disabled := mockUser
+				disabled.PasswordHash = string(hashedPassword)
+				disabled.DisabledAt = &disabledAt
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&disabled, nil)
+			},
+			wantErr:     true,
+			errContains: "invalid credentials",
+			wantResult:  metrics.LoginInvalidCredentials,
 		},
 		{
 			name: "user not found",
@@ -156,6 +347,31 @@ func Test_service_Login(t *testing.T) {
 			},
 			wantErr:     true,
 			errContains: "invalid credentials",
+			wantResult:  metrics.LoginInvalidCredentials,
+		},
+		{
+			name: "email looked up in normalized form",
+			input: loginInput{
+				Email:    " Someone@EXAMPLE.com",
+				Password: "password",
+			},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), "Someone@example.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr:     true,
+			errContains: "invalid credentials",
+			wantResult:  metrics.LoginInvalidCredentials,
+		},
+		{
+			name: "invalid email",
+			input: loginInput{
+				Email:    "no-at-sign",
+				Password: "password",
+			},
+			mockFn:      func(*MockRepository) {},
+			wantErr:     true,
+			errContains: "invalid credentials",
+			wantResult:  metrics.LoginInvalidCredentials,
 		},
 	}
 
@@ -163,7 +379,7 @@ func Test_service_Login(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			authService, mockRepo := setupServiceTest(t)
 			tt.mockFn(mockRepo)
-			token, err := authService.Login(context.Background(), tt.input.Email, tt.input.Password)
+			token, err := authService.Login(context.Background(), tt.input.Email, tt.input.Password, session.Client{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -173,6 +389,454 @@ func Test_service_Login(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotEmpty(t, token)
 			}
+
+			expected := fmt.Sprintf(`
+# HELP go_auth_api_auth_login_attempts_total Total number of login attempts by result.
+# TYPE go_auth_api_auth_login_attempts_total counter
+go_auth_api_auth_login_attempts_total{result="%s"} 1
+`, tt.wantResult)
+			err = promtestutil.GatherAndCompare(authService.(*service).metrics.Registry(),
+				strings.NewReader(expected), "go_auth_api_auth_login_attempts_total")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_service_ChangePassword(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	mockUser.PasswordHash = string(hashedPassword)
+	userID := mockUser.ID.String()
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		mockFn          func(*MockRepository)
+		errContains     string
+	}{
+		{
+			name:            "successful change",
+			currentPassword: "password",
+			newPassword:     "correct horse battery",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, hash string) error {
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("correct horse battery")))
+						return nil
+					})
+			},
+		},
+		{
+			name:            "user not found",
+			currentPassword: "password",
+			newPassword:     "correct horse battery",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errContains: ErrUserNotFound.Error(),
+		},
+		{
+			name:            "wrong current password",
+			currentPassword: "wrong password",
+			newPassword:     "correct horse battery",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+			},
+			errContains: ErrWrongPassword.Error(),
+		},
+		{
+			name:            "new password violates the policy",
+			currentPassword: "password",
+			newPassword:     "short",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+			},
+			errContains: "password does not meet the policy: must be at least 8 characters long; is too easy to guess",
+		},
+		{
+			name:            "update fails",
+			currentPassword: "password",
+			newPassword:     "correct horse battery",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).Return(gorm.ErrInvalidDB)
+			},
+			errContains: gorm.ErrInvalidDB.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			tt.mockFn(mockRepo)
+
+			err := authService.ChangePassword(context.Background(), userID, tt.currentPassword, tt.newPassword)
+
+			if tt.errContains != "" {
+				assert.EqualError(t, err, tt.errContains)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_service_ChangePassword_History(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hasher := testHasher()
+	currentHash, _ := hasher.Hash("password")
+	oldHash, _ := hasher.Hash("correct horse battery")
+	mockUser.PasswordHash = currentHash
+	userID := mockUser.ID.String()
+
+	tests := []struct {
+		name        string
+		newPassword string
+		mockFn      func(*MockRepository)
+		errContains string
+	}{
+		{
+			name:        "new password recorded and history pruned",
+			newPassword: "staple battery horse",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().RecentPasswordHashes(gomock.Any(), userID, 3).Return([]string{currentHash, oldHash}, nil)
+				mr.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).Return(nil)
+				mr.EXPECT().AddPasswordHistory(gomock.Any(), userID, gomock.Any()).Return(nil)
+				mr.EXPECT().PrunePasswordHistory(gomock.Any(), userID, 3).Return(nil)
+			},
+		},
+		{
+			name:        "password from the history",
+			newPassword: "correct horse battery",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().RecentPasswordHashes(gomock.Any(), userID, 3).Return([]string{currentHash, oldHash}, nil)
+			},
+			errContains: "password does not meet the policy: must not match one of your last 3 passwords",
+		},
+		{
+			name:        "current password of a user without history",
+			newPassword: "password",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().RecentPasswordHashes(gomock.Any(), userID, 3).Return(nil, nil)
+			},
+			errContains: "password does not meet the policy: must not match one of your last 3 passwords",
+		},
+		{
+			name:        "history cannot be loaded",
+			newPassword: "staple battery horse",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().RecentPasswordHashes(gomock.Any(), userID, 3).Return(nil, gorm.ErrInvalidDB)
+			},
+			errContains: "failed to check password",
+		},
+		{
+			name:        "recording the history fails",
+			newPassword: "staple battery horse",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().RecentPasswordHashes(gomock.Any(), userID, 3).Return(nil, nil)
+				mr.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).Return(nil)
+				mr.EXPECT().AddPasswordHistory(gomock.Any(), userID, gomock.Any()).Return(gorm.ErrInvalidDB)
+			},
+			errContains: gorm.ErrInvalidDB.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			authService.(*service).config = config.NewStore(&config.Config{PasswordHistorySize: 3})
+			tt.mockFn(mockRepo)
+
+			err := authService.ChangePassword(context.Background(), userID, "password", tt.newPassword)
+
+			if tt.errContains != "" {
+				assert.EqualError(t, err, tt.errContains)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_service_Register_History(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	authService, mockRepo := setupServiceTest(t)
+	authService.(*service).config = config.NewStore(&config.Config{PasswordHistorySize: 3})
+
+	mockRepo.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&model.User{})).
+		DoAndReturn(func(_ context.Context, user *model.User) error {
+			user.ID = mockUser.ID
+			return nil
+		})
+	mockRepo.EXPECT().AddPasswordHistory(gomock.Any(), mockUser.ID.String(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, hash string) error {
+			ok, err := testHasher().Verify("password", hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			return nil
+		})
+
+	_, err := authService.Register(context.Background(), mockUser.Email, "password", mockUser.FullName)
+	assert.NoError(t, err)
+}
+
+func Test_service_Audit(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	userID := mockUser.ID.String()
+
+	tests := []struct {
+		name     string
+		mockFn   func(*MockRepository)
+		run      func(Service) error
+		want     audit.Event
+		auditErr error
+		wantErr  bool
+	}{
+		{
+			name: "registration",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *model.User) error {
+					user.ID = mockUser.ID
+					return nil
+				})
+			},
+			run: func(s Service) error {
+				_, err := s.Register(context.Background(), mockUser.Email, "correct horse battery staple", mockUser.FullName)
+				return err
+			},
+			want: audit.Event{ActorID: userID, Action: audit.ActionUserRegistered, TargetType: audit.TargetUser, TargetID: userID,
+				Metadata: map[string]interface{}{"email": mockUser.Email}},
+		},
+		{
+			name: "registration fails with its audit event",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *model.User) error {
+					user.ID = mockUser.ID
+					return nil
+				})
+			},
+			run: func(s Service) error {
+				_, err := s.Register(context.Background(), mockUser.Email, "correct horse battery staple", mockUser.FullName)
+				return err
+			},
+			want: audit.Event{ActorID: userID, Action: audit.ActionUserRegistered, TargetType: audit.TargetUser, TargetID: userID,
+				Metadata: map[string]interface{}{"email": mockUser.Email}},
+			auditErr: gorm.ErrInvalidDB,
+			wantErr:  true,
+		},
+		{
+			name: "password change",
+			mockFn: func(mr *MockRepository) {
+				user := mockUser
+				user.PasswordHash = string(hashedPassword)
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&user, nil)
+				mr.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).Return(nil)
+			},
+			run: func(s Service) error {
+				return s.ChangePassword(context.Background(), userID, "password", "correct horse battery staple")
+			},
+			want: audit.Event{ActorID: userID, Action: audit.ActionPasswordChanged, TargetType: audit.TargetUser, TargetID: userID},
+		},
+		{
+			name: "successful login",
+			mockFn: func(mr *MockRepository) {
+				user := mockUser
+				user.PasswordHash = string(hashedPassword)
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&user, nil)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "password", session.Client{})
+				return err
+			},
+			want: audit.Event{ActorID: userID, Action: audit.ActionLoginSucceeded, TargetType: audit.TargetUser, TargetID: userID,
+				Metadata: map[string]interface{}{"email": mockUser.Email}},
+		},
+		{
+			name: "failed login",
+			mockFn: func(mr *MockRepository) {
+				user := mockUser
+				user.PasswordHash = string(hashedPassword)
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&user, nil)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "wrong password", session.Client{})
+				return err
+			},
+			want: audit.Event{Action: audit.ActionLoginFailed, TargetType: audit.TargetUser, TargetID: userID,
+				Metadata: map[string]interface{}{"email": mockUser.Email, "reason": loginhistory.ReasonWrongPassword}},
+			wantErr: true,
+		},
+		{
+			name: "failed login with an unknown email is not recorded",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "password", session.Client{})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "login succeeds when its audit event fails",
+			mockFn: func(mr *MockRepository) {
+				user := mockUser
+				user.PasswordHash = string(hashedPassword)
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&user, nil)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "password", session.Client{})
+				return err
+			},
+			want: audit.Event{ActorID: userID, Action: audit.ActionLoginSucceeded, TargetType: audit.TargetUser, TargetID: userID,
+				Metadata: map[string]interface{}{"email": mockUser.Email}},
+			auditErr: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			authService.(*service).config = config.NewStore(&config.Config{JWTSecret: "test-secret", TokenExpiryDur: time.Hour})
+			mockAudit := audit.NewMockService(gomock.NewController(t))
+			if tt.want.Action != "" {
+				mockAudit.EXPECT().Record(gomock.Any(), tt.want).Return(tt.auditErr)
+			}
+			authService.(*service).auditLog = mockAudit
+			tt.mockFn(mockRepo)
+
+			err := tt.run(authService)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_service_Events(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	userID := mockUser.ID.String()
+
+	tests := []struct {
+		name       string
+		mockFn     func(*MockRepository)
+		run        func(Service) error
+		wantType   string
+		wantEvent  interface{}
+		publishErr error
+		wantErr    bool
+	}{
+		{
+			name: "registration",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *model.User) error {
+					user.ID = mockUser.ID
+					return nil
+				})
+			},
+			run: func(s Service) error {
+				_, err := s.Register(context.Background(), mockUser.Email, "correct horse battery staple", mockUser.FullName)
+				return err
+			},
+			wantType:  outbox.EventUserRegistered,
+			wantEvent: outbox.UserRegistered{UserID: userID, Email: mockUser.Email, FullName: mockUser.FullName},
+		},
+		{
+			name: "registration fails with its event",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *model.User) error {
+					user.ID = mockUser.ID
+					return nil
+				})
+			},
+			run: func(s Service) error {
+				_, err := s.Register(context.Background(), mockUser.Email, "correct horse battery staple", mockUser.FullName)
+				return err
+			},
+			wantType:   outbox.EventUserRegistered,
+			wantEvent:  outbox.UserRegistered{UserID: userID, Email: mockUser.Email, FullName: mockUser.FullName},
+			publishErr: gorm.ErrInvalidDB,
+			wantErr:    true,
+		},
+		{
+			name: "wrong password",
+			mockFn: func(mr *MockRepository) {
+				user := mockUser
+				user.PasswordHash = string(hashedPassword)
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&user, nil)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "wrong password", session.Client{IP: "192.0.2.1"})
+				return err
+			},
+			wantType:  outbox.EventLoginFailed,
+			wantEvent: outbox.LoginFailed{UserID: userID, Email: mockUser.Email, Reason: loginhistory.ReasonWrongPassword, IP: "192.0.2.1"},
+			wantErr:   true,
+		},
+		{
+			name: "unknown email",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(nil, gorm.ErrRecordNotFound)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "password", session.Client{IP: "192.0.2.1"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "login failure fails to publish",
+			mockFn: func(mr *MockRepository) {
+				user := mockUser
+				user.PasswordHash = string(hashedPassword)
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&user, nil)
+			},
+			run: func(s Service) error {
+				_, err := s.Login(context.Background(), mockUser.Email, "wrong password", session.Client{IP: "192.0.2.1"})
+				return err
+			},
+			wantType:   outbox.EventLoginFailed,
+			wantEvent:  outbox.LoginFailed{UserID: userID, Email: mockUser.Email, Reason: loginhistory.ReasonWrongPassword, IP: "192.0.2.1"},
+			publishErr: gorm.ErrInvalidDB,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			authService.(*service).config = config.NewStore(&config.Config{JWTSecret: "test-secret", TokenExpiryDur: time.Hour})
+			mockEvents := outbox.NewMockPublisher(gomock.NewController(t))
+			if tt.wantType != "" {
+				mockEvents.EXPECT().Publish(gomock.Any(), tt.wantType, tt.wantEvent).Return(tt.publishErr)
+			}
+			authService.(*service).events = mockEvents
+			tt.mockFn(mockRepo)
+
+			err := tt.run(authService)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
 		})
 	}
 }
@@ -180,8 +844,9 @@ func Test_service_Login(t *testing.T) {
 func TestGenerateToken(t *testing.T) {
 	authService, _ := setupServiceTest(t)
 	mockUser := testutil.NewMockUser()
+	sess := &model.Session{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
 
-	token, err := authService.(*service).generateToken(&mockUser)
+	token, err := authService.(*service).generateToken(&mockUser, sess)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
@@ -196,4 +861,264 @@ func TestGenerateToken(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, mockUser.ID.String(), claims["user_id"])
 	assert.Equal(t, mockUser.Email, claims["email"])
+	assert.Equal(t, sess.ID.String(), claims["sid"])
+	assert.Equal(t, float64(sess.ExpiresAt.Unix()), claims["exp"])
+}
+
+func TestGenerateToken_RotatedSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	store := config.NewStore(cfg)
+	ctrl := gomock.NewController(t)
+	authService := NewService(NewMockRepository(ctrl), passThroughTx(ctrl), email.NewNormalizer(cfg), testPolicy(t), testHasher(), testSessions(ctrl), testHistory(ctrl), testAudit(ctrl), testEvents(ctrl), store, metrics.New())
+	mockUser := testutil.NewMockUser()
+
+	rotated := *cfg
+	rotated.JWTSecret = "rotated-secret"
+	_, err = store.Apply(&rotated)
+	assert.NoError(t, err)
+
+	token, err := authService.(*service).generateToken(&mockUser, &model.Session{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+
+	_, err = jwt.Parse(token, func(_ *jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	assert.Error(t, err)
+
+	_, err = jwt.Parse(token, func(_ *jwt.Token) (interface{}, error) {
+		return []byte("rotated-secret"), nil
+	})
+	assert.NoError(t, err)
+}
+
+func Test_service_Login_Session(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockUser.PasswordHash = string(hashedPassword)
+	client := session.Client{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0", IP: "203.0.113.7"}
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name        string
+		createFn    func(context.Context, uuid.UUID, session.Client, time.Time) (*model.Session, error)
+		errContains string
+		wantResult  string
+	}{
+		{
+			name: "token bound to the new session",
+			createFn: func(_ context.Context, userID uuid.UUID, c session.Client, expiresAt time.Time) (*model.Session, error) {
+				assert.Equal(t, mockUser.ID, userID)
+				assert.Equal(t, client, c)
+				assert.WithinDuration(t, time.Now().Add(24*time.Hour), expiresAt, time.Minute)
+				return &model.Session{ID: sessionID, UserID: userID, ExpiresAt: expiresAt}, nil
+			},
+			wantResult: metrics.LoginSuccess,
+		},
+		{
+			name: "session cannot be created",
+			createFn: func(context.Context, uuid.UUID, session.Client, time.Time) (*model.Session, error) {
+				return nil, gorm.ErrInvalidDB
+			},
+			errContains: "failed to create session",
+			wantResult:  metrics.LoginError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			mockSessions := session.NewMockService(gomock.NewController(t))
+			mockSessions.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(tt.createFn)
+			authService.(*service).sessions = mockSessions
+			mockRepo.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&mockUser, nil)
+
+			token, err := authService.Login(context.Background(), mockUser.Email, "password", client)
+
+			if tt.errContains != "" {
+				assert.EqualError(t, err, tt.errContains)
+			} else {
+				assert.NoError(t, err)
+				parsed, err := jwt.Parse(token, func(_ *jwt.Token) (interface{}, error) {
+					return []byte("test-secret"), nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, sessionID.String(), parsed.Claims.(jwt.MapClaims)["sid"])
+			}
+
+			expected := fmt.Sprintf(`
+# HELP go_auth_api_auth_login_attempts_total Total number of login attempts by result.
+# TYPE go_auth_api_auth_login_attempts_total counter
+go_auth_api_auth_login_attempts_total{result="%s"} 1
+`, tt.wantResult)
+			err = promtestutil.GatherAndCompare(authService.(*service).metrics.Registry(),
+				strings.NewReader(expected), "go_auth_api_auth_login_attempts_total")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_service_Login_History(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockUser.PasswordHash = string(hashedPassword)
+	client := session.Client{UserAgent: "curl/8.4.0", IP: "192.0.2.1"}
+
+	tests := []struct {
+		name      string
+		email     string
+		password  string
+		mockFn    func(*MockRepository)
+		recordErr error
+		want      loginhistory.Attempt
+		wantErr   bool
+	}{
+		{
+			name:     "successful login",
+			email:    mockUser.Email,
+			password: "password",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&mockUser, nil)
+			},
+			want: loginhistory.Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+		},
+		{
+			name:     "wrong password",
+			email:    mockUser.Email,
+			password: "wrong password",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&mockUser, nil)
+			},
+			want:    loginhistory.Attempt{Email: mockUser.Email, User: &mockUser, Client: client, Reason: loginhistory.ReasonWrongPassword},
+			wantErr: true,
+		},
+		{
+			name:     "unknown email",
+			email:    " Someone@EXAMPLE.com",
+			password: "password",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), "Someone@example.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			want:    loginhistory.Attempt{Email: "Someone@example.com", Client: client, Reason: loginhistory.ReasonUnknownEmail},
+			wantErr: true,
+		},
+		{
+			name:     "invalid email",
+			email:    "no-at-sign",
+			password: "password",
+			mockFn:   func(*MockRepository) {},
+			want:     loginhistory.Attempt{Email: "no-at-sign", Client: client, Reason: loginhistory.ReasonInvalidEmail},
+			wantErr:  true,
+		},
+		{
+			name:     "recording failure does not fail the login",
+			email:    mockUser.Email,
+			password: "password",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&mockUser, nil)
+			},
+			recordErr: gorm.ErrInvalidDB,
+			want:      loginhistory.Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			mockHistory := loginhistory.NewMockService(gomock.NewController(t))
+			mockHistory.EXPECT().Record(gomock.Any(), tt.want).Return(tt.recordErr)
+			authService.(*service).history = mockHistory
+			tt.mockFn(mockRepo)
+
+			_, err := authService.Login(context.Background(), tt.email, tt.password, client)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_service_Login_Tracing(t *testing.T) {
+	recorder := testutil.SpanRecorder(t)
+	authService, mockRepo := setupServiceTest(t)
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockUser.PasswordHash = string(hashedPassword)
+	mockRepo.EXPECT().FindByEmail(gomock.Any(), mockUser.Email).Return(&mockUser, nil)
+
+	_, err := authService.Login(context.Background(), mockUser.Email, "password", session.Client{})
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "password.Verify", spans[0].Name())
+	assert.Equal(t, "auth.Service.Login", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func Test_service_Login_UpgradesHash(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+
+	argon2 := password.NewHasher(&config.Config{
+		PasswordHashAlgorithm:     password.AlgorithmArgon2id,
+		PasswordArgon2Memory:      64,
+		PasswordArgon2Iterations:  1,
+		PasswordArgon2Parallelism: 1,
+	})
+	argon2Hash, _ := argon2.Hash("password")
+
+	tests := []struct {
+		name       string
+		storedHash string
+		mockFn     func(*MockRepository)
+	}{
+		{
+			name:       "bcrypt hash replaced with argon2id",
+			storedHash: string(bcryptHash),
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().ReplacePasswordHash(gomock.Any(), mockUser.ID.String(), string(bcryptHash), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _, hash string) error {
+						assert.True(t, strings.HasPrefix(hash, "$argon2id$"))
+						ok, err := argon2.Verify("password", hash)
+						assert.NoError(t, err)
+						assert.True(t, ok)
+						return nil
+					})
+			},
+		},
+		{
+			name:       "failing upgrade does not fail the login",
+			storedHash: string(bcryptHash),
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().ReplacePasswordHash(gomock.Any(), mockUser.ID.String(), string(bcryptHash), gomock.Any()).
+					Return(gorm.ErrInvalidDB)
+			},
+		},
+		{
+			name:       "current hash kept",
+			storedHash: argon2Hash,
+			mockFn:     func(*MockRepository) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, mockRepo := setupServiceTest(t)
+			authService.(*service).hasher = argon2
+
+			user := mockUser
+			user.PasswordHash = tt.storedHash
+			mockRepo.EXPECT().FindByEmail(gomock.Any(), user.Email).Return(&user, nil)
+			tt.mockFn(mockRepo)
+
+			token, err := authService.Login(context.Background(), user.Email, "password", session.Client{})
+			assert.NoError(t, err)
+			assert.NotEmpty(t, token)
+		})
+	}
 }
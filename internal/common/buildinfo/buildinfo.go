@@ -0,0 +1,99 @@
+// Package buildinfo exposes version information about the running binary.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Values injected at build time, e.g.
+//
+//	go build -ldflags "-X github.com/PakornBank/go-backend-example/internal/common/buildinfo.Version=v1.2.3"
+//
+// Empty values fall back to the information embedded by the Go toolchain.
+var (
+	Version   string
+	CommitSHA string
+	BuildDate string
+)
+
+// unknown is reported for values that are neither injected nor embedded.
+const unknown = "unknown"
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	CommitSHA string `json:"commit_sha"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module"`
+	Modified  bool   `json:"modified"`
+}
+
+// get caches the result of reading the build information.
+var get = sync.OnceValue(func() Info {
+	bi, ok := debug.ReadBuildInfo()
+	return newInfo(bi, ok)
+})
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return get()
+}
+
+// String returns a single-line human readable description of the build.
+func (i Info) String() string {
+	s := fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, i.CommitSHA, i.BuildDate, i.GoVersion)
+	if i.Modified {
+		s += " modified"
+	}
+	return s
+}
+
+// newInfo combines the injected values with the embedded build information.
+func newInfo(bi *debug.BuildInfo, ok bool) Info {
+	info := Info{
+		Version:   Version,
+		CommitSHA: CommitSHA,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if ok && bi != nil {
+		info.Module = bi.Main.Path
+		if bi.GoVersion != "" {
+			info.GoVersion = bi.GoVersion
+		}
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.CommitSHA == "" {
+					info.CommitSHA = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.CommitSHA == "" {
+		info.CommitSHA = unknown
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = unknown
+	}
+
+	return info
+}
@@ -0,0 +1,93 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInfo(t *testing.T) {
+	embedded := &debug.BuildInfo{
+		GoVersion: "go1.24.0",
+		Main:      debug.Module{Path: "github.com/PakornBank/go-backend-example", Version: "v1.0.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2025-01-01T00:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		injected [3]string
+		bi       *debug.BuildInfo
+		ok       bool
+		want     Info
+	}{
+		{
+			name: "falls back to embedded build info",
+			bi:   embedded,
+			ok:   true,
+			want: Info{
+				Version:   "v1.0.0",
+				CommitSHA: "abc123",
+				BuildDate: "2025-01-01T00:00:00Z",
+				GoVersion: "go1.24.0",
+				Module:    "github.com/PakornBank/go-backend-example",
+				Modified:  true,
+			},
+		},
+		{
+			name:     "injected values take precedence",
+			injected: [3]string{"v2.0.0", "def456", "2025-02-02"},
+			bi:       embedded,
+			ok:       true,
+			want: Info{
+				Version:   "v2.0.0",
+				CommitSHA: "def456",
+				BuildDate: "2025-02-02",
+				GoVersion: "go1.24.0",
+				Module:    "github.com/PakornBank/go-backend-example",
+				Modified:  true,
+			},
+		},
+		{
+			name: "devel version and no build info",
+			bi:   &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}},
+			ok:   false,
+			want: Info{
+				Version:   "dev",
+				CommitSHA: unknown,
+				BuildDate: unknown,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := [3]string{Version, CommitSHA, BuildDate}
+			Version, CommitSHA, BuildDate = tt.injected[0], tt.injected[1], tt.injected[2]
+			t.Cleanup(func() { Version, CommitSHA, BuildDate = prev[0], prev[1], prev[2] })
+
+			got := newInfo(tt.bi, tt.ok)
+			if tt.want.GoVersion == "" {
+				tt.want.GoVersion = got.GoVersion
+				assert.NotEmpty(t, got.GoVersion)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInfo_String(t *testing.T) {
+	info := Info{Version: "v1.0.0", CommitSHA: "abc123", BuildDate: "2025-01-01", GoVersion: "go1.24.0", Modified: true}
+	assert.Equal(t, "v1.0.0 (commit abc123, built 2025-01-01, go1.24.0) modified", info.String())
+}
+
+func TestGet(t *testing.T) {
+	info := Get()
+	assert.NotEmpty(t, info.Version)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.Equal(t, info, Get())
+}
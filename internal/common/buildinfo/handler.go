@@ -0,0 +1,27 @@
+package buildinfo
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler defines the interface for build information HTTP requests.
+type Handler interface {
+	Version(c *gin.Context)
+}
+
+// handler handles build information HTTP requests.
+type handler struct {
+	info Info
+}
+
+// NewHandler creates a new instance of handler reporting the provided build information.
+func NewHandler(info Info) Handler {
+	return &handler{info: info}
+}
+
+// Version returns the build information of the running binary.
+func (h *handler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, h.info)
+}
@@ -0,0 +1,27 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Version(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	info := Info{Version: "v1.0.0", CommitSHA: "abc123", BuildDate: "2025-01-01", GoVersion: "go1.24.0"}
+	router := gin.New()
+	router.GET("/version", NewHandler(info).Version)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got Info
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, info, got)
+}
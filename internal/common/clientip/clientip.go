@@ -0,0 +1,21 @@
+// Package clientip provides helpers for carrying the IP of the client of a request.
+package clientip
+
+import "context"
+
+// ctxKey is the context key type for the client IP.
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying the provided client IP.
+func WithContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ip)
+}
+
+// FromContext returns the client IP stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ip, _ := ctx.Value(ctxKey{}).(string)
+	return ip
+}
@@ -0,0 +1,14 @@
+package clientip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	ctx := WithContext(context.Background(), "192.0.2.1")
+	assert.Equal(t, "192.0.2.1", FromContext(ctx))
+	assert.Empty(t, FromContext(context.Background()))
+}
@@ -1,55 +1,222 @@
 // Package config provides configuration management for the application.
+//
+// Every field of Config is described by struct tags: `config` names the key
+// used in configuration files, the upper-cased key is the environment variable
+// and the key with dashes is the command line flag. `default` holds the value
+// used when no source sets the field and `secret` marks values that must be
+// masked when the configuration is printed.
+//
+// Sources are applied in increasing order of precedence: defaults, the
+// configuration file, a directory of mounted secret files, environment
+// variables and command line flags. Any environment variable may instead be
+// read from a file by setting the variable with a _FILE suffix, e.g.
+// JWT_SECRET_FILE. Fields tagged `reload` may change while the application
+// is running, see Store and Loader.Reload.
 package config
 
 import (
-	"errors"
-	"fmt"
+	"flag"
 	"os"
+	"strings"
 	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 // Config holds the configuration values for the application.
 type Config struct {
-	DBHost         string
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	DBPort         string
-	ServerPort     string
-	JWTSecret      string
-	TokenExpiryDur time.Duration
-	GinMode        string
+	DBHost     string `config:"db_host" default:"localhost"`
+	DBUser     string `config:"db_user" default:"postgres"`
+	DBPassword string `config:"db_password" secret:"true"`
+	DBName     string `config:"db_name" default:"go_backend_db"`
+	DBPort     string `config:"db_port" default:"5432"`
+	// DatabaseURL is a postgres:// connection URL used instead of the individual
+	// db_host, db_user, db_password, db_name and db_port values when set.
+	DatabaseURL string `config:"database_url" secret:"true"`
+	// DBReplicaURLs is a comma-separated list of postgres:// URLs of read replicas.
+	DBReplicaURLs string `config:"db_replica_urls" secret:"true"`
+	// DBReplicaCheckInterval is how often read replicas are pinged to decide whether they receive reads.
+	DBReplicaCheckInterval time.Duration `config:"db_replica_check_interval" default:"5s"`
+	// DBTxIsolation is the isolation level of transactions, one of read_committed, repeatable_read or serializable.
+	DBTxIsolation string `config:"db_tx_isolation" default:"read_committed"`
+	// DBTxMaxRetries is how often a transaction failing with a serialization failure or deadlock is retried.
+	DBTxMaxRetries int `config:"db_tx_max_retries" default:"3"`
+	// DBTxRetryBackoff is the delay before the first retry, doubled on every further retry.
+	DBTxRetryBackoff time.Duration `config:"db_tx_retry_backoff" default:"20ms"`
+	// DBSSLMode is the libpq sslmode, one of disable, allow, prefer, require, verify-ca or verify-full.
+	DBSSLMode string `config:"db_sslmode" default:"disable"`
+	// DBSSLRootCert is the path of the CA certificate used to verify the server.
+	DBSSLRootCert string `config:"db_sslrootcert"`
+	// DBSSLCert and DBSSLKey are the paths of the client certificate and key.
+	DBSSLCert string `config:"db_sslcert"`
+	DBSSLKey  string `config:"db_sslkey"`
+	// DBConnectTimeout bounds establishing a connection. Zero waits indefinitely.
+	DBConnectTimeout time.Duration `config:"db_connect_timeout" default:"10s"`
+	// DBApplicationName is reported to the server in pg_stat_activity. Defaults to ServiceName.
+	DBApplicationName string `config:"db_application_name"`
+	// DBSearchPath sets the schema search_path for every connection.
+	DBSearchPath string `config:"db_search_path"`
+	// DBStatementTimeout aborts statements running longer than this. Zero disables it.
+	DBStatementTimeout time.Duration `config:"db_statement_timeout" default:"0s"`
+	DBMaxIdleConns     int           `config:"db_max_idle_conns" default:"10"`
+	DBMaxOpenConns     int           `config:"db_max_open_conns" default:"50"`
+	DBConnMaxLifetime  time.Duration `config:"db_conn_max_lifetime" default:"1h"`
+	RepositoryTimeout  time.Duration `config:"repository_timeout" default:"5s"`
+	ServerPort         string        `config:"server_port" default:"8080"`
+	JWTSecret          string        `config:"jwt_secret" secret:"true" reload:"true"`
+	// JWTPreviousSecret is the secret replaced by JWTSecret in a rotation. Tokens
+	// signed with it are still accepted until it is unset.
+	JWTPreviousSecret string        `config:"jwt_previous_secret" secret:"true" reload:"true"`
+	TokenExpiryDur    time.Duration `config:"token_expiry" default:"24h" reload:"true"`
+	GinMode           string        `config:"gin_mode" default:"debug"`
+	LogLevel          string        `config:"log_level" default:"info" reload:"true"`
+	LogFormat         string        `config:"log_format" default:"text"`
+	MetricsPort       string        `config:"metrics_port"`
+	ServiceName       string        `config:"service_name" default:"go-auth-api"`
+	TracingExporter   string        `config:"tracing_exporter" default:"none"`
+	TracingEndpoint   string        `config:"tracing_endpoint" default:"localhost:4318"`
+	TracingInsecure   bool          `config:"tracing_insecure" default:"false"`
+	// EmailLowercaseLocal lowercases the local part of email addresses in addition to the domain.
+	EmailLowercaseLocal bool `config:"email_lowercase_local" default:"false"`
+	// EmailPunycodeDomain converts internationalized email domains to their punycode form.
+	EmailPunycodeDomain bool `config:"email_punycode_domain" default:"true"`
+	// PasswordHashAlgorithm is the algorithm new password hashes are created with, bcrypt or argon2id.
+	// Hashes of another algorithm or with weaker parameters are replaced on the next successful login.
+	PasswordHashAlgorithm string `config:"password_hash_algorithm" default:"argon2id"`
+	// PasswordBcryptCost is the cost of bcrypt hashes.
+	PasswordBcryptCost int `config:"password_bcrypt_cost" default:"10"`
+	// PasswordArgon2Memory, PasswordArgon2Iterations and PasswordArgon2Parallelism are the
+	// memory in KiB, number of passes and degree of parallelism of argon2id hashes.
+	PasswordArgon2Memory      int `config:"password_argon2_memory" default:"19456"`
+	PasswordArgon2Iterations  int `config:"password_argon2_iterations" default:"2"`
+	PasswordArgon2Parallelism int `config:"password_argon2_parallelism" default:"1"`
+	// PasswordMinLength is the minimum number of characters of a password.
+	PasswordMinLength int `config:"password_min_length" default:"8"`
+	// PasswordMaxBytes is the maximum length of a password in bytes. bcrypt ignores everything past 72 bytes.
+	PasswordMaxBytes int `config:"password_max_bytes" default:"72"`
+	// PasswordRequireUpper, PasswordRequireLower, PasswordRequireDigit and
+	// PasswordRequireSymbol require at least one character of the class.
+	PasswordRequireUpper  bool `config:"password_require_upper" default:"false"`
+	PasswordRequireLower  bool `config:"password_require_lower" default:"false"`
+	PasswordRequireDigit  bool `config:"password_require_digit" default:"false"`
+	PasswordRequireSymbol bool `config:"password_require_symbol" default:"false"`
+	// PasswordDisallowPersonal rejects passwords containing the email address or a part of the user's name.
+	PasswordDisallowPersonal bool `config:"password_disallow_personal" default:"true"`
+	// PasswordMinEntropy is the minimum estimated entropy of a password in bits. Zero disables the check.
+	PasswordMinEntropy int `config:"password_min_entropy" default:"30"`
+	// PasswordHistorySize is how many of a user's most recent passwords cannot be chosen again. Zero disables the check.
+	PasswordHistorySize int `config:"password_history_size" default:"5"`
+	// PasswordBreachedList is the path of a list of SHA-1 hashes of breached passwords,
+	// either a single file or a directory of files named after a 5 character hash prefix.
+	PasswordBreachedList string `config:"password_breached_list"`
+	// SMTPHost is the mail server notifications are sent through. When empty,
+	// notifications are only logged.
+	SMTPHost string `config:"smtp_host"`
+	SMTPPort string `config:"smtp_port" default:"587"`
+	// SMTPUsername and SMTPPassword authenticate with the mail server when set.
+	SMTPUsername string `config:"smtp_username"`
+	SMTPPassword string `config:"smtp_password" secret:"true" reload:"true"`
+	// MailFrom is the sender address of notifications.
+	MailFrom string `config:"mail_from" default:"no-reply@localhost"`
+	// LoginAlertNewDevice emails users when they log in from a device not seen before.
+	LoginAlertNewDevice bool `config:"login_alert_new_device" default:"true"`
+	// AdminUserIDs is a comma-separated list of the IDs of users allowed to use the admin API.
+	AdminUserIDs string `config:"admin_user_ids" reload:"true"`
+	// OutboxPollInterval is how often the outbox is checked for events due for delivery.
+	OutboxPollInterval time.Duration `config:"outbox_poll_interval" default:"1s"`
+	// OutboxBatchSize is the maximum number of outbox events delivered at once.
+	OutboxBatchSize int `config:"outbox_batch_size" default:"100"`
+	// OutboxMaxAttempts is how often delivering an event is attempted before it is dead-lettered.
+	OutboxMaxAttempts int `config:"outbox_max_attempts" default:"10"`
+	// OutboxRetryBackoff is the delay before the first retry of an event, doubled on every
+	// further retry up to OutboxMaxBackoff.
+	OutboxRetryBackoff time.Duration `config:"outbox_retry_backoff" default:"1s"`
+	OutboxMaxBackoff   time.Duration `config:"outbox_max_backoff" default:"1h"`
+	// OutboxLease bounds how long delivering a batch of events may take. Events
+	// not delivered within the lease are delivered again.
+	OutboxLease time.Duration `config:"outbox_lease" default:"1m"`
+	// OutboxRetention is how long delivered and dead events are kept.
+	OutboxRetention time.Duration `config:"outbox_retention" default:"168h"`
+	// OutboxPurgeSchedule is the cron expression, in UTC, of the removal of events older than OutboxRetention.
+	OutboxPurgeSchedule string `config:"outbox_purge_schedule" default:"15 * * * *"`
+	// WebhookPollInterval is how often webhook deliveries due for sending are checked for.
+	WebhookPollInterval time.Duration `config:"webhook_poll_interval" default:"1s"`
+	// WebhookBatchSize is the maximum number of webhook deliveries sent at once.
+	WebhookBatchSize int `config:"webhook_batch_size" default:"20"`
+	// WebhookMaxAttempts is how often sending a delivery is attempted before it fails.
+	WebhookMaxAttempts int `config:"webhook_max_attempts" default:"8"`
+	// WebhookRetryBackoff is the delay before the first retry of a delivery, doubled on every
+	// further retry up to WebhookMaxBackoff.
+	WebhookRetryBackoff time.Duration `config:"webhook_retry_backoff" default:"10s"`
+	WebhookMaxBackoff   time.Duration `config:"webhook_max_backoff" default:"6h"`
+	// WebhookTimeout bounds how long an endpoint may take to answer a delivery.
+	WebhookTimeout time.Duration `config:"webhook_timeout" default:"10s"`
+	// WebhookLease bounds how long sending a batch of deliveries may take. Deliveries
+	// not sent within the lease are sent again.
+	WebhookLease time.Duration `config:"webhook_lease" default:"5m"`
+	// JobConcurrency is the number of background jobs run at once by every instance.
+	JobConcurrency int `config:"job_concurrency" default:"4"`
+	// JobPollInterval is how often idle workers check for jobs due to run.
+	JobPollInterval time.Duration `config:"job_poll_interval" default:"1s"`
+	// JobMaxAttempts is how often running a job is attempted before it fails, unless set when enqueuing it.
+	JobMaxAttempts int `config:"job_max_attempts" default:"5"`
+	// JobRetryBackoff is the delay before the first retry of a job, doubled on every
+	// further retry up to JobMaxBackoff.
+	JobRetryBackoff time.Duration `config:"job_retry_backoff" default:"10s"`
+	JobMaxBackoff   time.Duration `config:"job_max_backoff" default:"1h"`
+	// JobTimeout bounds how long a job may run.
+	JobTimeout time.Duration `config:"job_timeout" default:"5m"`
+	// JobLease is how long a claimed job is leased to its worker. A job whose
+	// worker did not finish it within the lease is run again. It must exceed
+	// JobTimeout, so that a job is not run again while its handler runs.
+	JobLease time.Duration `config:"job_lease" default:"6m"`
+	// JobRetention is how long succeeded and failed jobs are kept.
+	JobRetention time.Duration `config:"job_retention" default:"720h"`
+	// JobPurgeSchedule is the cron expression, in UTC, of the removal of jobs older than JobRetention.
+	JobPurgeSchedule string `config:"job_purge_schedule" default:"45 * * * *"`
+	// SchedulerLease is how long the replica running scheduled tasks leads
+	// without renewing its lease. Another replica takes over once the lease of a
+	// leader that stopped has expired.
+	SchedulerLease time.Duration `config:"scheduler_lease" default:"30s"`
+	// SessionPurgeSchedule is the cron expression, in UTC, of the removal of expired and revoked sessions.
+	SessionPurgeSchedule string `config:"session_purge_schedule" default:"0 * * * *"`
+	// RetentionInactiveMonths is how many months an account may go without a
+	// login before it is disabled or deleted. Zero keeps accounts forever.
+	RetentionInactiveMonths int `config:"retention_inactive_months" default:"0"`
+	// RetentionNotice is how long before an inactive account is disabled or
+	// deleted its owner is notified by email.
+	RetentionNotice time.Duration `config:"retention_notice" default:"336h"`
+	// RetentionAction is what is done to inactive accounts: disable or delete.
+	RetentionAction string `config:"retention_action" default:"disable"`
+	// RetentionDryRun logs what the retention policy would do instead of doing it.
+	RetentionDryRun bool `config:"retention_dry_run" default:"false"`
+	// RetentionBatchSize bounds the number of accounts handled by a run of the retention policy.
+	RetentionBatchSize int `config:"retention_batch_size" default:"100"`
+	// RetentionSchedule is the cron expression, in UTC, of the runs of the retention policy.
+	RetentionSchedule string `config:"retention_schedule" default:"30 3 * * *"`
+	// SecretsRefreshInterval is how often mounted secret files, and the configuration
+	// file when ConfigWatch is set, are checked for changes. Zero disables it.
+	SecretsRefreshInterval time.Duration `config:"secrets_refresh_interval" default:"30s"`
+	// ConfigWatch reloads the configuration file when it changes, in addition to on SIGHUP.
+	ConfigWatch bool `config:"config_watch" default:"false"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish on shutdown.
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" default:"10s"`
+	// ShutdownDrainDelay is how long readiness reports failure before the
+	// server stops accepting connections, giving load balancers time to drain.
+	ShutdownDrainDelay time.Duration `config:"shutdown_drain_delay" default:"5s"`
 }
 
-// LoadConfig loads the configuration from environment variables and returns a Config struct.
-func LoadConfig() (*Config, error) {
-	// Only try to load .env file in development (when file exists and is readable)
-	if _, err := os.Stat(".env"); err == nil {
-		if err := godotenv.Load(); err != nil {
-			fmt.Printf("Warning: .env file exists but couldn't be loaded: %v\n", err)
+// IsAdmin reports whether userID is one of AdminUserIDs, ignoring case.
+func (c *Config) IsAdmin(userID string) bool {
+	for _, admin := range splitList(c.AdminUserIDs) {
+		if strings.EqualFold(admin, userID) {
+			return true
 		}
 	}
+	return false
+}
 
-	config := &Config{
-		DBHost:         getEnv("DB_HOST", "localhost"),
-		DBUser:         getEnv("DB_USER", "postgres"),
-		DBPassword:     getEnv("DB_PASSWORD", ""),
-		DBName:         getEnv("DB_NAME", "go_backend_db"),
-		DBPort:         getEnv("DB_PORT", "5432"),
-		ServerPort:     getEnv("SERVER_PORT", "8080"),
-		JWTSecret:      getEnv("JWT_SECRET", ""),
-		TokenExpiryDur: 24 * time.Hour,
-		GinMode:        getEnv("GIN_MODE", string(gin.DebugMode)),
-	}
-
-	if config.JWTSecret == "" {
-		return nil, errors.New("JWT_SECRET environment variable must be set")
-	}
-
-	return config, nil
+// LoadConfig loads the configuration from the default sources without command line flags.
+func LoadConfig() (*Config, error) {
+	return NewLoader(flag.NewFlagSet("config", flag.ContinueOnError)).Load()
 }
 
 // getEnv retrieves the value of the environment variable named by the key.
@@ -60,11 +227,3 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
-
-// DBURL constructs and returns the database connection URL string
-func (c *Config) DBURL() string {
-	return fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		c.DBHost, c.DBUser, c.DBPassword, c.DBName, c.DBPort,
-	)
-}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// databaseURLSchemes lists the schemes accepted for database_url.
+var databaseURLSchemes = []string{"postgres", "postgresql"}
+
+// DBURL constructs and returns the database connection URL. Every component
+// is escaped, so credentials may contain any character. When database_url is
+// set it is used as the base and the connection options configured here only
+// fill in parameters the URL does not already specify.
+func (c *Config) DBURL() string {
+	if c.DatabaseURL != "" {
+		return c.withParams(c.DatabaseURL)
+	}
+
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.User(c.DBUser),
+		Host:   net.JoinHostPort(c.DBHost, c.DBPort),
+		Path:   "/" + c.DBName,
+	}
+	if c.DBPassword != "" {
+		u.User = url.UserPassword(c.DBUser, c.DBPassword)
+	}
+	return c.withParams(u.String())
+}
+
+// ReplicaDBURLs returns the connection URLs of the configured read replicas,
+// with the connection options applied as for DBURL.
+func (c *Config) ReplicaDBURLs() []string {
+	var urls []string
+	for _, raw := range splitList(c.DBReplicaURLs) {
+		urls = append(urls, c.withParams(raw))
+	}
+	return urls
+}
+
+// withParams adds the configured connection options that raw does not already specify.
+func (c *Config) withParams(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	query := u.Query()
+	for key, value := range c.dbParams() {
+		if value != "" && !query.Has(key) {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// dbParams returns the connection parameters derived from the configuration.
+func (c *Config) dbParams() map[string]string {
+	applicationName := c.DBApplicationName
+	if applicationName == "" {
+		applicationName = c.ServiceName
+	}
+
+	params := map[string]string{
+		"sslmode":          c.DBSSLMode,
+		"sslrootcert":      c.DBSSLRootCert,
+		"sslcert":          c.DBSSLCert,
+		"sslkey":           c.DBSSLKey,
+		"application_name": applicationName,
+		"search_path":      c.DBSearchPath,
+	}
+	if c.DBConnectTimeout > 0 {
+		params["connect_timeout"] = strconv.Itoa(int(c.DBConnectTimeout.Round(time.Second) / time.Second))
+	}
+	if c.DBStatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(c.DBStatementTimeout.Milliseconds(), 10)
+	}
+	return params
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
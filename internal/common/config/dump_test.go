@@ -0,0 +1,29 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_Dump(t *testing.T) {
+	c := defaultConfig("super-secret")
+	c.DBPassword = ""
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Dump(&buf))
+
+	assert.NotContains(t, buf.String(), "super-secret")
+
+	var got map[string]string
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, maskedValue, got["jwt_secret"])
+	assert.Equal(t, "", got["db_password"])
+	assert.Equal(t, "24h0m0s", got["token_expiry"])
+	assert.Equal(t, "50", got["db_max_open_conns"])
+	assert.Equal(t, "localhost", got["db_host"])
+	assert.Len(t, got, len(fields))
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// configFileFlag is the command line flag selecting the configuration file.
+	configFileFlag = "config"
+	// configFileEnv is the environment variable selecting the configuration file.
+	configFileEnv = "CONFIG_FILE"
+	// secretsDirFlag is the command line flag selecting the directory of mounted secret files.
+	secretsDirFlag = "secrets-dir"
+	// secretsDirEnv is the environment variable selecting the directory of mounted secret files.
+	secretsDirEnv = "SECRETS_DIR"
+	// fileEnvSuffix marks an environment variable holding the path of a file with the value.
+	fileEnvSuffix = "_FILE"
+	// maskedValue replaces secrets when the configuration is printed.
+	maskedValue = "********"
+)
+
+// field describes how a single Config field is loaded.
+type field struct {
+	key    string
+	env    string
+	flag   string
+	def    string
+	secret bool
+	reload bool
+	index  int
+}
+
+// fields lists every configurable field of Config in declaration order.
+var fields = parseFields()
+
+// parseFields derives the field descriptions from the Config struct tags.
+func parseFields() []field {
+	t := reflect.TypeOf(Config{})
+	result := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		result = append(result, field{
+			key:    key,
+			env:    strings.ToUpper(key),
+			flag:   strings.ReplaceAll(key, "_", "-"),
+			def:    sf.Tag.Get("default"),
+			secret: sf.Tag.Get("secret") == "true",
+			reload: sf.Tag.Get("reload") == "true",
+			index:  i,
+		})
+	}
+	return result
+}
+
+// lookupField returns the description of the field with the given key.
+func lookupField(key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// Loader merges configuration from defaults, a configuration file, a
+// directory of mounted secret files, environment variables and command line flags.
+type Loader struct {
+	flags      *flag.FlagSet
+	configFile *string
+	secretsDir *string
+	flagKeys   map[string]string
+
+	mu       sync.Mutex
+	watched  []string
+	sum      [sha256.Size]byte
+	reloadMu sync.Mutex
+}
+
+// NewLoader creates a new Loader and registers a command line flag for every
+// configuration field on fs, except secrets, which would show in process
+// listings and shell history. Load must be called after fs has been parsed.
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{
+		flags:      fs,
+		configFile: fs.String(configFileFlag, "", "path to a YAML or TOML configuration file (env "+configFileEnv+")"),
+		secretsDir: fs.String(secretsDirFlag, "", "directory of mounted secret files named after keys (env "+secretsDirEnv+")"),
+		flagKeys:   make(map[string]string, len(fields)),
+	}
+	for _, f := range fields {
+		if f.secret {
+			continue
+		}
+		usage := fmt.Sprintf("%s (env %s)", f.key, f.env)
+		if f.def != "" {
+			usage += fmt.Sprintf(" (default %q)", f.def)
+		}
+		fs.String(f.flag, "", usage)
+		l.flagKeys[f.flag] = f.key
+	}
+	return l
+}
+
+// Load merges all sources, validates the result and returns the configuration.
+// Every problem found is reported in the returned error.
+func (l *Loader) Load() (*Config, error) {
+	// Only try to load .env file in development (when file exists and is readable)
+	if _, err := os.Stat(".env"); err == nil {
+		if err := godotenv.Load(); err != nil {
+			fmt.Printf("Warning: .env file exists but couldn't be loaded: %v\n", err)
+		}
+	}
+
+	values := make(map[string]string, len(fields))
+	for _, f := range fields {
+		values[f.key] = f.def
+	}
+
+	var errs []error
+
+	path := *l.configFile
+	if path == "" {
+		path = getEnv(configFileEnv, "")
+	}
+	if path != "" {
+		fileValues, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileValues {
+			if _, known := values[key]; !known {
+				errs = append(errs, fmt.Errorf("%s: unknown key in %s", key, path))
+				continue
+			}
+			values[key] = value
+		}
+	}
+
+	var watched []string
+	h := sha256.New()
+
+	if dir := l.secretsDirectory(); dir != "" {
+		for _, f := range fields {
+			for _, name := range []string{f.key, f.env} {
+				path := filepath.Join(dir, name)
+				watched = append(watched, path)
+				value, err := readSecretFile(path)
+				hashSecret(h, path, value, err)
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+					continue
+				}
+				values[f.key] = value
+			}
+		}
+	}
+
+	for _, f := range fields {
+		value, exists := os.LookupEnv(f.env)
+		path, fromFile := os.LookupEnv(f.env + fileEnvSuffix)
+		switch {
+		case exists && fromFile:
+			errs = append(errs, fmt.Errorf("%s: only one of %s and %s may be set", f.key, f.env, f.env+fileEnvSuffix))
+		case fromFile:
+			watched = append(watched, path)
+			v, err := readSecretFile(path)
+			hashSecret(h, path, v, err)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+				continue
+			}
+			values[f.key] = v
+		case exists:
+			values[f.key] = value
+		}
+	}
+
+	l.flags.Visit(func(fl *flag.Flag) {
+		if key, ok := l.flagKeys[fl.Name]; ok {
+			values[key] = fl.Value.String()
+		}
+	})
+
+	config := &Config{}
+	for _, f := range fields {
+		if err := setField(config, f, values[f.key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+		}
+	}
+	errs = append(errs, config.validate()...)
+
+	if path != "" && config.ConfigWatch {
+		watched = append(watched, path)
+		value, err := readSecretFile(path)
+		hashSecret(h, path, value, err)
+	}
+
+	l.mu.Lock()
+	l.watched = watched
+	copy(l.sum[:], h.Sum(nil))
+	l.mu.Unlock()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return config, nil
+}
+
+// secretsDirectory returns the configured directory of mounted secret files.
+func (l *Loader) secretsDirectory() string {
+	if *l.secretsDir != "" {
+		return *l.secretsDir
+	}
+	return getEnv(secretsDirEnv, "")
+}
+
+// readSecretFile reads a value from a file, dropping the trailing newline editors and
+// Kubernetes secret volumes commonly leave behind.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("secret file %s: %w", path, os.ErrNotExist)
+		}
+		return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readFile reads a flat YAML or TOML configuration file into raw string values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			values[key] = ""
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("failed to parse config file: %s must be a scalar value", key)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// setField parses value according to the type of the field and stores it in config.
+func setField(config *Config, f field, value string) error {
+	v := reflect.ValueOf(config).Elem().Field(f.index)
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		v.SetInt(int64(d))
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// formatField returns the string representation of a field of config.
+func formatField(config *Config, f field) string {
+	switch v := reflect.ValueOf(config).Elem().Field(f.index).Interface().(type) {
+	case time.Duration:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
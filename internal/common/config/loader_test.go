@@ -0,0 +1,235 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes content to a file named name in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoader_Precedence(t *testing.T) {
+	os.Clearenv()
+	path := writeFile(t, "config.yaml", `
+jwt_secret: file-secret
+db_host: file-host
+log_level: warn
+token_expiry: 2h
+db_max_open_conns: 20
+`)
+	os.Setenv("LOG_LEVEL", "error")
+	os.Setenv("DB_HOST", "env-host")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := NewLoader(fs)
+	require.NoError(t, fs.Parse([]string{"-config", path, "-db-host", "flag-host"}))
+
+	got, err := loader.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "file-secret", got.JWTSecret)
+	assert.Equal(t, 2*time.Hour, got.TokenExpiryDur)
+	assert.Equal(t, 20, got.DBMaxOpenConns)
+	assert.Equal(t, "error", got.LogLevel)
+	assert.Equal(t, "flag-host", got.DBHost)
+	assert.Equal(t, "postgres", got.DBUser)
+}
+
+func TestLoader_ConfigFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		wantErr     bool
+		errContains string
+		check       func(*testing.T, *Config)
+	}{
+		{
+			name:    "toml file",
+			file:    "config.toml",
+			content: "jwt_secret = \"toml-secret\"\nrepository_timeout = \"2s\"\ndb_max_idle_conns = 3\ntracing_insecure = true\n",
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "toml-secret", c.JWTSecret)
+				assert.Equal(t, 2*time.Second, c.RepositoryTimeout)
+				assert.Equal(t, 3, c.DBMaxIdleConns)
+				assert.True(t, c.TracingInsecure)
+			},
+		},
+		{
+			name:        "unknown key",
+			file:        "config.yaml",
+			content:     "jwt_secret: secret\ndb_hots: typo\n",
+			wantErr:     true,
+			errContains: "db_hots: unknown key",
+		},
+		{
+			name:        "nested value",
+			file:        "config.yaml",
+			content:     "db:\n  host: nested\n",
+			wantErr:     true,
+			errContains: "db must be a scalar value",
+		},
+		{
+			name:        "unsupported format",
+			file:        "config.json",
+			content:     "{}",
+			wantErr:     true,
+			errContains: "unsupported config file format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("CONFIG_FILE", writeFile(t, tt.file, tt.content))
+
+			got, err := LoadConfig()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestLoader_MissingFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	_, err := LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read config file")
+}
+
+func TestLoader_AggregatesErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_MAX_OPEN_CONNS", "many")
+	os.Setenv("LOG_FORMAT", "xml")
+	os.Setenv("SERVER_PORT", "70000")
+
+	_, err := LoadConfig()
+	require.Error(t, err)
+	for _, want := range []string{
+		"invalid configuration",
+		`db_max_open_conns: invalid integer "many"`,
+		"log_format: must be one of [text json]",
+		`server_port: invalid port "70000"`,
+		"jwt_secret: must be set",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestNewLoader_RegistersFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	NewLoader(fs)
+
+	assert.NotNil(t, fs.Lookup("config"))
+	for _, f := range fields {
+		if f.secret {
+			assert.Nil(t, fs.Lookup(f.flag), f.flag)
+		} else {
+			assert.NotNil(t, fs.Lookup(f.flag), f.flag)
+		}
+	}
+	assert.Nil(t, fs.Lookup("jwt-secret"))
+	assert.NotNil(t, fs.Lookup("shutdown-drain-delay"))
+}
+
+func TestLoader_SecretFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, fs *flag.FlagSet) []string
+		wantErr     bool
+		errContains string
+		check       func(*testing.T, *Config)
+	}{
+		{
+			name: "secrets directory named by key",
+			setup: func(t *testing.T, _ *flag.FlagSet) []string {
+				dir := t.TempDir()
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("dir-secret\n"), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("dir-password"), 0o600))
+				return []string{"-secrets-dir", dir}
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "dir-secret", c.JWTSecret)
+				assert.Equal(t, "dir-password", c.DBPassword)
+			},
+		},
+		{
+			name: "environment overrides secrets directory",
+			setup: func(t *testing.T, _ *flag.FlagSet) []string {
+				dir := t.TempDir()
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("dir-secret"), 0o600))
+				os.Setenv("SECRETS_DIR", dir)
+				os.Setenv("JWT_SECRET", "env-secret")
+				return nil
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "env-secret", c.JWTSecret)
+			},
+		},
+		{
+			name: "_FILE environment variable",
+			setup: func(t *testing.T, _ *flag.FlagSet) []string {
+				os.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", "file-secret\r\n"))
+				return nil
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "file-secret", c.JWTSecret)
+			},
+		},
+		{
+			name: "both variable and _FILE set",
+			setup: func(t *testing.T, _ *flag.FlagSet) []string {
+				os.Setenv("JWT_SECRET", "env-secret")
+				os.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", "file-secret"))
+				return nil
+			},
+			wantErr:     true,
+			errContains: "jwt_secret: only one of JWT_SECRET and JWT_SECRET_FILE may be set",
+		},
+		{
+			name: "missing _FILE target",
+			setup: func(t *testing.T, _ *flag.FlagSet) []string {
+				os.Setenv("JWT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+				return nil
+			},
+			wantErr:     true,
+			errContains: "jwt_secret: secret file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			loader := NewLoader(fs)
+			require.NoError(t, fs.Parse(tt.setup(t, fs)))
+
+			got, err := loader.Load()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, got)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Change describes a configuration value that differs between two configurations.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// String returns the change in key: old -> new form, masking secrets.
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+// Store holds the current configuration, allows reloadable values to be
+// swapped atomically and notifies subscribers when they change.
+type Store struct {
+	mu          sync.Mutex
+	current     atomic.Pointer[Config]
+	subscribers []func(old, new *Config)
+}
+
+// NewStore creates a new Store holding the provided configuration.
+func NewStore(config *Config) *Store {
+	s := &Store{}
+	s.current.Store(config)
+	return s
+}
+
+// Get returns the current configuration. The returned value must not be modified.
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+// Subscribe registers fn to be called after every successful Apply that changed a value.
+func (s *Store) Subscribe(fn func(old, new *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Apply validates next and makes it the current configuration. It fails
+// without changing anything when next is invalid or when a value that is not
+// marked reloadable differs from the current configuration.
+func (s *Store) Apply(next *Config) ([]Change, error) {
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.current.Load()
+	changes := Diff(old, next)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var rejected []string
+	for _, c := range changes {
+		if f, ok := lookupField(c.Key); !ok || !f.reload {
+			rejected = append(rejected, c.Key)
+		}
+	}
+	if len(rejected) > 0 {
+		return changes, fmt.Errorf("restart required to change %s", strings.Join(rejected, ", "))
+	}
+
+	s.current.Store(next)
+	for _, fn := range s.subscribers {
+		fn(old, next)
+	}
+
+	return changes, nil
+}
+
+// Diff returns the values that differ between old and new, masking secrets.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	for _, f := range fields {
+		o, n := formatField(old, f), formatField(new, f)
+		if o == n {
+			continue
+		}
+		if f.secret {
+			o, n = maskedValue, maskedValue
+		}
+		changes = append(changes, Change{Key: f.key, Old: o, New: n})
+	}
+	return changes
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Config)
+		wantChanges []Change
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:   "no changes",
+			modify: func(*Config) {},
+		},
+		{
+			name:        "reloadable secret",
+			modify:      func(c *Config) { c.JWTSecret = "rotated" },
+			wantChanges: []Change{{Key: "jwt_secret", Old: maskedValue, New: maskedValue}},
+		},
+		{
+			name:        "restart required",
+			modify:      func(c *Config) { c.ServerPort = "9000"; c.JWTSecret = "rotated" },
+			wantChanges: []Change{{Key: "server_port", Old: "8080", New: "9000"}, {Key: "jwt_secret", Old: maskedValue, New: maskedValue}},
+			wantErr:     true,
+			errContains: "restart required to change server_port",
+		},
+		{
+			name:        "invalid configuration",
+			modify:      func(c *Config) { c.JWTSecret = "" },
+			wantErr:     true,
+			errContains: "jwt_secret: must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initial := defaultConfig("secret")
+			store := NewStore(initial)
+			notified := 0
+			store.Subscribe(func(old, new *Config) {
+				notified++
+				assert.Equal(t, initial, old)
+			})
+
+			next := *initial
+			tt.modify(&next)
+
+			changes, err := store.Apply(&next)
+			assert.Equal(t, tt.wantChanges, changes)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Same(t, initial, store.Get())
+				assert.Zero(t, notified)
+				return
+			}
+			require.NoError(t, err)
+			if len(tt.wantChanges) > 0 {
+				assert.Same(t, &next, store.Get())
+				assert.Equal(t, 1, notified)
+			} else {
+				assert.Same(t, initial, store.Get())
+				assert.Zero(t, notified)
+			}
+		})
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	c := Change{Key: "token_expiry", Old: (time.Hour).String(), New: (2 * time.Hour).String()}
+	assert.Equal(t, "token_expiry: 1h0m0s -> 2h0m0s", c.String())
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/cron"
+	"github.com/google/uuid"
+)
+
+var (
+	ginModes         = []string{"debug", "release", "test"}
+	logLevels        = []string{"debug", "info", "warn", "error"}
+	logFormats       = []string{"text", "json"}
+	dbSSLModes       = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	dbTxIsolations   = []string{"read_committed", "repeatable_read", "serializable"}
+	tracingExporters = []string{"none", "stdout", "otlp"}
+	hashAlgorithms   = []string{"bcrypt", "argon2id"}
+	retentionActions = []string{"disable", "delete"}
+)
+
+// Validate checks the configuration and returns every problem found, joined into a single error.
+func (c *Config) Validate() error {
+	return errors.Join(c.validate()...)
+}
+
+// validate checks the configuration and returns every problem found.
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]interface{}{key}, args...)...))
+		}
+	}
+
+	check(c.JWTSecret != "", "jwt_secret", "must be set")
+	check(c.JWTPreviousSecret == "" || c.JWTPreviousSecret != c.JWTSecret, "jwt_previous_secret", "must differ from jwt_secret")
+	if c.DatabaseURL != "" {
+		errs = append(errs, validDatabaseURL("database_url", c.DatabaseURL)...)
+	} else {
+		check(c.DBHost != "", "db_host", "must be set")
+		check(c.DBUser != "", "db_user", "must be set")
+		check(c.DBName != "", "db_name", "must be set")
+		check(validPort(c.DBPort), "db_port", "invalid port %q", c.DBPort)
+	}
+	for _, u := range splitList(c.DBReplicaURLs) {
+		errs = append(errs, validDatabaseURL("db_replica_urls", u)...)
+	}
+	check(c.DBReplicaCheckInterval > 0, "db_replica_check_interval", "must be positive")
+	check(oneOf(c.DBTxIsolation, dbTxIsolations), "db_tx_isolation", "must be one of %v", dbTxIsolations)
+	check(c.DBTxMaxRetries >= 0, "db_tx_max_retries", "must not be negative")
+	check(c.DBTxRetryBackoff >= 0, "db_tx_retry_backoff", "must not be negative")
+	check(oneOf(c.DBSSLMode, dbSSLModes), "db_sslmode", "must be one of %v", dbSSLModes)
+	check((c.DBSSLCert == "") == (c.DBSSLKey == ""), "db_sslcert", "must be set together with db_sslkey")
+	for key, path := range map[string]string{
+		"db_sslrootcert": c.DBSSLRootCert,
+		"db_sslcert":     c.DBSSLCert,
+		"db_sslkey":      c.DBSSLKey,
+	} {
+		if path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, key, "cannot read %q", path)
+		}
+	}
+	check(c.DBConnectTimeout == 0 || c.DBConnectTimeout >= time.Second, "db_connect_timeout", "must be zero or at least 1s")
+	check(c.DBStatementTimeout >= 0, "db_statement_timeout", "must not be negative")
+	check(oneOf(c.PasswordHashAlgorithm, hashAlgorithms), "password_hash_algorithm", "must be one of %v", hashAlgorithms)
+	check(c.PasswordBcryptCost >= 4 && c.PasswordBcryptCost <= 31, "password_bcrypt_cost", "must be between 4 and 31")
+	check(c.PasswordArgon2Memory >= 8*c.PasswordArgon2Parallelism && uint64(c.PasswordArgon2Memory) <= math.MaxUint32,
+		"password_argon2_memory", "must be at least 8 KiB per unit of parallelism")
+	check(c.PasswordArgon2Iterations >= 1 && uint64(c.PasswordArgon2Iterations) <= math.MaxUint32,
+		"password_argon2_iterations", "must be positive")
+	check(c.PasswordArgon2Parallelism >= 1 && c.PasswordArgon2Parallelism <= math.MaxUint8,
+		"password_argon2_parallelism", "must be between 1 and 255")
+	check(c.PasswordMinLength > 0, "password_min_length", "must be positive")
+	check(c.PasswordMaxBytes > 0 && c.PasswordMaxBytes <= 72, "password_max_bytes", "must be between 1 and 72")
+	check(c.PasswordMinLength <= c.PasswordMaxBytes, "password_min_length", "must not exceed password_max_bytes")
+	check(c.PasswordMinEntropy >= 0, "password_min_entropy", "must not be negative")
+	check(c.PasswordHistorySize >= 0, "password_history_size", "must not be negative")
+	if c.PasswordBreachedList != "" {
+		_, err := os.Stat(c.PasswordBreachedList)
+		check(err == nil, "password_breached_list", "cannot read %q", c.PasswordBreachedList)
+	}
+	if c.SMTPHost != "" {
+		check(validPort(c.SMTPPort), "smtp_port", "invalid port %q", c.SMTPPort)
+		check(strings.Contains(c.MailFrom, "@"), "mail_from", "must be an email address")
+	}
+	for _, id := range splitList(c.AdminUserIDs) {
+		_, err := uuid.Parse(id)
+		check(err == nil, "admin_user_ids", "invalid user ID %q", id)
+	}
+	check(c.OutboxBatchSize > 0, "outbox_batch_size", "must be positive")
+	check(c.OutboxMaxAttempts > 0, "outbox_max_attempts", "must be positive")
+	check(c.OutboxMaxBackoff >= c.OutboxRetryBackoff, "outbox_max_backoff", "must not be less than outbox_retry_backoff")
+	if _, err := cron.Parse(c.OutboxPurgeSchedule); err != nil {
+		check(false, "outbox_purge_schedule", "%v", err)
+	}
+	check(c.WebhookBatchSize > 0, "webhook_batch_size", "must be positive")
+	check(c.WebhookMaxAttempts > 0, "webhook_max_attempts", "must be positive")
+	check(c.WebhookMaxBackoff >= c.WebhookRetryBackoff, "webhook_max_backoff", "must not be less than webhook_retry_backoff")
+	check(c.WebhookLease > c.WebhookTimeout, "webhook_lease", "must exceed webhook_timeout")
+	check(c.JobConcurrency > 0, "job_concurrency", "must be positive")
+	check(c.JobMaxAttempts > 0, "job_max_attempts", "must be positive")
+	check(c.JobMaxBackoff >= c.JobRetryBackoff, "job_max_backoff", "must not be less than job_retry_backoff")
+	check(c.JobLease > c.JobTimeout, "job_lease", "must exceed job_timeout")
+	if _, err := cron.Parse(c.JobPurgeSchedule); err != nil {
+		check(false, "job_purge_schedule", "%v", err)
+	}
+	check(c.SchedulerLease >= 3*time.Second, "scheduler_lease", "must be at least 3s")
+	check(c.SchedulerLease > c.RepositoryTimeout, "scheduler_lease", "must exceed repository_timeout")
+	if _, err := cron.Parse(c.SessionPurgeSchedule); err != nil {
+		check(false, "session_purge_schedule", "%v", err)
+	}
+	check(c.RetentionInactiveMonths >= 0, "retention_inactive_months", "must not be negative")
+	check(oneOf(c.RetentionAction, retentionActions), "retention_action", "must be one of %v", retentionActions)
+	check(c.RetentionBatchSize > 0, "retention_batch_size", "must be positive")
+	if _, err := cron.Parse(c.RetentionSchedule); err != nil {
+		check(false, "retention_schedule", "%v", err)
+	}
+	check(validPort(c.ServerPort), "server_port", "invalid port %q", c.ServerPort)
+	if c.MetricsPort != "" {
+		check(validPort(c.MetricsPort), "metrics_port", "invalid port %q", c.MetricsPort)
+		check(c.MetricsPort != c.ServerPort, "metrics_port", "must differ from server_port")
+	}
+
+	check(c.DBMaxOpenConns > 0, "db_max_open_conns", "must be positive")
+	check(c.DBMaxIdleConns >= 0, "db_max_idle_conns", "must not be negative")
+	check(c.DBMaxIdleConns <= c.DBMaxOpenConns, "db_max_idle_conns", "must not exceed db_max_open_conns")
+
+	for key, d := range map[string]time.Duration{
+		"db_conn_max_lifetime":  c.DBConnMaxLifetime,
+		"repository_timeout":    c.RepositoryTimeout,
+		"token_expiry":          c.TokenExpiryDur,
+		"shutdown_timeout":      c.ShutdownTimeout,
+		"outbox_poll_interval":  c.OutboxPollInterval,
+		"outbox_retry_backoff":  c.OutboxRetryBackoff,
+		"outbox_lease":          c.OutboxLease,
+		"outbox_retention":      c.OutboxRetention,
+		"webhook_poll_interval": c.WebhookPollInterval,
+		"webhook_retry_backoff": c.WebhookRetryBackoff,
+		"webhook_timeout":       c.WebhookTimeout,
+		"job_poll_interval":     c.JobPollInterval,
+		"job_retry_backoff":     c.JobRetryBackoff,
+		"job_timeout":           c.JobTimeout,
+		"job_retention":         c.JobRetention,
+		"retention_notice":      c.RetentionNotice,
+	} {
+		check(d > 0, key, "must be positive")
+	}
+	check(c.ShutdownDrainDelay >= 0, "shutdown_drain_delay", "must not be negative")
+	check(c.SecretsRefreshInterval >= 0, "secrets_refresh_interval", "must not be negative")
+
+	check(oneOf(c.GinMode, ginModes), "gin_mode", "must be one of %v", ginModes)
+	check(oneOf(c.LogLevel, logLevels), "log_level", "must be one of %v", logLevels)
+	check(oneOf(c.LogFormat, logFormats), "log_format", "must be one of %v", logFormats)
+	check(oneOf(c.TracingExporter, tracingExporters), "tracing_exporter", "must be one of %v", tracingExporters)
+	if c.TracingExporter == "otlp" {
+		check(c.TracingEndpoint != "", "tracing_endpoint", "must be set when tracing_exporter is otlp")
+	}
+
+	return errs
+}
+
+// validDatabaseURL checks that raw is a postgres connection URL with a host.
+func validDatabaseURL(key, raw string) []error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return []error{fmt.Errorf("%s: invalid URL", key)}
+	}
+	var errs []error
+	if !oneOf(u.Scheme, databaseURLSchemes) {
+		errs = append(errs, fmt.Errorf("%s: scheme must be one of %v", key, databaseURLSchemes))
+	}
+	if u.Host == "" {
+		errs = append(errs, fmt.Errorf("%s: must include a host", key))
+	}
+	return errs
+}
+
+// validPort reports whether port is a valid TCP port number.
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// oneOf reports whether value is in allowed.
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
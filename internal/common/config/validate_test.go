@@ -0,0 +1,305 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Config)
+		errContains []string
+	}{
+		{
+			name:   "valid defaults",
+			modify: func(*Config) {},
+		},
+		{
+			name: "idle connections exceed open connections",
+			modify: func(c *Config) {
+				c.DBMaxIdleConns = 100
+			},
+			errContains: []string{"db_max_idle_conns: must not exceed db_max_open_conns"},
+		},
+		{
+			name: "non-positive durations",
+			modify: func(c *Config) {
+				c.TokenExpiryDur = 0
+				c.RepositoryTimeout = -time.Second
+				c.ShutdownDrainDelay = -time.Second
+			},
+			errContains: []string{
+				"token_expiry: must be positive",
+				"repository_timeout: must be positive",
+				"shutdown_drain_delay: must not be negative",
+			},
+		},
+		{
+			name: "previous JWT secret equals the current one",
+			modify: func(c *Config) {
+				c.JWTPreviousSecret = c.JWTSecret
+			},
+			errContains: []string{"jwt_previous_secret: must differ from jwt_secret"},
+		},
+		{
+			name: "metrics port equals server port",
+			modify: func(c *Config) {
+				c.MetricsPort = c.ServerPort
+			},
+			errContains: []string{"metrics_port: must differ from server_port"},
+		},
+		{
+			name: "otlp without endpoint",
+			modify: func(c *Config) {
+				c.TracingExporter = "otlp"
+				c.TracingEndpoint = ""
+			},
+			errContains: []string{"tracing_endpoint: must be set when tracing_exporter is otlp"},
+		},
+		{
+			name: "invalid enumerations",
+			modify: func(c *Config) {
+				c.GinMode = "prod"
+				c.LogLevel = "trace"
+				c.TracingExporter = "zipkin"
+			},
+			errContains: []string{"gin_mode", "log_level", "tracing_exporter"},
+		},
+		{
+			name: "database url replaces individual values",
+			modify: func(c *Config) {
+				c.DatabaseURL = "postgres://user:pass@db:5432/app"
+				c.DBHost = ""
+				c.DBPort = ""
+			},
+		},
+		{
+			name: "invalid database url",
+			modify: func(c *Config) {
+				c.DatabaseURL = "mysql://user@db/app"
+			},
+			errContains: []string{"database_url: scheme must be one of [postgres postgresql]"},
+		},
+		{
+			name: "database url without host",
+			modify: func(c *Config) {
+				c.DatabaseURL = "postgres:///app"
+			},
+			errContains: []string{"database_url: must include a host"},
+		},
+		{
+			name: "invalid replica urls",
+			modify: func(c *Config) {
+				c.DBReplicaURLs = "postgres://u@replica/app,replica-2"
+				c.DBReplicaCheckInterval = 0
+			},
+			errContains: []string{
+				"db_replica_urls: scheme must be one of",
+				"db_replica_urls: must include a host",
+				"db_replica_check_interval: must be positive",
+			},
+		},
+		{
+			name: "invalid transaction options",
+			modify: func(c *Config) {
+				c.DBTxIsolation = "snapshot"
+				c.DBTxMaxRetries = -1
+			},
+			errContains: []string{
+				"db_tx_isolation: must be one of [read_committed repeatable_read serializable]",
+				"db_tx_max_retries: must not be negative",
+			},
+		},
+		{
+			name: "invalid tls options",
+			modify: func(c *Config) {
+				c.DBSSLMode = "on"
+				c.DBSSLCert = "client.pem"
+				c.DBSSLRootCert = "/does/not/exist.pem"
+			},
+			errContains: []string{
+				"db_sslmode: must be one of",
+				"db_sslcert: must be set together with db_sslkey",
+				`db_sslrootcert: cannot read "/does/not/exist.pem"`,
+			},
+		},
+		{
+			name: "invalid connection timeouts",
+			modify: func(c *Config) {
+				c.DBConnectTimeout = 500 * time.Millisecond
+				c.DBStatementTimeout = -time.Second
+			},
+			errContains: []string{
+				"db_connect_timeout: must be zero or at least 1s",
+				"db_statement_timeout: must not be negative",
+			},
+		},
+		{
+			name: "invalid mail settings",
+			modify: func(c *Config) {
+				c.SMTPHost = "smtp.example.com"
+				c.SMTPPort = "smtp"
+				c.MailFrom = "no-reply"
+			},
+			errContains: []string{
+				`smtp_port: invalid port "smtp"`,
+				"mail_from: must be an email address",
+			},
+		},
+		{
+			name: "invalid admin user IDs",
+			modify: func(c *Config) {
+				c.AdminUserIDs = "6f1c2d8e-3b5a-4c7d-9e0f-1a2b3c4d5e6f, admin@example.com"
+			},
+			errContains: []string{`admin_user_ids: invalid user ID "admin@example.com"`},
+		},
+		{
+			name: "invalid outbox settings",
+			modify: func(c *Config) {
+				c.OutboxBatchSize = 0
+				c.OutboxMaxAttempts = 0
+				c.OutboxRetryBackoff = time.Minute
+				c.OutboxMaxBackoff = time.Second
+				c.OutboxLease = 0
+				c.OutboxRetention = 0
+				c.OutboxPurgeSchedule = "hourly"
+			},
+			errContains: []string{
+				"outbox_batch_size: must be positive",
+				"outbox_max_attempts: must be positive",
+				"outbox_max_backoff: must not be less than outbox_retry_backoff",
+				"outbox_lease: must be positive",
+				"outbox_retention: must be positive",
+				"outbox_purge_schedule: expected 5 fields, got 1",
+			},
+		},
+		{
+			name: "invalid webhook settings",
+			modify: func(c *Config) {
+				c.WebhookBatchSize = 0
+				c.WebhookMaxAttempts = 0
+				c.WebhookRetryBackoff = time.Minute
+				c.WebhookMaxBackoff = time.Second
+				c.WebhookTimeout = time.Minute
+				c.WebhookLease = time.Minute
+			},
+			errContains: []string{
+				"webhook_batch_size: must be positive",
+				"webhook_max_attempts: must be positive",
+				"webhook_max_backoff: must not be less than webhook_retry_backoff",
+				"webhook_lease: must exceed webhook_timeout",
+			},
+		},
+		{
+			name: "invalid job settings",
+			modify: func(c *Config) {
+				c.JobConcurrency = 0
+				c.JobMaxAttempts = 0
+				c.JobRetryBackoff = time.Minute
+				c.JobMaxBackoff = time.Second
+				c.JobTimeout = 0
+				c.JobLease = 0
+				c.JobRetention = 0
+				c.JobPurgeSchedule = "weekly"
+			},
+			errContains: []string{
+				"job_concurrency: must be positive",
+				"job_max_attempts: must be positive",
+				"job_max_backoff: must not be less than job_retry_backoff",
+				"job_timeout: must be positive",
+				"job_lease: must exceed job_timeout",
+				"job_retention: must be positive",
+				"job_purge_schedule: expected 5 fields, got 1",
+			},
+		},
+		{
+			name: "invalid scheduler settings",
+			modify: func(c *Config) {
+				c.SchedulerLease = time.Second
+				c.SessionPurgeSchedule = "every hour"
+			},
+			errContains: []string{
+				"scheduler_lease: must be at least 3s",
+				"session_purge_schedule: expected 5 fields, got 2",
+			},
+		},
+		{
+			name: "scheduler lease shorter than repository timeout",
+			modify: func(c *Config) {
+				c.SchedulerLease = 5 * time.Second
+				c.RepositoryTimeout = 5 * time.Second
+			},
+			errContains: []string{"scheduler_lease: must exceed repository_timeout"},
+		},
+		{
+			name: "invalid retention settings",
+			modify: func(c *Config) {
+				c.RetentionInactiveMonths = -1
+				c.RetentionNotice = 0
+				c.RetentionAction = "archive"
+				c.RetentionBatchSize = 0
+				c.RetentionSchedule = "daily"
+			},
+			errContains: []string{
+				"retention_inactive_months: must not be negative",
+				"retention_notice: must be positive",
+				"retention_action: must be one of [disable delete]",
+				"retention_batch_size: must be positive",
+				"retention_schedule: expected 5 fields, got 1",
+			},
+		},
+		{
+			name: "invalid password policy",
+			modify: func(c *Config) {
+				c.PasswordMinLength = 120
+				c.PasswordMaxBytes = 100
+				c.PasswordMinEntropy = -1
+				c.PasswordHistorySize = -1
+				c.PasswordBreachedList = "/does/not/exist.txt"
+			},
+			errContains: []string{
+				"password_max_bytes: must be between 1 and 72",
+				"password_min_length: must not exceed password_max_bytes",
+				"password_min_entropy: must not be negative",
+				"password_history_size: must not be negative",
+				`password_breached_list: cannot read "/does/not/exist.txt"`,
+			},
+		},
+		{
+			name: "invalid password hashing",
+			modify: func(c *Config) {
+				c.PasswordHashAlgorithm = "md5"
+				c.PasswordBcryptCost = 40
+				c.PasswordArgon2Memory = 4
+				c.PasswordArgon2Iterations = 0
+				c.PasswordArgon2Parallelism = 256
+			},
+			errContains: []string{
+				"password_hash_algorithm: must be one of [bcrypt argon2id]",
+				"password_bcrypt_cost: must be between 4 and 31",
+				"password_argon2_memory: must be at least 8 KiB per unit of parallelism",
+				"password_argon2_iterations: must be positive",
+				"password_argon2_parallelism: must be between 1 and 255",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfig("test-secret")
+			tt.modify(c)
+
+			err := c.Validate()
+			if len(tt.errContains) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, want := range tt.errContains {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Watch polls the secret files read by the last Load, and the configuration
+// file when config_watch is set, every interval and calls Reload when their
+// contents change. It blocks until ctx is done.
+func (l *Loader) Watch(ctx context.Context, store *Store, interval time.Duration, log *slog.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if l.changed() {
+			l.Reload(store, log)
+		}
+	}
+}
+
+// Reload loads the configuration again and applies it to store, logging every
+// changed value. A configuration that fails validation or changes a value that
+// is not reloadable is logged and discarded, leaving store untouched.
+func (l *Loader) Reload(store *Store, log *slog.Logger) {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	next, err := l.Load()
+	if err != nil {
+		log.Error("failed to reload configuration", slog.Any("error", err))
+		return
+	}
+
+	changes, err := store.Apply(next)
+	if err != nil {
+		log.Error("configuration change rejected", slog.Any("error", err))
+		return
+	}
+	if len(changes) == 0 {
+		log.Info("configuration reloaded without changes")
+		return
+	}
+	for _, c := range changes {
+		log.Info("configuration reloaded", slog.String("key", c.Key), slog.String("change", c.String()))
+	}
+}
+
+// changed reports whether any file watched by the last Load has been
+// created, removed or modified since.
+func (l *Loader) changed() bool {
+	l.mu.Lock()
+	paths, last := l.watched, l.sum
+	l.mu.Unlock()
+
+	h := sha256.New()
+	for _, path := range paths {
+		value, err := readSecretFile(path)
+		hashSecret(h, path, value, err)
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum != last
+}
+
+// hashSecret adds the outcome of reading a watched file to h.
+func hashSecret(h hash.Hash, path, value string, err error) {
+	io.WriteString(h, path)
+	if err != nil {
+		h.Write([]byte{0})
+		return
+	}
+	h.Write([]byte{1})
+	io.WriteString(h, value)
+	h.Write([]byte{0})
+}
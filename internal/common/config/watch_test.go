@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_Watch(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "jwt_secret")
+	require.NoError(t, os.WriteFile(secretPath, []byte("initial"), 0o600))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := NewLoader(fs)
+	require.NoError(t, fs.Parse([]string{"-secrets-dir", dir}))
+
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	store := NewStore(cfg)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		loader.Watch(ctx, store, 10*time.Millisecond, log)
+		close(done)
+	}()
+
+	require.NoError(t, os.WriteFile(secretPath, []byte("rotated"), 0o600))
+	assert.Eventually(t, func() bool {
+		return store.Get().JWTSecret == "rotated"
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Contains(t, buf.String(), "configuration reloaded")
+	assert.NotContains(t, buf.String(), "rotated")
+}
+
+func TestLoader_Reload_RestartRequired(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	os.Setenv("JWT_SECRET", "secret")
+	portPath := filepath.Join(dir, "server_port")
+	require.NoError(t, os.WriteFile(portPath, []byte("8080"), 0o600))
+	os.Setenv("SECRETS_DIR", dir)
+
+	loader := NewLoader(flag.NewFlagSet("test", flag.ContinueOnError))
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	store := NewStore(cfg)
+
+	var buf bytes.Buffer
+	require.NoError(t, os.WriteFile(portPath, []byte("9000"), 0o600))
+	loader.Reload(store, slog.New(slog.NewTextHandler(&buf, nil)))
+
+	assert.Same(t, cfg, store.Get())
+	assert.Contains(t, buf.String(), "restart required to change server_port")
+}
+
+func TestLoader_Watch_ConfigFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("JWT_SECRET", "secret")
+	path := writeFile(t, "config.yaml", "config_watch: true\nlog_level: info\n")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := NewLoader(fs)
+	require.NoError(t, fs.Parse([]string{"-config", path}))
+
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	store := NewStore(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loader.Watch(ctx, store, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, os.WriteFile(path, []byte("config_watch: true\nlog_level: debug\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		return store.Get().LogLevel == "debug"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLoader_Reload(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantLog  string
+		wantSame bool
+	}{
+		{
+			name:    "reloadable values",
+			content: "log_level: warn\ntoken_expiry: 1h\n",
+			wantLog: "log_level: info -> warn",
+		},
+		{
+			name:     "no changes",
+			content:  "log_level: info\n",
+			wantLog:  "configuration reloaded without changes",
+			wantSame: true,
+		},
+		{
+			name:     "invalid value",
+			content:  "log_level: loud\n",
+			wantLog:  "log_level: must be one of",
+			wantSame: true,
+		},
+		{
+			name:     "restart required",
+			content:  "db_host: elsewhere\nlog_level: warn\n",
+			wantLog:  "restart required to change db_host",
+			wantSame: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("JWT_SECRET", "secret")
+			path := writeFile(t, "config.yaml", "log_level: info\n")
+			os.Setenv("CONFIG_FILE", path)
+
+			loader := NewLoader(flag.NewFlagSet("test", flag.ContinueOnError))
+			cfg, err := loader.Load()
+			require.NoError(t, err)
+			store := NewStore(cfg)
+
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			var buf bytes.Buffer
+			loader.Reload(store, slog.New(slog.NewTextHandler(&buf, nil)))
+
+			assert.Contains(t, buf.String(), tt.wantLog)
+			if tt.wantSame {
+				assert.Same(t, cfg, store.Get())
+			} else {
+				assert.NotSame(t, cfg, store.Get())
+			}
+		})
+	}
+}
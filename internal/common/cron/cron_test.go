@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		errContains string
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "lists, ranges and steps", expr: "0,30 9-17 */2 1-6/2 1-5"},
+		{name: "sunday as 7", expr: "0 0 * * 7"},
+		{name: "descriptor", expr: "@daily"},
+		{name: "interval", expr: "@every 90s"},
+		{name: "too few fields", expr: "* * * *", errContains: "expected 5 fields, got 4"},
+		{name: "value out of range", expr: "60 * * * *", errContains: "minute: invalid value \"60\""},
+		{name: "reversed range", expr: "* 5-1 * * *", errContains: "hour: invalid range \"5-1\""},
+		{name: "invalid step", expr: "*/0 * * * *", errContains: "minute: invalid step \"0\""},
+		{name: "day of month zero", expr: "* * 0 * *", errContains: "day of month: invalid value"},
+		{name: "short interval", expr: "@every 10ms", errContains: "at least 1s"},
+		{name: "unknown descriptor", expr: "@often", errContains: "expected 5 fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				assert.Nil(t, s)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, s)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// A Thursday.
+	from := time.Date(2026, 1, 1, 12, 34, 56, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", want: time.Date(2026, 1, 1, 12, 35, 0, 0, time.UTC)},
+		{name: "hourly", expr: "@hourly", want: time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{name: "daily at 3:15", expr: "15 3 * * *", want: time.Date(2026, 1, 2, 3, 15, 0, 0, time.UTC)},
+		{name: "every 15 minutes", expr: "*/15 * * * *", want: time.Date(2026, 1, 1, 12, 45, 0, 0, time.UTC)},
+		{name: "weekdays", expr: "0 9 * * 1-5", from: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC), want: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", want: time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{name: "monthly", expr: "@monthly", want: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or day of week", expr: "0 0 15 * 6", want: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", expr: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "never", expr: "0 0 30 2 *", want: time.Time{}},
+		{name: "interval", expr: "@every 1m30s", want: from.Add(90 * time.Second)},
+		{
+			name: "other time zone matched in UTC",
+			expr: "0 3 * * *",
+			from: time.Date(2026, 1, 1, 4, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60)),
+			want: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			assert.NoError(t, err)
+
+			start := tt.from
+			if start.IsZero() {
+				start = from
+			}
+			assert.True(t, tt.want.Equal(s.Next(start)), "got %v, want %v", s.Next(start), tt.want)
+		})
+	}
+}
@@ -2,8 +2,8 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
-	"time"
 
 	"github.com/PakornBank/go-backend-example/internal/common/config"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
@@ -11,27 +11,114 @@ import (
 	"gorm.io/gorm"
 )
 
-// NewDataBase initializes a new database connection using the provided configuration.
+// Models lists the models whose tables are created by NewDataBase.
+var Models = []interface{}{
+	&model.User{},
+	&model.PasswordHistory{},
+	&model.Session{},
+	&model.LoginAttempt{},
+	&model.AuditEvent{},
+	&model.OutboxEvent{},
+	&model.WebhookSubscription{},
+	&model.WebhookDelivery{},
+	&model.WebhookAttempt{},
+	&model.Job{},
+	&model.SchedulerLease{},
+}
+
+// droppedIndexes lists the indexes of earlier versions of the schema that
+// have since been replaced.
+var droppedIndexes = []string{
+	model.LegacyEmailIndex,
+}
+
+// NewDataBase initializes a new database connection using the provided configuration
+// and migrates the schema.
 func NewDataBase(config *config.Config) (*gorm.DB, error) {
+	db, err := Open(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(Models...); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := dropIndexes(db, droppedIndexes...); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return db, nil
+}
+
+// dropIndexes drops the indexes with the given names, when they exist. It
+// runs after AutoMigrate, so that the indexes replacing them exist first.
+func dropIndexes(db *gorm.DB, names ...string) error {
+	for _, name := range names {
+		if err := db.Exec(`DROP INDEX IF EXISTS "` + name + `"`).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Open initializes a new database connection using the provided configuration
+// without touching the schema, for tools that must run before migrations.
+func Open(config *config.Config) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(config.DBURL()), &gorm.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := db.Use(NewRequestIDPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register request ID plugin: %w", err)
+	}
+
+	if err := db.Use(NewTracingPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
 	// Configure connection pooling
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database SQL DB: %w", err)
 	}
+	configurePool(sqlDB, config)
 
-	// Set connection pool parameters
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(50)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	if err := db.AutoMigrate(&model.User{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	// Route reads to replicas when configured
+	var replicas []*Replica
+	for i, dsn := range config.ReplicaDBURLs() {
+		replica, err := openReplica(fmt.Sprintf("replica-%d", i), dsn, config)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+	if len(replicas) > 0 {
+		if err := db.Use(NewReplicasPlugin(config.DBReplicaCheckInterval, replicas...)); err != nil {
+			return nil, fmt.Errorf("failed to register replicas plugin: %w", err)
+		}
 	}
 
 	return db, nil
 }
+
+// openReplica opens the connection pool of a read replica.
+func openReplica(name, dsn string, config *config.Config) (*Replica, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s SQL DB: %w", name, err)
+	}
+	configurePool(sqlDB, config)
+	return &Replica{Name: name, DB: sqlDB}, nil
+}
+
+// configurePool applies the connection pool parameters to sqlDB.
+func configurePool(sqlDB *sql.DB, config *config.Config) {
+	sqlDB.SetMaxIdleConns(config.DBMaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.DBMaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.DBConnMaxLifetime)
+}
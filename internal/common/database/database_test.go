@@ -0,0 +1,21 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropIndexes(t *testing.T) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	sqlMock.ExpectExec(`DROP INDEX IF EXISTS "idx_users_email"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(`DROP INDEX IF EXISTS "idx_old"`).WillReturnError(sql.ErrConnDone)
+
+	err := dropIndexes(gormDB, "idx_users_email", "idx_old", "idx_never_reached")
+
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err is a unique constraint violation,
+// optionally restricted to the constraints or indexes with the given names.
+func IsUniqueViolation(err error, constraints ...string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return false
+	}
+	return len(constraints) == 0 || slices.Contains(constraints, pgErr.ConstraintName)
+}
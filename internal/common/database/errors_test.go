@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	violation := &pgconn.PgError{Code: uniqueViolation, ConstraintName: "idx_users_email_lower"}
+
+	tests := []struct {
+		name        string
+		err         error
+		constraints []string
+		want        bool
+	}{
+		{name: "any constraint", err: violation, want: true},
+		{name: "matching constraint", err: violation, constraints: []string{"idx_users_email_lower"}, want: true},
+		{name: "one of the constraints", err: violation, constraints: []string{"idx_users_email", "idx_users_email_lower"}, want: true},
+		{name: "other constraint", err: violation, constraints: []string{"users_pkey"}, want: false},
+		{name: "wrapped", err: fmt.Errorf("insert: %w", violation), want: true},
+		{name: "other postgres error", err: &pgconn.PgError{Code: serializationFailure}, want: false},
+		{name: "other error", err: errors.New("boom"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUniqueViolation(tt.err, tt.constraints...))
+		})
+	}
+}
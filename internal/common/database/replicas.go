@@ -0,0 +1,236 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// replicasPluginName is the name under which the replica plugin is registered.
+const replicasPluginName = "replicas"
+
+// Replica is a read replica connection pool.
+type Replica struct {
+	Name    string
+	DB      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicasPlugin is a gorm.Plugin that sends read queries to healthy read
+// replicas in turn. Statements inside transactions, locking reads and reads
+// within a session that has already written go to the primary, and when no
+// replica is healthy every query falls back to the primary.
+type replicasPlugin struct {
+	replicas []*Replica
+	interval time.Duration
+	next     atomic.Uint64
+	primary  gorm.ConnPool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewReplicasPlugin creates a new gorm.Plugin that routes reads to the given
+// replicas, pinging each of them every interval to decide whether it is healthy.
+func NewReplicasPlugin(interval time.Duration, replicas ...*Replica) gorm.Plugin {
+	return &replicasPlugin{
+		replicas: replicas,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Name returns the name of the plugin.
+func (p *replicasPlugin) Name() string {
+	return replicasPluginName
+}
+
+// Initialize checks the replicas once, starts the periodic health checks and
+// registers the callbacks that route statements.
+func (p *replicasPlugin) Initialize(db *gorm.DB) error {
+	p.primary = db.ConnPool
+
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("replicas:query", p.route); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("replicas:row", p.route); err != nil {
+		return err
+	}
+	if err := cb.Create().Before("gorm:create").Register("replicas:create", markWrite); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("replicas:update", markWrite); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("replicas:delete", markWrite); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("replicas:raw", markWrite); err != nil {
+		return err
+	}
+
+	p.checkAll()
+	if len(p.replicas) > 0 && p.interval > 0 {
+		p.wg.Add(1)
+		go p.run()
+	}
+	return nil
+}
+
+// route points a read statement at a healthy replica when it is safe to do so.
+func (p *replicasPlugin) route(db *gorm.DB) {
+	if db.Error != nil || db.Statement.ConnPool != p.primary {
+		return
+	}
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return
+	}
+	if s := sessionFromContext(db.Statement.Context); s != nil && s.wrote.Load() {
+		return
+	}
+
+	if r := p.pick(); r != nil {
+		db.Statement.ConnPool = r.DB
+	}
+}
+
+// pick returns the next healthy replica in round-robin order, or nil.
+func (p *replicasPlugin) pick() *Replica {
+	n := len(p.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := p.next.Add(1)
+	for i := 0; i < n; i++ {
+		r := p.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// run pings the replicas every interval until the plugin is closed.
+func (p *replicasPlugin) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkAll()
+		}
+	}
+}
+
+// checkAll pings every replica and records whether it is healthy.
+func (p *replicasPlugin) checkAll() {
+	for _, r := range p.replicas {
+		timeout := p.interval
+		if timeout <= 0 || timeout > 2*time.Second {
+			timeout = 2 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := r.DB.PingContext(ctx)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			slog.Info("read replica healthy", slog.String("replica", r.Name))
+		} else {
+			slog.Warn("read replica unhealthy, reads fall back to the primary",
+				slog.String("replica", r.Name), slog.Any("error", err))
+		}
+	}
+}
+
+// close stops the health checks and closes the replica connection pools.
+func (p *replicasPlugin) close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	p.wg.Wait()
+
+	var firstErr error
+	for _, r := range p.replicas {
+		if err := r.DB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Replicas returns the read replicas registered on db, if any.
+func Replicas(db *gorm.DB) []*Replica {
+	if p, ok := db.Config.Plugins[replicasPluginName].(*replicasPlugin); ok {
+		return p.replicas
+	}
+	return nil
+}
+
+// Close stops the replica health checks and closes every connection pool of db.
+func Close(db *gorm.DB) error {
+	if p, ok := db.Config.Plugins[replicasPluginName].(*replicasPlugin); ok {
+		if err := p.close(); err != nil {
+			return err
+		}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// sessionKey is the context key type for the read-your-writes session.
+type sessionKey struct{}
+
+// session records whether a request has written to the primary.
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession returns a copy of ctx that tracks writes, so that once a
+// statement has written to the primary every later read using the context
+// is sent to the primary as well and observes that write.
+func WithSession(ctx context.Context) context.Context {
+	if sessionFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// UsePrimary returns a copy of ctx whose reads always go to the primary.
+func UsePrimary(ctx context.Context) context.Context {
+	s := &session{}
+	s.wrote.Store(true)
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// sessionFromContext returns the session stored in ctx, or nil.
+func sessionFromContext(ctx context.Context) *session {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s
+}
+
+// markWrite records that the session of the statement has written to the primary.
+func markWrite(db *gorm.DB) {
+	if s := sessionFromContext(db.Statement.Context); s != nil {
+		s.wrote.Store(true)
+	}
+}
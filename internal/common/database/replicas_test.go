@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func setupReplicasTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, sqlmock.Sqlmock, *Replica) {
+	_, gormDB, primaryMock := testutil.DBMock(t)
+
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	replicaMock.ExpectPing()
+
+	replica := &Replica{Name: "replica-0", DB: replicaDB}
+	require.NoError(t, gormDB.Use(NewReplicasPlugin(0, replica)))
+	require.True(t, replica.healthy.Load())
+	return gormDB, primaryMock, replicaMock, replica
+}
+
+func TestReplicasPlugin(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	userRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email"}).AddRow(mockUser.ID, mockUser.Email)
+	}
+	insertUser := func(sqlMock sqlmock.Sqlmock) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`INSERT INTO "users"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+				AddRow(mockUser.ID, mockUser.CreatedAt, mockUser.UpdatedAt))
+		sqlMock.ExpectCommit()
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		unhealthy bool
+		primaryFn func(sqlmock.Sqlmock)
+		replicaFn func(sqlmock.Sqlmock)
+		execFn    func(context.Context, *gorm.DB) error
+	}{
+		{
+			name: "read goes to replica",
+			ctx:  context.Background(),
+			replicaFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows())
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				var user model.User
+				return db.WithContext(ctx).Where("id = ?", mockUser.ID).First(&user).Error
+			},
+		},
+		{
+			name:      "read falls back to primary when replica is unhealthy",
+			ctx:       context.Background(),
+			unhealthy: true,
+			primaryFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows())
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				var user model.User
+				return db.WithContext(ctx).First(&user).Error
+			},
+		},
+		{
+			name:      "write goes to primary",
+			ctx:       context.Background(),
+			primaryFn: insertUser,
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				return db.WithContext(ctx).Create(&model.User{Email: mockUser.Email, PasswordHash: "hash", FullName: "name"}).Error
+			},
+		},
+		{
+			name: "read after write in a session goes to primary",
+			ctx:  WithSession(context.Background()),
+			primaryFn: func(sqlMock sqlmock.Sqlmock) {
+				insertUser(sqlMock)
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows())
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				if err := db.WithContext(ctx).Create(&model.User{Email: mockUser.Email, PasswordHash: "hash", FullName: "name"}).Error; err != nil {
+					return err
+				}
+				var user model.User
+				return db.WithContext(ctx).First(&user).Error
+			},
+		},
+		{
+			name: "read without a prior write in a session goes to replica",
+			ctx:  WithSession(context.Background()),
+			replicaFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows())
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				var user model.User
+				return db.WithContext(ctx).First(&user).Error
+			},
+		},
+		{
+			name: "primary forced by context",
+			ctx:  UsePrimary(context.Background()),
+			primaryFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows())
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				var user model.User
+				return db.WithContext(ctx).First(&user).Error
+			},
+		},
+		{
+			name: "locking read goes to primary",
+			ctx:  context.Background(),
+			primaryFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "users" .* FOR UPDATE`).WillReturnRows(userRows())
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				var user model.User
+				return db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&user).Error
+			},
+		},
+		{
+			name: "read inside transaction goes to primary",
+			ctx:  context.Background(),
+			primaryFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows())
+				sqlMock.ExpectCommit()
+			},
+			execFn: func(ctx context.Context, db *gorm.DB) error {
+				return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+					var user model.User
+					return tx.First(&user).Error
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, primaryMock, replicaMock, replica := setupReplicasTest(t)
+			if tt.unhealthy {
+				replica.healthy.Store(false)
+			}
+			if tt.primaryFn != nil {
+				tt.primaryFn(primaryMock)
+			}
+			if tt.replicaFn != nil {
+				tt.replicaFn(replicaMock)
+			}
+
+			require.NoError(t, tt.execFn(tt.ctx, gormDB))
+			assert.NoError(t, primaryMock.ExpectationsWereMet())
+			assert.NoError(t, replicaMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReplicasPlugin_HealthCheck(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicaMock.ExpectPing()
+	replicaMock.ExpectClose()
+
+	replica := &Replica{Name: "replica-0", DB: replicaDB}
+	require.NoError(t, gormDB.Use(NewReplicasPlugin(10*time.Millisecond, replica)))
+	assert.False(t, replica.healthy.Load())
+
+	assert.Eventually(t, replica.healthy.Load, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []*Replica{replica}, Replicas(gormDB))
+
+	plugin := gormDB.Config.Plugins[replicasPluginName].(*replicasPlugin)
+	require.NoError(t, plugin.close())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicas_NotConfigured(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	assert.Nil(t, Replicas(gormDB))
+}
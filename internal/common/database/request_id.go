@@ -0,0 +1,81 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// requestIDPlugin is a gorm.Plugin that prefixes every SQL statement with a
+// comment carrying the request ID found in the statement context, so that
+// slow query logs and pg_stat_activity can be correlated with API requests.
+type requestIDPlugin struct{}
+
+// NewRequestIDPlugin creates a new gorm.Plugin that tags queries with the request ID.
+func NewRequestIDPlugin() gorm.Plugin {
+	return &requestIDPlugin{}
+}
+
+// Name returns the name of the plugin.
+func (p *requestIDPlugin) Name() string {
+	return "request_id"
+}
+
+// Initialize registers the callbacks that add the SQL comment.
+func (p *requestIDPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("request_id:create", tagClause("INSERT")); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("request_id:query", tagClause("SELECT")); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("request_id:update", tagClause("UPDATE")); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("request_id:delete", tagClause("DELETE")); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("request_id:row", tagClause("SELECT")); err != nil {
+		return err
+	}
+	return cb.Raw().Before("gorm:raw").Register("request_id:raw", tagRaw)
+}
+
+// comment returns the SQL comment for the request ID in the statement context.
+func comment(db *gorm.DB) string {
+	id := requestid.FromContext(db.Statement.Context)
+	if id == "" {
+		return ""
+	}
+	return "/* request_id=" + strings.ReplaceAll(id, "*/", "") + " */"
+}
+
+// tagClause returns a callback that places the comment before the named clause.
+func tagClause(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		c := comment(db)
+		if c == "" || db.Statement.SQL.Len() > 0 {
+			return
+		}
+
+		cl := db.Statement.Clauses[name]
+		cl.Name = name
+		cl.BeforeExpression = clause.Expr{SQL: c}
+		db.Statement.Clauses[name] = cl
+	}
+}
+
+// tagRaw prefixes a raw SQL statement with the comment.
+func tagRaw(db *gorm.DB) {
+	c := comment(db)
+	if c == "" || db.Statement.SQL.Len() == 0 {
+		return
+	}
+
+	sql := db.Statement.SQL.String()
+	db.Statement.SQL.Reset()
+	db.Statement.SQL.WriteString(c + " " + sql)
+}
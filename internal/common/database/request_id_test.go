@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRequestIDTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	require.NoError(t, gormDB.Use(NewRequestIDPlugin()))
+	return gormDB, sqlMock
+}
+
+func TestRequestIDPlugin(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		mockFn func(sqlmock.Sqlmock)
+		execFn func(*gorm.DB) error
+	}{
+		{
+			name: "query tagged with request ID",
+			ctx:  requestid.WithContext(context.Background(), "req-123"),
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`^/\* request_id=req-123 \*/ SELECT .* FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUser.ID))
+			},
+			execFn: func(db *gorm.DB) error {
+				var user model.User
+				return db.First(&user).Error
+			},
+		},
+		{
+			name: "insert tagged with request ID",
+			ctx:  requestid.WithContext(context.Background(), "req-123"),
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`^/\* request_id=req-123 \*/ INSERT INTO "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+						AddRow(mockUser.ID, mockUser.CreatedAt, mockUser.UpdatedAt))
+				sqlMock.ExpectCommit()
+			},
+			execFn: func(db *gorm.DB) error {
+				return db.Create(&model.User{Email: mockUser.Email, PasswordHash: "hash", FullName: "name"}).Error
+			},
+		},
+		{
+			name: "raw statement tagged with request ID",
+			ctx:  requestid.WithContext(context.Background(), "req-123"),
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectExec(`^/\* request_id=req-123 \*/ DELETE FROM users`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			execFn: func(db *gorm.DB) error {
+				return db.Exec("DELETE FROM users").Error
+			},
+		},
+		{
+			name: "no request ID leaves query untouched",
+			ctx:  context.Background(),
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`^SELECT .* FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUser.ID))
+			},
+			execFn: func(db *gorm.DB) error {
+				var user model.User
+				return db.First(&user).Error
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, sqlMock := setupRequestIDTest(t)
+			tt.mockFn(sqlMock)
+
+			err := tt.execFn(gormDB.WithContext(tt.ctx))
+
+			assert.NoError(t, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+)
+
+func TestTracingPlugin(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name       string
+		mockFn     func(sqlmock.Sqlmock)
+		execFn     func(*gorm.DB) error
+		wantName   string
+		wantStatus codes.Code
+	}{
+		{
+			name: "query span",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT .* FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUser.ID))
+			},
+			execFn: func(db *gorm.DB) error {
+				var user model.User
+				return db.First(&user).Error
+			},
+			wantName:   "gorm.SELECT",
+			wantStatus: codes.Unset,
+		},
+		{
+			name: "record not found is not an error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT .* FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			execFn: func(db *gorm.DB) error {
+				var user model.User
+				if err := db.First(&user).Error; err != gorm.ErrRecordNotFound {
+					return err
+				}
+				return nil
+			},
+			wantName:   "gorm.SELECT",
+			wantStatus: codes.Unset,
+		},
+		{
+			name: "failed raw statement",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectExec(`DELETE FROM users`).WillReturnError(sql.ErrConnDone)
+			},
+			execFn: func(db *gorm.DB) error {
+				if err := db.Exec("DELETE FROM users").Error; err != sql.ErrConnDone {
+					return err
+				}
+				return nil
+			},
+			wantName:   "gorm.RAW",
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.SpanRecorder(t)
+			_, gormDB, sqlMock := testutil.DBMock(t)
+			require.NoError(t, gormDB.Use(NewTracingPlugin()))
+			tt.mockFn(sqlMock)
+
+			ctx, parent := tracing.Start(context.Background(), "parent")
+			err := tt.execFn(gormDB.WithContext(ctx))
+			parent.End()
+
+			require.NoError(t, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 2)
+			span := spans[0]
+			assert.Equal(t, tt.wantName, span.Name())
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Equal(t, tt.wantStatus, span.Status().Code)
+
+			attrs := attribute.NewSet(span.Attributes()...)
+			statement, ok := attrs.Value("db.query.text")
+			assert.True(t, ok)
+			assert.NotEmpty(t, statement.AsString())
+		})
+	}
+}
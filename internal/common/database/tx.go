@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes of transactions that may succeed when retried.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// isolationLevels maps configuration values to transaction isolation levels.
+var isolationLevels = map[string]sql.IsolationLevel{
+	"read_committed":  sql.LevelReadCommitted,
+	"repeatable_read": sql.LevelRepeatableRead,
+	"serializable":    sql.LevelSerializable,
+}
+
+//go:generate mockgen -destination=./tx_mock.go -package=database github.com/PakornBank/go-backend-example/internal/common/database TxManager
+
+// TxManager defines the methods that a transaction manager must implement.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// TxOptions holds the settings of a transaction manager.
+type TxOptions struct {
+	// Isolation is read_committed, repeatable_read or serializable. Empty uses the server default.
+	Isolation string
+	// MaxRetries is how many times a transaction failing with a serialization
+	// failure or deadlock is retried.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on every further retry.
+	RetryBackoff time.Duration
+}
+
+// txManager runs functions in database transactions carried by the context.
+type txManager struct {
+	db   *gorm.DB
+	opts TxOptions
+}
+
+// NewTxManager creates a new instance of txManager with the provided gorm.DB connection and options.
+func NewTxManager(db *gorm.DB, opts TxOptions) TxManager {
+	return &txManager{db: db, opts: opts}
+}
+
+// txKey is the context key type for the active transaction.
+type txKey struct{}
+
+// WithinTransaction runs fn in a transaction. The context passed to fn
+// carries the transaction, so repositories using Conn join it. The
+// transaction commits when fn returns nil and rolls back otherwise. When ctx
+// already carries a transaction fn joins it, and the outermost call decides
+// the outcome. Transactions failing with a serialization failure or deadlock
+// are retried, so fn must be safe to run more than once.
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	opts := &sql.TxOptions{}
+	if level, ok := isolationLevels[m.opts.Isolation]; ok {
+		opts.Isolation = level
+	}
+
+	backoff := m.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		}, opts)
+		if err == nil || !retryable(err) || attempt >= m.opts.MaxRetries {
+			return err
+		}
+
+		logger.FromContext(ctx).Warn("retrying transaction",
+			slog.Int("attempt", attempt+1), slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db
+}
+
+// retryable reports whether err is a serialization failure or deadlock.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTxManager_WithinTransaction(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	serializationErr := &pgconn.PgError{Code: serializationFailure, Message: "could not serialize access"}
+
+	insert := func(ctx context.Context, db *gorm.DB) error {
+		return Conn(ctx, db).WithContext(ctx).
+			Create(&model.User{Email: mockUser.Email, PasswordHash: "hash", FullName: "name"}).Error
+	}
+	expectInsert := func(sqlMock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+		return sqlMock.ExpectQuery(`INSERT INTO "users"`)
+	}
+	insertRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+			AddRow(mockUser.ID, mockUser.CreatedAt, mockUser.UpdatedAt)
+	}
+
+	tests := []struct {
+		name      string
+		opts      TxOptions
+		mockFn    func(sqlmock.Sqlmock)
+		fn        func(*gorm.DB, TxManager, *int) func(context.Context) error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name: "commits on success",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				expectInsert(sqlMock).WillReturnRows(insertRows())
+				sqlMock.ExpectCommit()
+			},
+			fn: func(db *gorm.DB, _ TxManager, calls *int) func(context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					var users []model.User
+					if err := Conn(ctx, db).WithContext(ctx).Find(&users).Error; err != nil {
+						return err
+					}
+					return insert(ctx, db)
+				}
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "rolls back on error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				expectInsert(sqlMock).WillReturnRows(insertRows())
+				sqlMock.ExpectRollback()
+			},
+			fn: func(db *gorm.DB, _ TxManager, calls *int) func(context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					if err := insert(ctx, db); err != nil {
+						return err
+					}
+					return errors.New("later step failed")
+				}
+			},
+			wantErr:   errors.New("later step failed"),
+			wantCalls: 1,
+		},
+		{
+			name: "nested call joins the outer transaction",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				expectInsert(sqlMock).WillReturnRows(insertRows())
+				sqlMock.ExpectCommit()
+			},
+			fn: func(db *gorm.DB, m TxManager, calls *int) func(context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					return m.WithinTransaction(ctx, func(ctx context.Context) error {
+						return insert(ctx, db)
+					})
+				}
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "retries serialization failures",
+			opts: TxOptions{MaxRetries: 2},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				expectInsert(sqlMock).WillReturnError(serializationErr)
+				sqlMock.ExpectRollback()
+				sqlMock.ExpectBegin()
+				expectInsert(sqlMock).WillReturnRows(insertRows())
+				sqlMock.ExpectCommit()
+			},
+			fn: func(db *gorm.DB, _ TxManager, calls *int) func(context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					return insert(ctx, db)
+				}
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "gives up after max retries",
+			opts: TxOptions{MaxRetries: 1},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				for i := 0; i < 2; i++ {
+					sqlMock.ExpectBegin()
+					expectInsert(sqlMock).WillReturnError(serializationErr)
+					sqlMock.ExpectRollback()
+				}
+			},
+			fn: func(db *gorm.DB, _ TxManager, calls *int) func(context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					return insert(ctx, db)
+				}
+			},
+			wantErr:   serializationErr,
+			wantCalls: 2,
+		},
+		{
+			name: "does not retry other errors",
+			opts: TxOptions{MaxRetries: 3},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				expectInsert(sqlMock).WillReturnError(&pgconn.PgError{Code: "23505"})
+				sqlMock.ExpectRollback()
+			},
+			fn: func(db *gorm.DB, _ TxManager, calls *int) func(context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					return insert(ctx, db)
+				}
+			},
+			wantErr:   &pgconn.PgError{Code: "23505"},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gormDB, sqlMock := testutil.DBMock(t)
+			tt.mockFn(sqlMock)
+			m := NewTxManager(gormDB, tt.opts)
+
+			calls := 0
+			err := m.WithinTransaction(context.Background(), tt.fn(gormDB, m, &calls))
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr.Error(), err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestConn(t *testing.T) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	assert.Same(t, gormDB, Conn(context.Background(), gormDB))
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	err := NewTxManager(gormDB, TxOptions{}).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		assert.NotSame(t, gormDB, Conn(ctx, gormDB))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestTxManager_Isolation(t *testing.T) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+
+	m := NewTxManager(gormDB, TxOptions{Isolation: "serializable"})
+	require.NoError(t, m.WithinTransaction(context.Background(), func(context.Context) error { return nil }))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
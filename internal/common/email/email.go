@@ -0,0 +1,56 @@
+// Package email provides canonicalization of email addresses so that the same
+// mailbox is always stored and looked up under the same string.
+package email
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalid is returned for addresses that cannot be normalized.
+var ErrInvalid = errors.New("invalid email address")
+
+// Normalizer canonicalizes email addresses.
+type Normalizer struct {
+	lowercaseLocal bool
+	punycodeDomain bool
+}
+
+// NewNormalizer creates a new Normalizer configured from the provided configuration.
+func NewNormalizer(cfg *config.Config) *Normalizer {
+	return &Normalizer{
+		lowercaseLocal: cfg.EmailLowercaseLocal,
+		punycodeDomain: cfg.EmailPunycodeDomain,
+	}
+}
+
+// Normalize trims surrounding whitespace, applies Unicode NFC and lowercases
+// the domain. Depending on the configuration it also lowercases the local
+// part and converts an internationalized domain to its punycode form.
+func (n *Normalizer) Normalize(address string) (string, error) {
+	address = norm.NFC.String(strings.TrimSpace(address))
+
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return "", ErrInvalid
+	}
+	local, domain := address[:at], strings.ToLower(address[at+1:])
+
+	if n.lowercaseLocal {
+		local = strings.ToLower(local)
+	}
+
+	if n.punycodeDomain {
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return "", ErrInvalid
+		}
+		domain = ascii
+	}
+
+	return local + "@" + domain, nil
+}
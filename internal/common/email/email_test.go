@@ -0,0 +1,84 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "trims and lowercases domain",
+			input: "  Test@Example.COM \n",
+			want:  "Test@example.com",
+		},
+		{
+			name:  "lowercases local part when enabled",
+			cfg:   config.Config{EmailLowercaseLocal: true},
+			input: "Test@Example.com",
+			want:  "test@example.com",
+		},
+		{
+			name:  "applies NFC",
+			input: "jose\u0301@example.com",
+			want:  "jos\u00e9@example.com",
+		},
+		{
+			name:  "converts internationalized domain to punycode when enabled",
+			cfg:   config.Config{EmailPunycodeDomain: true},
+			input: "user@Bücher.example",
+			want:  "user@xn--bcher-kva.example",
+		},
+		{
+			name:  "keeps internationalized domain when disabled",
+			input: "user@Bücher.example",
+			want:  "user@bücher.example",
+		},
+		{
+			name:  "quoted local part containing @",
+			input: `"a@b"@Example.com`,
+			want:  `"a@b"@example.com`,
+		},
+		{
+			name:    "missing domain",
+			input:   "user@",
+			wantErr: true,
+		},
+		{
+			name:    "missing local part",
+			input:   "@example.com",
+			wantErr: true,
+		},
+		{
+			name:    "no at sign",
+			input:   "user.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "invalid internationalized domain",
+			cfg:     config.Config{EmailPunycodeDomain: true},
+			input:   "user@exa mple.com",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNormalizer(&tt.cfg).Normalize(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
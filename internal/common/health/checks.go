@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck returns a CheckFunc that pings the database.
+func DatabaseCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("database connection error: %w", err)
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return fmt.Errorf("database ping failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// MigrationsCheck returns a CheckFunc that verifies the tables of the given models exist.
+func MigrationsCheck(db *gorm.DB, models ...interface{}) CheckFunc {
+	return func(ctx context.Context) error {
+		migrator := db.WithContext(ctx).Migrator()
+		for _, m := range models {
+			if !migrator.HasTable(m) {
+				return fmt.Errorf("table for %T has not been migrated", m)
+			}
+		}
+		return nil
+	}
+}
+
+// MailerCheck returns a CheckFunc that connects to the configured SMTP server
+// and exchanges a NOOP with it. The server is read from the store on every
+// run so that configuration reloads are followed.
+func MailerCheck(store *config.Store) CheckFunc {
+	return func(ctx context.Context) error {
+		cfg := store.Get()
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort))
+		if err != nil {
+			return fmt.Errorf("mail server unreachable: %w", err)
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		client, err := smtp.NewClient(conn, cfg.SMTPHost)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("mail server unreachable: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Noop(); err != nil {
+			return fmt.Errorf("mail server not responding: %w", err)
+		}
+		return client.Quit()
+	}
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name: "ping succeeds",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectPing()
+			},
+		},
+		{
+			name: "ping fails",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectPing().WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			require.NoError(t, err)
+			defer sqlDB.Close()
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DisableAutomaticPing: true})
+			require.NoError(t, err)
+
+			tt.mockFn(sqlMock)
+			err = DatabaseCheck(gormDB)(context.Background())
+
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "database ping failed")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		exists  bool
+		wantErr bool
+	}{
+		{name: "table exists", exists: true},
+		{name: "table missing", exists: false, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gormDB, sqlMock := testutil.DBMock(t)
+			count := 0
+			if tt.exists {
+				count = 1
+			}
+			sqlMock.ExpectQuery(`SELECT count\(\*\) FROM information_schema.tables`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+
+			err := MigrationsCheck(gormDB, &model.User{})(context.Background())
+
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "has not been migrated")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// serveSMTP answers the connections of l as a minimal SMTP server.
+func serveSMTP(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			_, _ = conn.Write([]byte("220 test ESMTP\r\n"))
+			r := bufio.NewReader(conn)
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.HasPrefix(line, "QUIT") {
+					_, _ = conn.Write([]byte("221 bye\r\n"))
+					return
+				}
+				_, _ = conn.Write([]byte("250 ok\r\n"))
+			}
+		}()
+	}
+}
+
+func TestMailerCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go serveSMTP(l)
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = MailerCheck(config.NewStore(&config.Config{SMTPHost: host, SMTPPort: port}))(ctx)
+	assert.NoError(t, err)
+
+	l.Close()
+	err = MailerCheck(config.NewStore(&config.Config{SMTPHost: host, SMTPPort: port}))(ctx)
+	assert.ErrorContains(t, err, "mail server unreachable")
+}
@@ -4,47 +4,52 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // Handler defines the interface for health check HTTP requests.
 type Handler interface {
-	Check(c *gin.Context)
+	Live(c *gin.Context)
+	Ready(c *gin.Context)
+	Started(c *gin.Context)
 }
 
 // handler handles health check HTTP requests.
 type handler struct {
-	db *gorm.DB
+	registry *Registry
 }
 
-// NewHandler creates a new instance of handler with the provided DB connection.
-func NewHandler(db *gorm.DB) Handler {
-	return &handler{db: db}
+// NewHandler creates a new instance of handler with the provided check registry.
+func NewHandler(registry *Registry) Handler {
+	return &handler{registry: registry}
 }
 
-// Check performs a health check on the application and its dependencies.
-func (h *handler) Check(c *gin.Context) {
-	// Check database connection
-	sqlDB, err := h.db.DB()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "Database connection error",
-		})
-		return
-	}
+// Live reports whether the process is alive and able to serve requests.
+func (h *handler) Live(c *gin.Context) {
+	h.respond(c, Liveness)
+}
+
+// Ready reports whether the application can accept traffic.
+func (h *handler) Ready(c *gin.Context) {
+	h.respond(c, Readiness)
+}
 
-	// Test database connectivity
-	if err := sqlDB.Ping(); err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "Database ping failed",
-		})
-		return
+// Started reports whether the application has finished starting up.
+func (h *handler) Started(c *gin.Context) {
+	h.respond(c, Startup)
+}
+
+// respond runs the checks for scope and writes the report. Per-check results
+// are only included when the verbose query parameter is present.
+func (h *handler) respond(c *gin.Context, scope Scope) {
+	report := h.registry.Run(c.Request.Context(), scope)
+
+	if _, verbose := c.GetQuery("verbose"); !verbose {
+		report.Checks = nil
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"message": "Service is healthy",
-	})
+	code := http.StatusOK
+	if !report.Healthy() {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
 }
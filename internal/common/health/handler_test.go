@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHandlerTest(registry *Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(registry)
+	router := gin.New()
+	router.GET("/livez", h.Live)
+	router.GET("/readyz", h.Ready)
+	router.GET("/startupz", h.Started)
+	return router
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{Name: "database", Func: func(context.Context) error { return nil }, Scopes: Readiness})
+	registry.Register(Check{Name: "migrations", Func: func(context.Context) error { return errors.New("missing") }, Scopes: Startup})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantCode   int
+		wantChecks int
+	}{
+		{name: "liveness", path: "/livez", wantCode: http.StatusOK},
+		{name: "readiness", path: "/readyz", wantCode: http.StatusOK},
+		{name: "readiness verbose", path: "/readyz?verbose", wantCode: http.StatusOK, wantChecks: 1},
+		{name: "startup failing", path: "/startupz", wantCode: http.StatusServiceUnavailable},
+		{name: "startup failing verbose", path: "/startupz?verbose=1", wantCode: http.StatusServiceUnavailable, wantChecks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupHandlerTest(registry)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			var report Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Len(t, report.Checks, tt.wantChecks)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, StatusOK, report.Status)
+			} else {
+				assert.Equal(t, StatusFail, report.Status)
+			}
+		})
+	}
+}
+
+func TestHandler_ReadyDuringShutdown(t *testing.T) {
+	registry := NewRegistry()
+	router := setupHandlerTest(registry)
+	registry.SetShuttingDown()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "shutting down")
+}
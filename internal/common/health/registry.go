@@ -0,0 +1,197 @@
+// Package health provides liveness, readiness and startup probes backed by
+// a registry of named dependency checks.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Scope selects which probes a check contributes to.
+type Scope uint8
+
+// Probe scopes a check can belong to.
+const (
+	Liveness Scope = 1 << iota
+	Readiness
+	Startup
+)
+
+// Check statuses reported in results.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// CheckFunc reports whether a dependency is healthy.
+type CheckFunc func(ctx context.Context) error
+
+// Check describes a named dependency check.
+type Check struct {
+	// Name identifies the check in verbose reports.
+	Name string
+	// Func performs the check.
+	Func CheckFunc
+	// Scopes lists the probes this check belongs to.
+	Scopes Scope
+	// Timeout bounds a single run of Func. Defaults to 2s.
+	Timeout time.Duration
+	// CacheTTL is how long a result is reused before Func runs again. Defaults to 5s.
+	CacheTTL time.Duration
+	// Optional checks are reported but do not fail the probe, for dependencies
+	// whose outage degrades the application without stopping it from serving.
+	Optional bool
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Optional  bool      `json:"optional,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the aggregated outcome of a probe.
+type Report struct {
+	Status string   `json:"status"`
+	Reason string   `json:"reason,omitempty"`
+	Checks []Result `json:"checks,omitempty"`
+}
+
+// Healthy reports whether every check in the report passed.
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// entry is a registered check together with its cached result.
+type entry struct {
+	check  Check
+	mu     sync.Mutex
+	result Result
+	cached time.Time
+}
+
+// Registry holds the registered checks and the application lifecycle state.
+type Registry struct {
+	mu           sync.RWMutex
+	entries      []*entry
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+	now          func() time.Time
+}
+
+// NewRegistry creates a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{now: time.Now}
+}
+
+// Register adds a check to the registry.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+	if check.CacheTTL <= 0 {
+		check.CacheTTL = defaultCacheTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, &entry{check: check})
+}
+
+// SetShuttingDown marks the application as shutting down so that readiness
+// fails immediately and load balancers stop sending new traffic.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether shutdown has started.
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Run executes every check belonging to scope concurrently and aggregates the results.
+func (r *Registry) Run(ctx context.Context, scope Scope) Report {
+	if scope == Readiness && r.ShuttingDown() {
+		return Report{Status: StatusFail, Reason: "shutting down"}
+	}
+	if scope == Startup && r.started.Load() {
+		return Report{Status: StatusOK}
+	}
+
+	r.mu.RLock()
+	var entries []*entry
+	for _, e := range r.entries {
+		if e.check.Scopes&scope != 0 {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = r.run(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK && !res.Optional {
+			report.Status = StatusFail
+		}
+	}
+
+	if scope == Startup && report.Healthy() {
+		r.started.Store(true)
+	}
+
+	return report
+}
+
+// run executes a single check, reusing its cached result while it is fresh.
+// Holding the entry lock ensures concurrent probes never run the same check twice.
+func (r *Registry) run(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := r.now()
+	if !e.cached.IsZero() && now.Sub(e.cached) < e.check.CacheTTL {
+		return e.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.check.Func(ctx)
+
+	res := Result{
+		Name:      e.check.Name,
+		Status:    StatusOK,
+		Optional:  e.check.Optional,
+		Duration:  time.Since(start).String(),
+		CheckedAt: now,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	e.result = res
+	e.cached = now
+	return res
+}
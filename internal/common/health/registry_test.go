@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okCheck(calls *atomic.Int32) CheckFunc {
+	return func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}
+}
+
+func TestRegistry_Run(t *testing.T) {
+	failing := func(context.Context) error { return errors.New("down") }
+	passing := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		scope      Scope
+		wantStatus string
+		wantChecks []string
+	}{
+		{
+			name:       "no checks is healthy",
+			scope:      Liveness,
+			wantStatus: StatusOK,
+		},
+		{
+			name: "only checks in scope run",
+			checks: []Check{
+				{Name: "database", Func: passing, Scopes: Readiness | Startup},
+				{Name: "migrations", Func: failing, Scopes: Startup},
+			},
+			scope:      Readiness,
+			wantStatus: StatusOK,
+			wantChecks: []string{"database"},
+		},
+		{
+			name: "any failing check fails the probe",
+			checks: []Check{
+				{Name: "database", Func: passing, Scopes: Startup},
+				{Name: "migrations", Func: failing, Scopes: Startup},
+			},
+			scope:      Startup,
+			wantStatus: StatusFail,
+			wantChecks: []string{"database", "migrations"},
+		},
+		{
+			name: "failing optional check does not fail the probe",
+			checks: []Check{
+				{Name: "database", Func: passing, Scopes: Readiness},
+				{Name: "mailer", Func: failing, Scopes: Readiness, Optional: true},
+			},
+			scope:      Readiness,
+			wantStatus: StatusOK,
+			wantChecks: []string{"database", "mailer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, c := range tt.checks {
+				registry.Register(c)
+			}
+
+			report := registry.Run(context.Background(), tt.scope)
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+			names := make([]string, 0, len(report.Checks))
+			for _, c := range report.Checks {
+				names = append(names, c.Name)
+			}
+			assert.ElementsMatch(t, tt.wantChecks, names)
+		})
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{
+		Name: "slow",
+		Func: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Scopes:  Readiness,
+		Timeout: 10 * time.Millisecond,
+	})
+
+	report := registry.Run(context.Background(), Readiness)
+
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var calls atomic.Int32
+	now := time.Now()
+
+	registry := NewRegistry()
+	registry.now = func() time.Time { return now }
+	registry.Register(Check{Name: "database", Func: okCheck(&calls), Scopes: Readiness, CacheTTL: time.Minute})
+
+	registry.Run(context.Background(), Readiness)
+	registry.Run(context.Background(), Readiness)
+	assert.Equal(t, int32(1), calls.Load())
+
+	now = now.Add(2 * time.Minute)
+	registry.Run(context.Background(), Readiness)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRegistry_StartupLatches(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry()
+	registry.Register(Check{Name: "migrations", Func: okCheck(&calls), Scopes: Startup, CacheTTL: time.Nanosecond})
+
+	assert.True(t, registry.Run(context.Background(), Startup).Healthy())
+	assert.True(t, registry.Run(context.Background(), Startup).Healthy())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRegistry_ShuttingDown(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry()
+	registry.Register(Check{Name: "database", Func: okCheck(&calls), Scopes: Readiness | Liveness})
+
+	assert.True(t, registry.Run(context.Background(), Readiness).Healthy())
+
+	registry.SetShuttingDown()
+	assert.True(t, registry.ShuttingDown())
+
+	report := registry.Run(context.Background(), Readiness)
+	assert.False(t, report.Healthy())
+	assert.Equal(t, "shutting down", report.Reason)
+	assert.True(t, registry.Run(context.Background(), Liveness).Healthy())
+}
@@ -0,0 +1,104 @@
+// Package logger provides structured logging built on log/slog.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+)
+
+// redactedValue replaces the value of any attribute considered sensitive.
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys lists substrings of attribute keys whose values must never be logged.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie"}
+
+// ctxKey is the context key type for the request-scoped logger.
+type ctxKey struct{}
+
+// New creates a new slog.Logger configured from the provided configuration.
+func New(cfg *config.Config) *slog.Logger {
+	return NewWithWriter(os.Stdout, cfg)
+}
+
+// NewWithWriter creates a new slog.Logger that writes to w using the provided configuration.
+func NewWithWriter(w io.Writer, cfg *config.Config) *slog.Logger {
+	return NewWithLevel(w, cfg.LogFormat, ParseLevel(cfg.LogLevel))
+}
+
+// NewWithLevel creates a new slog.Logger that writes to w in the given format.
+// Passing a *slog.LevelVar allows the level to be changed while running, see WatchLevel.
+func NewWithLevel(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(handler)
+}
+
+// ParseLevel converts a level name into a slog.Level, defaulting to info.
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// WatchLevel returns a level that follows the log level of the configuration held by store.
+func WatchLevel(store *config.Store) *slog.LevelVar {
+	level := new(slog.LevelVar)
+	level.Set(ParseLevel(store.Get().LogLevel))
+	store.Subscribe(func(old, new *config.Config) {
+		if old.LogLevel != new.LogLevel {
+			level.Set(ParseLevel(new.LogLevel))
+		}
+	})
+	return level
+}
+
+// WithContext returns a copy of ctx carrying the provided logger.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok && l != nil {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// redact replaces the value of sensitive attributes with a placeholder.
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, redactedValue)
+	}
+	return a
+}
+
+// IsSensitive reports whether an attribute or field with the given key holds a secret.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
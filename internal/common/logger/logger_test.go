@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithWriter(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *config.Config
+		logFn     func(*slog.Logger)
+		wantEmpty bool
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name: "json format",
+			cfg:  &config.Config{LogLevel: "info", LogFormat: "json"},
+			logFn: func(l *slog.Logger) {
+				l.Info("hello", slog.String("key", "value"))
+			},
+			contains: []string{`"msg":"hello"`, `"key":"value"`},
+		},
+		{
+			name: "text format",
+			cfg:  &config.Config{LogLevel: "info", LogFormat: "text"},
+			logFn: func(l *slog.Logger) {
+				l.Info("hello", slog.String("key", "value"))
+			},
+			contains: []string{"msg=hello", "key=value"},
+		},
+		{
+			name: "below configured level",
+			cfg:  &config.Config{LogLevel: "warn", LogFormat: "text"},
+			logFn: func(l *slog.Logger) {
+				l.Info("hello")
+			},
+			wantEmpty: true,
+		},
+		{
+			name: "sensitive attributes are redacted",
+			cfg:  &config.Config{LogLevel: "info", LogFormat: "json"},
+			logFn: func(l *slog.Logger) {
+				l.Info("hello",
+					slog.String("password", "hunter22"),
+					slog.String("jwt_secret", "s3cr3t"),
+					slog.Group("req", slog.String("Authorization", "Bearer abc")),
+				)
+			},
+			contains: []string{`"password":"[REDACTED]"`, `"jwt_secret":"[REDACTED]"`, `"Authorization":"[REDACTED]"`},
+			excludes: []string{"hunter22", "s3cr3t", "Bearer abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.logFn(NewWithWriter(&buf, tt.cfg))
+
+			if tt.wantEmpty {
+				assert.Empty(t, buf.String())
+				return
+			}
+			for _, s := range tt.contains {
+				assert.Contains(t, buf.String(), s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, buf.String(), s)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  slog.Level
+	}{
+		{name: "debug", level: "debug", want: slog.LevelDebug},
+		{name: "upper case", level: "WARN", want: slog.LevelWarn},
+		{name: "error", level: "error", want: slog.LevelError},
+		{name: "invalid defaults to info", level: "verbose", want: slog.LevelInfo},
+		{name: "empty defaults to info", level: "", want: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseLevel(tt.level))
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWithWriter(&buf, &config.Config{LogLevel: "info", LogFormat: "json"}).With(slog.String("request_id", "abc"))
+
+	t.Run("logger stored in context", func(t *testing.T) {
+		ctx := WithContext(context.Background(), l)
+		FromContext(ctx).Info("scoped")
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "abc", entry["request_id"])
+	})
+
+	t.Run("falls back to default logger", func(t *testing.T) {
+		assert.Equal(t, slog.Default(), FromContext(context.Background()))
+	})
+}
+
+func TestWatchLevel(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("LOG_LEVEL", "info")
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	store := config.NewStore(cfg)
+
+	var buf bytes.Buffer
+	l := NewWithLevel(&buf, "text", WatchLevel(store))
+
+	l.Debug("hidden")
+	assert.Empty(t, buf.String())
+
+	next := *cfg
+	next.LogLevel = "debug"
+	_, err = store.Apply(&next)
+	require.NoError(t, err)
+
+	l.Debug("shown")
+	assert.Contains(t, buf.String(), "shown")
+}
@@ -0,0 +1,127 @@
+// Package mail sends notification emails to users.
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+//go:generate mockgen -destination=./mail_mock.go -package=mail github.com/PakornBank/go-backend-example/internal/common/mail Sender
+
+// Sender defines the methods that a mail sender must implement.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender creates a new Sender delivering through the configured SMTP
+// server, or one that only logs messages when no server is configured. The
+// SMTP password is read from the store on every message so that a rotated
+// password takes effect immediately.
+func NewSender(store *config.Store) Sender {
+	if store.Get().SMTPHost == "" {
+		return logSender{}
+	}
+	return &smtpSender{store: store}
+}
+
+// logSender logs messages instead of sending them.
+type logSender struct{}
+
+// Send logs the recipient and subject of msg.
+func (logSender) Send(ctx context.Context, msg Message) error {
+	logger.FromContext(ctx).Info("email not sent, no SMTP server configured",
+		slog.String("to", msg.To), slog.String("subject", msg.Subject))
+	return nil
+}
+
+// smtpSender sends messages through an SMTP server, upgrading the connection
+// with STARTTLS when the server supports it.
+type smtpSender struct {
+	store *config.Store
+}
+
+// Send delivers msg, giving up when ctx is done.
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	cfg := s.store.Get()
+	addr := net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.SMTPHost}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if cfg.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)); err != nil {
+			return fmt.Errorf("failed to authenticate with mail server: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.MailFrom); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(compose(cfg.MailFrom, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose renders msg from the sender address as a plain text email with CRLF line endings.
+func compose(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	header := func(name, value string) {
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		name     string
+		smtpHost string
+		want     Sender
+	}{
+		{name: "no SMTP server", want: logSender{}},
+		{name: "SMTP server", smtpHost: "smtp.example.com", want: &smtpSender{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := config.NewStore(&config.Config{SMTPHost: tt.smtpHost})
+			sender := NewSender(store)
+			assert.IsType(t, tt.want, sender)
+			assert.NoError(t, logSender{}.Send(context.Background(), Message{To: "user@example.com"}))
+		})
+	}
+}
+
+func Test_compose(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msg := Message{
+		To:      "user@example.com",
+		Subject: "New login to your account",
+		Body:    "Hello,\nsomeone logged in.\n",
+	}
+
+	got := string(compose("no-reply@example.com", msg, date))
+
+	assert.Equal(t, "From: no-reply@example.com\r\n"+
+		"To: user@example.com\r\n"+
+		"Subject: New login to your account\r\n"+
+		"Date: Wed, 01 May 2024 12:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=\"utf-8\"\r\n"+
+		"Content-Transfer-Encoding: 8bit\r\n"+
+		"\r\n"+
+		"Hello,\r\nsomeone logged in.\r\n", got)
+}
+
+func Test_compose_EncodesSubject(t *testing.T) {
+	got := string(compose("no-reply@example.com", Message{Subject: "Connexion réussie"}, time.Now()))
+	assert.Contains(t, got, "Subject: =?utf-8?q?Connexion_r=C3=A9ussie?=\r\n")
+}
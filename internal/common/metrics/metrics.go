@@ -0,0 +1,245 @@
+// Package metrics provides Prometheus instrumentation for the application.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/buildinfo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric exported by the application.
+const namespace = "go_auth_api"
+
+// Login results recorded by ObserveLogin.
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginError              = "error"
+)
+
+// Outbox delivery results recorded by ObserveOutbox.
+const (
+	OutboxDelivered = "delivered"
+	OutboxRetried   = "retried"
+	OutboxDead      = "dead"
+)
+
+// Webhook delivery results recorded by ObserveWebhook.
+const (
+	WebhookSucceeded = "succeeded"
+	WebhookRetried   = "retried"
+	WebhookFailed    = "failed"
+)
+
+// Job run results recorded by ObserveJob.
+const (
+	JobSucceeded = "succeeded"
+	JobRetried   = "retried"
+	JobFailed    = "failed"
+)
+
+// Scheduled task run results recorded by ObserveScheduledRun.
+const (
+	ScheduledRunSucceeded = "succeeded"
+	ScheduledRunFailed    = "failed"
+)
+
+// Metrics holds the Prometheus registry and the collectors used by the application.
+type Metrics struct {
+	registry      *prometheus.Registry
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	loginAttempts *prometheus.CounterVec
+	outboxEvents  *prometheus.CounterVec
+	webhooks      *prometheus.CounterVec
+	jobs          *prometheus.CounterVec
+	scheduledRuns *prometheus.CounterVec
+	lastRun       *prometheus.GaugeVec
+	lastSuccess   *prometheus.GaugeVec
+	leader        prometheus.Gauge
+}
+
+// New creates a new Metrics with its own registry, including Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		loginAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "login_attempts_total",
+			Help:      "Total number of login attempts by result.",
+		}, []string{"result"}),
+		outboxEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "outbox",
+			Name:      "deliveries_total",
+			Help:      "Total number of outbox event delivery attempts by event type and result.",
+		}, []string{"type", "result"}),
+		webhooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "webhook",
+			Name:      "deliveries_total",
+			Help:      "Total number of webhook delivery attempts by event type and result.",
+		}, []string{"type", "result"}),
+		jobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "job",
+			Name:      "runs_total",
+			Help:      "Total number of background job runs by job type and result.",
+		}, []string{"type", "result"}),
+		scheduledRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "runs_total",
+			Help:      "Total number of runs of scheduled tasks by task and result.",
+		}, []string{"task", "result"}),
+		lastRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "last_run_timestamp_seconds",
+			Help:      "Unix time at which a scheduled task last finished, whatever its result.",
+		}, []string{"task"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time at which a scheduled task last succeeded.",
+		}, []string{"task"}),
+		leader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "leader",
+			Help:      "Whether this instance runs the scheduled tasks: 1 when it holds the lease, 0 otherwise.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.loginAttempts,
+		m.outboxEvents,
+		m.webhooks,
+		m.jobs,
+		m.scheduledRuns,
+		m.lastRun,
+		m.lastSuccess,
+		m.leader,
+	)
+
+	return m
+}
+
+// Registry returns the underlying Prometheus registry.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns an http.Handler serving the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDB exports connection pool statistics of db under the given name.
+func (m *Metrics) RegisterDB(name string, db *sql.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterBuildInfo exports a constant build_info gauge labelled with the build information.
+func (m *Metrics) RegisterBuildInfo(info buildinfo.Info) error {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "A metric with a constant '1' value labelled by version, commit, build date and Go version.",
+	}, []string{"version", "commit_sha", "build_date", "go_version"})
+	if err := m.registry.Register(gauge); err != nil {
+		return err
+	}
+	gauge.WithLabelValues(info.Version, info.CommitSHA, info.BuildDate, info.GoVersion).Set(1)
+	return nil
+}
+
+// ObserveHTTP records a handled HTTP request. It is safe to call on a nil Metrics.
+func (m *Metrics) ObserveHTTP(method, route, status string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, status).Inc()
+	m.httpDuration.WithLabelValues(method, route, status).Observe(seconds)
+}
+
+// ObserveLogin records the result of a login attempt. It is safe to call on a nil Metrics.
+func (m *Metrics) ObserveLogin(result string) {
+	if m == nil {
+		return
+	}
+	m.loginAttempts.WithLabelValues(result).Inc()
+}
+
+// ObserveOutbox records the result of an attempt to deliver an outbox event. It is safe to call on a nil Metrics.
+func (m *Metrics) ObserveOutbox(eventType, result string) {
+	if m == nil {
+		return
+	}
+	m.outboxEvents.WithLabelValues(eventType, result).Inc()
+}
+
+// ObserveWebhook records the result of an attempt to send a webhook delivery. It is safe to call on a nil Metrics.
+func (m *Metrics) ObserveWebhook(eventType, result string) {
+	if m == nil {
+		return
+	}
+	m.webhooks.WithLabelValues(eventType, result).Inc()
+}
+
+// ObserveJob records the result of a run of a background job. It is safe to call on a nil Metrics.
+func (m *Metrics) ObserveJob(jobType, result string) {
+	if m == nil {
+		return
+	}
+	m.jobs.WithLabelValues(jobType, result).Inc()
+}
+
+// ObserveScheduledRun records the result of a run of a scheduled task finished at the given time.
+// It is safe to call on a nil Metrics.
+func (m *Metrics) ObserveScheduledRun(task, result string, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.scheduledRuns.WithLabelValues(task, result).Inc()
+	m.lastRun.WithLabelValues(task).Set(float64(at.Unix()))
+	if result == ScheduledRunSucceeded {
+		m.lastSuccess.WithLabelValues(task).Set(float64(at.Unix()))
+	}
+}
+
+// SetSchedulerLeader records whether this instance runs the scheduled tasks. It is safe to call on a nil Metrics.
+func (m *Metrics) SetSchedulerLeader(leader bool) {
+	if m == nil {
+		return
+	}
+	if leader {
+		m.leader.Set(1)
+	} else {
+		m.leader.Set(0)
+	}
+}
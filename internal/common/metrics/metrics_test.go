@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/buildinfo"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	m := New()
+
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.Contains(t, names, "go_goroutines")
+}
+
+func TestMetrics_ObserveHTTP(t *testing.T) {
+	m := New()
+	m.ObserveHTTP(http.MethodGet, "/api/user/profile", "200", 0.1)
+	m.ObserveHTTP(http.MethodGet, "/api/user/profile", "200", 0.2)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/api/user/profile", "200")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.httpDuration))
+}
+
+func TestMetrics_ObserveLogin(t *testing.T) {
+	m := New()
+	m.ObserveLogin(LoginSuccess)
+	m.ObserveLogin(LoginInvalidCredentials)
+	m.ObserveLogin(LoginInvalidCredentials)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.loginAttempts.WithLabelValues(LoginSuccess)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.loginAttempts.WithLabelValues(LoginInvalidCredentials)))
+}
+
+func TestMetrics_ObserveOutbox(t *testing.T) {
+	m := New()
+	m.ObserveOutbox("user.registered", OutboxDelivered)
+	m.ObserveOutbox("user.registered", OutboxRetried)
+	m.ObserveOutbox("user.registered", OutboxRetried)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.outboxEvents.WithLabelValues("user.registered", OutboxDelivered)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.outboxEvents.WithLabelValues("user.registered", OutboxRetried)))
+}
+
+func TestMetrics_ObserveWebhook(t *testing.T) {
+	m := New()
+	m.ObserveWebhook("user.deleted", WebhookSucceeded)
+	m.ObserveWebhook("user.deleted", WebhookFailed)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.webhooks.WithLabelValues("user.deleted", WebhookSucceeded)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.webhooks.WithLabelValues("user.deleted", WebhookFailed)))
+}
+
+func TestMetrics_ObserveJob(t *testing.T) {
+	m := New()
+	m.ObserveJob("mail.send", JobRetried)
+	m.ObserveJob("mail.send", JobSucceeded)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.jobs.WithLabelValues("mail.send", JobRetried)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.jobs.WithLabelValues("mail.send", JobSucceeded)))
+}
+
+func TestMetrics_ObserveScheduledRun(t *testing.T) {
+	m := New()
+	first := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	m.ObserveScheduledRun("sessions.purge", ScheduledRunSucceeded, first)
+	m.ObserveScheduledRun("sessions.purge", ScheduledRunFailed, second)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.scheduledRuns.WithLabelValues("sessions.purge", ScheduledRunSucceeded)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.scheduledRuns.WithLabelValues("sessions.purge", ScheduledRunFailed)))
+	assert.Equal(t, float64(second.Unix()), testutil.ToFloat64(m.lastRun.WithLabelValues("sessions.purge")))
+	assert.Equal(t, float64(first.Unix()), testutil.ToFloat64(m.lastSuccess.WithLabelValues("sessions.purge")))
+}
+
+func TestMetrics_SetSchedulerLeader(t *testing.T) {
+	m := New()
+	m.SetSchedulerLeader(true)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.leader))
+	m.SetSchedulerLeader(false)
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.leader))
+}
+
+func TestMetrics_NilSafe(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ObserveHTTP(http.MethodGet, "/", "200", 0)
+		m.ObserveLogin(LoginSuccess)
+		m.ObserveOutbox("user.registered", OutboxDead)
+		m.ObserveWebhook("user.registered", WebhookRetried)
+		m.ObserveJob("mail.send", JobFailed)
+		m.ObserveScheduledRun("sessions.purge", ScheduledRunFailed, time.Now())
+		m.SetSchedulerLeader(true)
+	})
+}
+
+func TestMetrics_RegisterDB(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	m := New()
+	require.NoError(t, m.RegisterDB("primary", sqlDB))
+
+	count, err := testutil.GatherAndCount(m.Registry(), "go_sql_max_open_connections")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.Error(t, m.RegisterDB("primary", sqlDB))
+}
+
+func TestMetrics_RegisterBuildInfo(t *testing.T) {
+	m := New()
+	info := buildinfo.Info{Version: "v1.0.0", CommitSHA: "abc123", BuildDate: "2025-01-01", GoVersion: "go1.24.0"}
+	require.NoError(t, m.RegisterBuildInfo(info))
+
+	expected := `
+# HELP go_auth_api_build_info A metric with a constant '1' value labelled by version, commit, build date and Go version.
+# TYPE go_auth_api_build_info gauge
+go_auth_api_build_info{build_date="2025-01-01",commit_sha="abc123",go_version="go1.24.0",version="v1.0.0"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected), "go_auth_api_build_info"))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveLogin(LoginSuccess)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(string(body), `go_auth_api_auth_login_attempts_total{result="success"} 1`))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	const admin = "6f1c2d8e-3b5a-4c7d-9e0f-1a2b3c4d5e6f"
+
+	tests := []struct {
+		name        string
+		userID      interface{}
+		wantCode    int
+		errContains string
+	}{
+		{
+			name:     "admin",
+			userID:   admin,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "not an admin",
+			userID:      "1d04e9a4-0c44-4da9-af14-ac9b1300f419",
+			wantCode:    http.StatusForbidden,
+			errContains: "forbidden",
+		},
+		{
+			name:        "not authenticated",
+			wantCode:    http.StatusUnauthorized,
+			errContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.userID != nil {
+					c.Set("user_id", tt.userID)
+				}
+			})
+			router.Use(RequireAdmin(config.NewStore(&config.Config{AdminUserIDs: admin})))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.errContains != "" {
+				var res map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Contains(t, res["error"], tt.errContains)
+			}
+		})
+	}
+}
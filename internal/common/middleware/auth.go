@@ -2,58 +2,98 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/response"
+	"github.com/PakornBank/go-backend-example/internal/session"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
 // Auth is a middleware function for the Gin framework that handles
-// JWT authentication.
-func Auth(jwtSecret string) gin.HandlerFunc {
+// JWT authentication. The secret is read from the store on every request so
+// that a rotated secret takes effect without a restart, and tokens signed with
+// the previous secret are accepted while it is set. The session named by the
+// token must still be valid, so revoking a session rejects its tokens.
+func Auth(store *config.Store, sessions session.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
 		if header == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			response.Error(c, http.StatusUnauthorized, "authorization header required")
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(header, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+			response.Error(c, http.StatusUnauthorized, "invalid authorization header format")
 			c.Abort()
 			return
 		}
 
-		token, err := jwt.Parse(parts[1], func(_ *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
-
+		token, err := parseToken(parts[1], store.Get())
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			response.Error(c, http.StatusUnauthorized, "invalid token")
 			c.Abort()
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			response.Error(c, http.StatusUnauthorized, "invalid token claims")
 			c.Abort()
 			return
 		}
 
 		userID, hasUserID := claims["user_id"]
 		email, hasEmail := claims["email"]
-		if !hasUserID || userID == "" || !hasEmail || email == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		sessionID, hasSessionID := claims["sid"].(string)
+		if !hasUserID || userID == "" || !hasEmail || email == "" || !hasSessionID || sessionID == "" {
+			response.Error(c, http.StatusUnauthorized, "invalid token claims")
+			c.Abort()
+			return
+		}
+
+		err = sessions.Validate(c.Request.Context(), fmt.Sprint(userID), sessionID)
+		if errors.Is(err, session.ErrInvalid) {
+			response.Error(c, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "failed to validate session")
 			c.Abort()
 			return
 		}
 
 		c.Set("user_id", userID)
 		c.Set("email", email)
+		c.Set("session_id", sessionID)
 		c.Next()
 	}
 }
+
+// parseToken verifies raw with the current secret, falling back to the
+// previous secret when the signature does not match and one is set.
+func parseToken(raw string, cfg *config.Config) (*jwt.Token, error) {
+	token, err := jwt.Parse(raw, hmacKey(cfg.JWTSecret))
+	var ve *jwt.ValidationError
+	if err != nil && cfg.JWTPreviousSecret != "" && errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+		return jwt.Parse(raw, hmacKey(cfg.JWTPreviousSecret))
+	}
+	return token, err
+}
+
+// hmacKey returns a jwt.Keyfunc accepting only HMAC-signed tokens, verified with secret.
+func hmacKey(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	}
+}
@@ -2,45 +2,71 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/session"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
 )
 
 const (
-	testSecret   = "test-secret"
-	bearerPrefix = "Bearer "
+	testSecret         = "test-secret"
+	testPreviousSecret = "test-previous-secret"
+	testSessionID      = "test-session-id"
+	bearerPrefix       = "Bearer "
 )
 
-func setupAuthTest() *gin.Engine {
+func setupAuthTest(t *testing.T) (*gin.Engine, *session.MockService) {
 	gin.SetMode(gin.TestMode)
+	mockSessions := session.NewMockService(gomock.NewController(t))
 	router := gin.New()
-	router.Use(Auth(testSecret))
+	router.Use(Auth(config.NewStore(&config.Config{JWTSecret: testSecret, JWTPreviousSecret: testPreviousSecret}), mockSessions))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"user_id": c.MustGet("user_id"),
-			"email":   c.MustGet("email"),
+			"user_id":    c.MustGet("user_id"),
+			"email":      c.MustGet("email"),
+			"session_id": c.MustGet("session_id"),
 		})
 	})
-	return router
+	return router, mockSessions
 }
 
 func generateTestToken(userID string, email string, expiry time.Duration) string {
+	return generateTestSessionToken(userID, email, testSessionID, expiry)
+}
+
+func generateTestSessionToken(userID, email, sessionID string, expiry time.Duration) string {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
 		"exp":     time.Now().Add(expiry).Unix(),
 	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signedToken, _ := token.SignedString([]byte(testSecret))
 	return signedToken
 }
 
+func signTestToken(userID, email, secret string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"email":   email,
+		"sid":     testSessionID,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	signedToken, _ := token.SignedString([]byte(secret))
+	return signedToken
+}
+
 func TestAuth(t *testing.T) {
 	const (
 		testID    = "test-user-id"
@@ -50,6 +76,7 @@ func TestAuth(t *testing.T) {
 	tests := []struct {
 		name           string
 		generateHeader func() string
+		mockFn         func(*session.MockService)
 		wantCode       int
 		errContains    string
 	}{
@@ -58,8 +85,29 @@ func TestAuth(t *testing.T) {
 			generateHeader: func() string {
 				return bearerPrefix + generateTestToken(testID, testEmail, time.Hour)
 			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Validate(gomock.Any(), testID, testSessionID).Return(nil)
+			},
 			wantCode: http.StatusOK,
 		},
+		{
+			name: "token signed with the previous secret",
+			generateHeader: func() string {
+				return bearerPrefix + signTestToken(testID, testEmail, testPreviousSecret)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Validate(gomock.Any(), testID, testSessionID).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "token signed with an unknown secret",
+			generateHeader: func() string {
+				return bearerPrefix + signTestToken(testID, testEmail, "other-secret")
+			},
+			wantCode:    http.StatusUnauthorized,
+			errContains: "invalid token",
+		},
 		{
 			name: "expired token",
 			generateHeader: func() string {
@@ -135,11 +183,44 @@ func TestAuth(t *testing.T) {
 			wantCode:    http.StatusUnauthorized,
 			errContains: "invalid token claims",
 		},
+		{
+			name: "missing sid claim",
+			generateHeader: func() string {
+				return bearerPrefix + generateTestSessionToken(testID, testEmail, "", time.Hour)
+			},
+			wantCode:    http.StatusUnauthorized,
+			errContains: "invalid token claims",
+		},
+		{
+			name: "revoked session",
+			generateHeader: func() string {
+				return bearerPrefix + generateTestToken(testID, testEmail, time.Hour)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Validate(gomock.Any(), testID, testSessionID).Return(session.ErrInvalid)
+			},
+			wantCode:    http.StatusUnauthorized,
+			errContains: "session expired or revoked",
+		},
+		{
+			name: "session validation error",
+			generateHeader: func() string {
+				return bearerPrefix + generateTestToken(testID, testEmail, time.Hour)
+			},
+			mockFn: func(ms *session.MockService) {
+				ms.EXPECT().Validate(gomock.Any(), testID, testSessionID).Return(errors.New("db error"))
+			},
+			wantCode:    http.StatusInternalServerError,
+			errContains: "failed to validate session",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			router := setupAuthTest()
+			router, mockSessions := setupAuthTest(t)
+			if tt.mockFn != nil {
+				tt.mockFn(mockSessions)
+			}
 
 			req := httptest.NewRequest(http.MethodGet, "/test", nil)
 			if header := tt.generateHeader(); header != "" {
@@ -158,6 +239,7 @@ func TestAuth(t *testing.T) {
 			if tt.wantCode == http.StatusOK {
 				assert.Equal(t, testID, res["user_id"])
 				assert.Equal(t, testEmail, res["email"])
+				assert.Equal(t, testSessionID, res["session_id"])
 			} else {
 				assert.Contains(t, res["error"], tt.errContains)
 			}
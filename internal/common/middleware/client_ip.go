@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/PakornBank/go-backend-example/internal/common/clientip"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP is a middleware function for the Gin framework that stores the
+// IP of the client in the request context, where the audit log finds it.
+func ClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(clientip.WithContext(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
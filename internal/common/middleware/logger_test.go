@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLoggerTest(buf *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Logger(logger.NewWithWriter(buf, &config.Config{LogLevel: "debug", LogFormat: "json"})))
+	router.GET("/ok", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Debug("inside handler")
+		c.Set("user_id", "test-user-id")
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	return router
+}
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantLevel string
+		wantUser  interface{}
+	}{
+		{
+			name:      "successful request",
+			path:      "/ok",
+			wantLevel: "INFO",
+			wantUser:  "test-user-id",
+		},
+		{
+			name:      "server error",
+			path:      "/fail",
+			wantLevel: "ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			router := setupLoggerTest(&buf)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Request-ID", "req-123")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+
+			assert.Equal(t, "request completed", entry["msg"])
+			assert.Equal(t, tt.wantLevel, entry["level"])
+			assert.Equal(t, "req-123", entry["request_id"])
+			assert.Equal(t, http.MethodGet, entry["method"])
+			assert.Equal(t, tt.path, entry["path"])
+			assert.EqualValues(t, w.Code, entry["status"])
+			assert.Contains(t, entry, "latency")
+			assert.Equal(t, tt.wantUser, entry["user_id"])
+
+			if tt.path == "/ok" {
+				var scoped map[string]interface{}
+				require.NoError(t, json.Unmarshal(lines[0], &scoped))
+				assert.Equal(t, "inside handler", scoped["msg"])
+				assert.Equal(t, "req-123", scoped["request_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites is a middleware function for the Gin framework that starts a
+// database session for every request, so that reads following a write within
+// the same request are served by the primary rather than a lagging replica.
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID is a middleware function for the Gin framework that accepts a
+// valid X-Request-ID header or generates a new one, stores it in the request
+// context and echoes it in the response headers.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(requestid.WithContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
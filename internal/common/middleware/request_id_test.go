@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantHeader string
+	}{
+		{
+			name:       "accepts client request ID",
+			header:     "client-id-123",
+			wantHeader: "client-id-123",
+		},
+		{
+			name:   "generates missing request ID",
+			header: "",
+		},
+		{
+			name:   "replaces invalid request ID",
+			header: "bad id */",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(RequestID())
+
+			var ctxID, ginID string
+			router.GET("/test", func(c *gin.Context) {
+				ctxID = requestid.FromContext(c.Request.Context())
+				ginID = c.GetString("request_id")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(requestid.Header)
+			assert.True(t, requestid.Valid(got))
+			if tt.wantHeader != "" {
+				assert.Equal(t, tt.wantHeader, got)
+			} else {
+				assert.NotEqual(t, tt.header, got)
+			}
+			assert.Equal(t, got, ctxID)
+			assert.Equal(t, got, ginID)
+		})
+	}
+}
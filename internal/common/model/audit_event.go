@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent records a security-relevant action. Events form a hash chain:
+// every event stores the hash of the previous one and its own hash covers
+// both, so editing or deleting an event breaks the chain.
+type AuditEvent struct {
+	Seq        int64      `gorm:"primaryKey;autoIncrement:false"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index"`
+	Action     string     `gorm:"type:varchar(64);not null;index"`
+	TargetType string     `gorm:"type:varchar(64);not null;index:idx_audit_events_target,priority:1"`
+	TargetID   string     `gorm:"type:varchar(255);not null;index:idx_audit_events_target,priority:2"`
+	IP         string     `gorm:"type:varchar(45);not null"`
+	RequestID  string     `gorm:"type:varchar(128);not null"`
+	// Metadata is a JSON object, stored as text so that its hash can be recomputed byte for byte.
+	Metadata  string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"not null;index"`
+	PrevHash  string    `gorm:"type:char(64);not null"`
+	Hash      string    `gorm:"type:char(64);not null;uniqueIndex"`
+}
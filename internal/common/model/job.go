@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job is a unit of background work, run by the handler registered for its type.
+type Job struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Type    string    `gorm:"type:varchar(64);not null"`
+	Payload string    `gorm:"type:text;not null"`
+	// Status is pending until the job succeeded, or failed once its attempts are exhausted.
+	Status      string `gorm:"type:varchar(16);not null;index:idx_jobs_due,priority:1"`
+	Attempts    int    `gorm:"not null"`
+	MaxAttempts int    `gorm:"not null"`
+	// RunAt is when the job is due, pushed back while the job is running.
+	RunAt time.Time `gorm:"not null;index:idx_jobs_due,priority:2"`
+	// UniqueKey, when set, is held by at most one pending job.
+	UniqueKey *string   `gorm:"type:varchar(255);uniqueIndex:idx_jobs_unique_key,where:status = 'pending'"`
+	LastError string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
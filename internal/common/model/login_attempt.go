@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttempt records an attempt to log in, successful or not. Attempts
+// for unknown email addresses have no user.
+type LoginAttempt struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID      *uuid.UUID `gorm:"type:uuid;index:idx_login_attempts_user_created,priority:1"`
+	User        *User      `gorm:"constraint:OnDelete:CASCADE"`
+	Email       string     `gorm:"type:varchar(255);not null"`
+	Success     bool       `gorm:"not null"`
+	Reason      string     `gorm:"type:varchar(32);not null"`
+	IP          string     `gorm:"type:varchar(45);not null"`
+	UserAgent   string     `gorm:"type:varchar(512);not null"`
+	Device      string     `gorm:"type:varchar(255);not null"`
+	Fingerprint string     `gorm:"type:varchar(64);not null"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP;index:idx_login_attempts_user_created,priority:2"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is a domain event written in the transaction of the change it
+// describes, and delivered to its handlers once that transaction commits.
+type OutboxEvent struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Type    string    `gorm:"type:varchar(64);not null"`
+	Payload string    `gorm:"type:text;not null"`
+	// Status is pending until every handler succeeded, then delivered, or dead once the attempts are exhausted.
+	Status        string    `gorm:"type:varchar(16);not null;index:idx_outbox_events_due,priority:1"`
+	Attempts      int       `gorm:"not null"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_events_due,priority:2"`
+	LastError     string    `gorm:"type:text;not null"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	DeliveredAt   *time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordHistory records a password hash a user has had, so that recent passwords cannot be reused.
+type PasswordHistory struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index:idx_password_histories_user_created,priority:1"`
+	User         *User     `gorm:"constraint:OnDelete:CASCADE"`
+	PasswordHash string    `gorm:"type:varchar(255);not null"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;index:idx_password_histories_user_created,priority:2"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// SchedulerLease records which instance runs the scheduled tasks. The holder
+// renews the lease before it expires; another instance takes it over once it
+// has expired.
+type SchedulerLease struct {
+	Name      string    `gorm:"type:varchar(64);primaryKey"`
+	Holder    string    `gorm:"type:varchar(255);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a login of a user on a device. Tokens carry the ID of
+// their session, so revoking the session revokes the tokens.
+type Session struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	User       *User     `gorm:"constraint:OnDelete:CASCADE"`
+	UserAgent  string    `gorm:"type:varchar(512);not null"`
+	Device     string    `gorm:"type:varchar(255);not null"`
+	IP         string    `gorm:"type:varchar(45);not null"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	LastSeenAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+}
@@ -1,17 +1,38 @@
 package model
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// EmailIndex is the unique index enforcing case-insensitive email uniqueness.
+const EmailIndex = "idx_users_email_lower"
+
+// LegacyEmailIndex is the case-sensitive unique index on email replaced by
+// EmailIndex. It is dropped by the migration, but may still exist while the
+// migration has not run.
+const LegacyEmailIndex = "idx_users_email"
+
 // User represents a user in the system.
 type User struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id" validate:"required"`
-	Email        string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"email" validate:"required,email"`
+	Email        string    `gorm:"type:varchar(255);uniqueIndex:idx_users_email_lower,expression:lower(email);not null" json:"email" validate:"required,email"`
 	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-" validate:"required"`
 	FullName     string    `gorm:"type:varchar(255);not null" json:"full_name" validate:"required"`
 	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// DisabledAt is when the account was disabled for inactivity. A disabled user cannot log in.
+	DisabledAt *time.Time `json:"-"`
+	// RetentionNoticeAt is when the user was last told that their inactive account would be disabled or deleted.
+	RetentionNoticeAt *time.Time `json:"-"`
+}
+
+// LogValue implements slog.LogValuer so that a logged user never exposes its password hash.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.ID.String()),
+		slog.String("email", u.Email),
+	)
 }
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -168,3 +170,19 @@ func TestUser_JSONSerialization(t *testing.T) {
 		assert.Equal(t, user.UpdatedAt.Format(time.RFC3339), unmarshalled.UpdatedAt.Format(time.RFC3339))
 	})
 }
+
+func TestUser_LogValue(t *testing.T) {
+	user := User{
+		ID:           uuid.New(),
+		Email:        testEmail,
+		PasswordHash: testPassword,
+		FullName:     testFullName,
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("user", slog.Any("user", user))
+
+	assert.Contains(t, buf.String(), user.ID.String())
+	assert.Contains(t, buf.String(), testEmail)
+	assert.NotContains(t, buf.String(), testPassword)
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription is an endpoint of another system receiving domain events.
+type WebhookSubscription struct {
+	ID  uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	URL string    `gorm:"type:varchar(2048);not null"`
+	// EventTypes is the comma-separated list of the types of events delivered to the endpoint.
+	EventTypes string `gorm:"type:text;not null"`
+	// Secret is the key of the HMAC-SHA256 signatures of deliveries.
+	Secret    string    `gorm:"type:varchar(255);not null"`
+	Active    bool      `gorm:"not null"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// WebhookDelivery is a domain event to be sent to a webhook subscription.
+// Every subscription receives an event at most once, however often the
+// event itself is delivered.
+type WebhookDelivery struct {
+	ID             uuid.UUID            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SubscriptionID uuid.UUID            `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_event,priority:1;index:idx_webhook_deliveries_subscription_created,priority:1"`
+	Subscription   *WebhookSubscription `gorm:"constraint:OnDelete:CASCADE"`
+	EventID        uuid.UUID            `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	EventType      string               `gorm:"type:varchar(64);not null"`
+	// Payload is the body of the requests.
+	Payload string `gorm:"type:text;not null"`
+	// Status is pending until the endpoint accepted the event, then succeeded, or failed once the attempts are exhausted.
+	Status        string    `gorm:"type:varchar(16);not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts      int       `gorm:"not null"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastError     string    `gorm:"type:text;not null"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP;index:idx_webhook_deliveries_subscription_created,priority:2"`
+	DeliveredAt   *time.Time
+}
+
+// WebhookAttempt logs a request sent for a webhook delivery.
+type WebhookAttempt struct {
+	ID         uuid.UUID        `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	DeliveryID uuid.UUID        `gorm:"type:uuid;not null;index"`
+	Delivery   *WebhookDelivery `gorm:"constraint:OnDelete:CASCADE"`
+	// StatusCode is the status of the response, zero when no response was received.
+	StatusCode int       `gorm:"not null"`
+	Error      string    `gorm:"type:text;not null"`
+	DurationMS int64     `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
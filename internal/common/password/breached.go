@@ -0,0 +1,129 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLength is the number of hex characters of a SHA-1 hash that select a bucket.
+const prefixLength = 5
+
+// BreachedList tells whether a password appears in a list of breached
+// passwords, given as upper-case hex SHA-1 hashes the way Have I Been Pwned
+// publishes them. Hashes are grouped by their first five characters, as in
+// the k-anonymity range API, so only the bucket of the password's prefix is
+// ever searched.
+//
+// The list is either a single file of "HASH" or "HASH:COUNT" lines, loaded
+// into memory, or a directory holding one file per prefix, named "PREFIX" or
+// "PREFIX.txt", of "SUFFIX:COUNT" lines, read on every lookup.
+type BreachedList struct {
+	dir     string
+	buckets map[string]map[string]struct{}
+}
+
+// LoadBreachedList loads the breached password list at path.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load breached password list: %w", err)
+	}
+	if info.IsDir() {
+		return &BreachedList{dir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load breached password list: %w", err)
+	}
+	defer f.Close()
+
+	b := &BreachedList{buckets: make(map[string]map[string]struct{})}
+	err = scanHashes(f, func(hash string) error {
+		if len(hash) != sha1.Size*2 {
+			return fmt.Errorf("invalid hash %q", hash)
+		}
+		prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+		if b.buckets[prefix] == nil {
+			b.buckets[prefix] = make(map[string]struct{})
+		}
+		b.buckets[prefix][suffix] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load breached password list %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// Contains reports whether password appears in the list.
+func (b *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	if b.dir == "" {
+		_, found := b.buckets[prefix][suffix]
+		return found, nil
+	}
+
+	f, err := b.openBucket(prefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	defer f.Close()
+
+	errFound := errors.New("found")
+	err = scanHashes(f, func(s string) error {
+		if s == suffix {
+			return errFound
+		}
+		return nil
+	})
+	if errors.Is(err, errFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return false, nil
+}
+
+// openBucket opens the file holding the hashes starting with prefix.
+func (b *BreachedList) openBucket(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(b.dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(filepath.Join(b.dir, prefix+".txt"))
+	}
+	return f, err
+}
+
+// scanHashes calls fn with the upper-cased hash of every non-empty line of r,
+// stripping an optional ":COUNT" suffix.
+func scanHashes(r io.Reader, fn func(hash string) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if err := fn(strings.ToUpper(line)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,63 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SHA-1 of "password1" is E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D.
+const breachedHash = "E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D"
+
+func TestBreachedList(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(file, []byte(
+		"0000000000000000000000000000000000000000:1\n"+
+			"e38ad214943daad1d64c102faec29de4afe9da3d:2413945\n"), 0o600))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, breachedHash[:5]+".txt"), []byte(
+		"0000000000000000000000000000000000:3\r\n"+
+			breachedHash[5:]+":2413945\r\n"), 0o600))
+
+	for name, path := range map[string]string{"file": file, "directory": dir} {
+		t.Run(name, func(t *testing.T) {
+			list, err := LoadBreachedList(path)
+			require.NoError(t, err)
+
+			found, err := list.Contains("password1")
+			require.NoError(t, err)
+			assert.True(t, found)
+
+			found, err = list.Contains("correct horse battery")
+			require.NoError(t, err)
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestLoadBreachedList_Invalid(t *testing.T) {
+	_, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(file, []byte("not-a-hash\n"), 0o600))
+	_, err = LoadBreachedList(file)
+	assert.ErrorContains(t, err, `invalid hash "NOT-A-HASH"`)
+}
+
+func TestPolicy_CheckBreached(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(file, []byte(breachedHash+"\n"), 0o600))
+
+	policy, err := NewPolicy(&config.Config{PasswordMinLength: 8, PasswordBreachedList: file})
+	require.NoError(t, err)
+
+	var policyErr *PolicyError
+	require.ErrorAs(t, policy.Check("password1"), &policyErr)
+	assert.Equal(t, []Violation{{Rule: RuleBreached, Message: "has appeared in a data breach"}}, policyErr.Violations)
+}
@@ -0,0 +1,201 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hashing algorithms accepted in the configuration.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// Sizes of the salt and key of argon2id hashes, in bytes.
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// ErrUnknownHash is returned when verifying a password against a hash of an unknown algorithm or format.
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+//go:generate mockgen -destination=./hasher_mock.go -package=password github.com/PakornBank/go-backend-example/internal/common/password Hasher
+
+// Hasher defines the methods that a password hasher must implement. Hashes
+// are self-describing, recording the algorithm and its parameters, so that a
+// hasher can verify hashes created with other settings.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hash string) (bool, error)
+	NeedsRehash(hash string) bool
+}
+
+// algorithm is a hashing algorithm that recognizes its own hashes.
+type algorithm interface {
+	Hasher
+	owns(hash string) bool
+}
+
+// hasher hashes with the configured algorithm and verifies hashes of every known algorithm.
+type hasher struct {
+	current    algorithm
+	algorithms []algorithm
+}
+
+// NewHasher creates a new Hasher that hashes with the algorithm and parameters of the provided configuration.
+func NewHasher(cfg *config.Config) Hasher {
+	bcryptAlgorithm := &bcryptHasher{cost: cfg.PasswordBcryptCost}
+	argon2Algorithm := &argon2idHasher{params: argon2Params{
+		memory:      uint32(cfg.PasswordArgon2Memory),
+		iterations:  uint32(cfg.PasswordArgon2Iterations),
+		parallelism: uint8(cfg.PasswordArgon2Parallelism),
+	}}
+
+	h := &hasher{algorithms: []algorithm{bcryptAlgorithm, argon2Algorithm}}
+	if cfg.PasswordHashAlgorithm == AlgorithmBcrypt {
+		h.current = bcryptAlgorithm
+	} else {
+		h.current = argon2Algorithm
+	}
+	return h
+}
+
+// Hash returns the hash of password created with the configured algorithm.
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify reports whether password matches hash, whatever algorithm created it.
+func (h *hasher) Verify(password, hash string) (bool, error) {
+	for _, a := range h.algorithms {
+		if a.owns(hash) {
+			return a.Verify(password, hash)
+		}
+	}
+	return false, ErrUnknownHash
+}
+
+// NeedsRehash reports whether hash was created with another algorithm or
+// weaker parameters than the configured ones.
+func (h *hasher) NeedsRehash(hash string) bool {
+	return !h.current.owns(hash) || h.current.NeedsRehash(hash)
+}
+
+// bcryptHasher hashes passwords with bcrypt, in its own $2a$ format.
+type bcryptHasher struct {
+	cost int
+}
+
+// Hash returns the bcrypt hash of password.
+func (b *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether password matches the bcrypt hash.
+func (b *bcryptHasher) Verify(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NeedsRehash reports whether hash has a lower cost than the configured one.
+func (b *bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < b.cost
+}
+
+// owns reports whether hash is a bcrypt hash.
+func (b *bcryptHasher) owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// argon2Params are the cost parameters of argon2id.
+type argon2Params struct {
+	memory      uint32 // KiB
+	iterations  uint32
+	parallelism uint8
+}
+
+// argon2idHasher hashes passwords with argon2id in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type argon2idHasher struct {
+	params argon2Params
+}
+
+// Hash returns the argon2id hash of password with a random salt.
+func (a *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := a.params
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches the argon2id hash, using the parameters recorded in it.
+func (a *argon2idHasher) Verify(password, hash string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether any parameter of hash is weaker than the configured one.
+func (a *argon2idHasher) NeedsRehash(hash string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return p.memory < a.params.memory || p.iterations < a.params.iterations ||
+		p.parallelism < a.params.parallelism || len(salt) < argon2SaltLength || len(key) < argon2KeyLength
+}
+
+// owns reports whether hash is an argon2id hash.
+func (a *argon2idHasher) owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// decodeArgon2id parses an argon2id hash in the PHC string format.
+func decodeArgon2id(hash string) (p argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism)
+	if err != nil || p.iterations < 1 || p.parallelism < 1 {
+		return p, nil, nil, fmt.Errorf("invalid argon2 parameters %q", parts[3])
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(salt) == 0 {
+		return p, nil, nil, fmt.Errorf("invalid argon2 salt %q", parts[4])
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return p, nil, nil, fmt.Errorf("invalid argon2 key %q", parts[5])
+	}
+	return p, salt, key, nil
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func hasherConfig(algorithm string) *config.Config {
+	return &config.Config{
+		PasswordHashAlgorithm:     algorithm,
+		PasswordBcryptCost:        bcrypt.MinCost,
+		PasswordArgon2Memory:      64,
+		PasswordArgon2Iterations:  1,
+		PasswordArgon2Parallelism: 1,
+	}
+}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	tests := []struct {
+		algorithm  string
+		wantPrefix string
+	}{
+		{algorithm: AlgorithmBcrypt, wantPrefix: "$2a$04$"},
+		{algorithm: AlgorithmArgon2id, wantPrefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h := NewHasher(hasherConfig(tt.algorithm))
+
+			hash, err := h.Hash("password")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.wantPrefix), hash)
+			assert.False(t, h.NeedsRehash(hash))
+
+			ok, err := h.Verify("password", hash)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = h.Verify("wrong password", hash)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			other, err := h.Hash("password")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "hashes must be salted")
+		})
+	}
+}
+
+func TestHasher_VerifiesOtherAlgorithms(t *testing.T) {
+	bcryptHash, err := NewHasher(hasherConfig(AlgorithmBcrypt)).Hash("password")
+	require.NoError(t, err)
+
+	h := NewHasher(hasherConfig(AlgorithmArgon2id))
+	ok, err := h.Verify("password", bcryptHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash(bcryptHash))
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	weak, err := NewHasher(hasherConfig(AlgorithmArgon2id)).Hash("password")
+	require.NoError(t, err)
+	weakBcrypt, err := NewHasher(hasherConfig(AlgorithmBcrypt)).Hash("password")
+	require.NoError(t, err)
+
+	stronger := func(modify func(*config.Config)) Hasher {
+		cfg := hasherConfig(AlgorithmArgon2id)
+		modify(cfg)
+		return NewHasher(cfg)
+	}
+
+	assert.True(t, stronger(func(c *config.Config) { c.PasswordArgon2Memory = 128 }).NeedsRehash(weak))
+	assert.True(t, stronger(func(c *config.Config) { c.PasswordArgon2Iterations = 2 }).NeedsRehash(weak))
+	assert.True(t, stronger(func(c *config.Config) { c.PasswordArgon2Parallelism = 2 }).NeedsRehash(weak))
+	assert.False(t, stronger(func(c *config.Config) { c.PasswordArgon2Memory = 32 }).NeedsRehash(weak))
+
+	bcryptHasher := NewHasher(&config.Config{PasswordHashAlgorithm: AlgorithmBcrypt, PasswordBcryptCost: bcrypt.MinCost + 1})
+	assert.True(t, bcryptHasher.NeedsRehash(weakBcrypt))
+	assert.True(t, bcryptHasher.NeedsRehash(weak))
+}
+
+func TestHasher_VerifyInvalidHash(t *testing.T) {
+	h := NewHasher(hasherConfig(AlgorithmArgon2id))
+
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+	} {
+		ok, err := h.Verify("password", hash)
+		assert.Error(t, err, hash)
+		assert.False(t, ok, hash)
+		assert.True(t, h.NeedsRehash(hash), hash)
+	}
+}
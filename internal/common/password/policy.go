@@ -0,0 +1,238 @@
+// Package password provides the password policy applied whenever a user
+// chooses a password.
+package password
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+)
+
+// Rules reported in Violation.Rule.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxBytes  = "max_bytes"
+	RuleUpper     = "upper"
+	RuleLower     = "lower"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RulePersonal  = "personal"
+	RuleEntropy   = "entropy"
+	RuleBreached  = "breached"
+	RuleReused    = "reused"
+)
+
+// minPersonalLength is the shortest part of an email address or name that a password may not contain.
+const minPersonalLength = 3
+
+// Violation describes a rule a password does not satisfy.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError is returned for passwords that violate the policy. It lists every violated rule.
+type PolicyError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// Policy checks passwords against the configured rules.
+type Policy struct {
+	minLength        int
+	maxBytes         int
+	requireUpper     bool
+	requireLower     bool
+	requireDigit     bool
+	requireSymbol    bool
+	disallowPersonal bool
+	minEntropy       float64
+	breached         *BreachedList
+}
+
+// NewPolicy creates a new Policy configured from the provided configuration,
+// loading the breached password list when one is configured.
+func NewPolicy(cfg *config.Config) (*Policy, error) {
+	p := &Policy{
+		minLength:        cfg.PasswordMinLength,
+		maxBytes:         cfg.PasswordMaxBytes,
+		requireUpper:     cfg.PasswordRequireUpper,
+		requireLower:     cfg.PasswordRequireLower,
+		requireDigit:     cfg.PasswordRequireDigit,
+		requireSymbol:    cfg.PasswordRequireSymbol,
+		disallowPersonal: cfg.PasswordDisallowPersonal,
+		minEntropy:       float64(cfg.PasswordMinEntropy),
+	}
+
+	if cfg.PasswordBreachedList != "" {
+		breached, err := LoadBreachedList(cfg.PasswordBreachedList)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = breached
+	}
+
+	return p, nil
+}
+
+// Check returns a *PolicyError listing every rule the password violates, or
+// nil when it satisfies the policy. personal holds the email address and
+// name of the user, which the password may not contain. Other errors are
+// returned when the breached password list cannot be read.
+func (p *Policy) Check(password string, personal ...string) error {
+	var violations []Violation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.minLength {
+		add(RuleMinLength, "must be at least %d characters long", p.minLength)
+	}
+	if p.maxBytes > 0 && len(password) > p.maxBytes {
+		add(RuleMaxBytes, "must be at most %d bytes long", p.maxBytes)
+	}
+
+	classes := classify(password)
+	if p.requireUpper && !classes.upper {
+		add(RuleUpper, "must contain an upper case letter")
+	}
+	if p.requireLower && !classes.lower {
+		add(RuleLower, "must contain a lower case letter")
+	}
+	if p.requireDigit && !classes.digit {
+		add(RuleDigit, "must contain a digit")
+	}
+	if p.requireSymbol && !classes.symbol {
+		add(RuleSymbol, "must contain a symbol")
+	}
+
+	if p.disallowPersonal && containsPersonal(password, personal) {
+		add(RulePersonal, "must not contain your email address or name")
+	}
+
+	if p.minEntropy > 0 && entropy(password, classes) < p.minEntropy {
+		add(RuleEntropy, "is too easy to guess")
+	}
+
+	if p.breached != nil {
+		found, err := p.breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if found {
+			add(RuleBreached, "has appeared in a data breach")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// characterClasses records which classes of characters a password contains.
+type characterClasses struct {
+	upper, lower, digit, symbol, other bool
+}
+
+// classify returns the character classes used in password.
+func classify(password string) characterClasses {
+	var c characterClasses
+	for _, r := range password {
+		switch {
+		case r <= unicode.MaxASCII && unicode.IsUpper(r):
+			c.upper = true
+		case r <= unicode.MaxASCII && unicode.IsLower(r):
+			c.lower = true
+		case r <= unicode.MaxASCII && unicode.IsDigit(r):
+			c.digit = true
+		case r <= unicode.MaxASCII && (unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' '):
+			c.symbol = true
+		case unicode.IsUpper(r):
+			c.upper = true
+		case unicode.IsLower(r):
+			c.lower = true
+		default:
+			c.other = true
+		}
+	}
+	return c
+}
+
+// entropy estimates the entropy of password in bits from the size of the
+// character pool it draws from. Characters repeating the previous one or
+// continuing a run such as "abc" or "321" add nothing.
+func entropy(password string, c characterClasses) float64 {
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{
+		{c.lower, 26},
+		{c.upper, 26},
+		{c.digit, 10},
+		{c.symbol, 33},
+		{c.other, 100},
+	} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	effective := 0
+	var prev rune
+	step := rune(0)
+	for i, r := range []rune(password) {
+		if i > 0 {
+			d := r - prev
+			if d == 0 || ((d == 1 || d == -1) && d == step) {
+				prev, step = r, d
+				continue
+			}
+			step = d
+		}
+		prev = r
+		effective++
+	}
+
+	return float64(effective) * math.Log2(float64(pool))
+}
+
+// containsPersonal reports whether password contains, ignoring case, one of
+// the personal values or a word of them. Only the local part of an email
+// address is considered, the domain is usually shared with other users.
+func containsPersonal(password string, personal []string) bool {
+	lower := strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if at := strings.LastIndex(value, "@"); at >= 0 {
+			value = value[:at]
+		}
+		parts := []string{value}
+		parts = append(parts, strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minPersonalLength && strings.Contains(lower, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
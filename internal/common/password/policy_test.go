@@ -0,0 +1,111 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	base := config.Config{
+		PasswordMinLength:        8,
+		PasswordMaxBytes:         72,
+		PasswordDisallowPersonal: true,
+		PasswordMinEntropy:       30,
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(*config.Config)
+		password  string
+		personal  []string
+		wantRules []string
+	}{
+		{
+			name:     "valid password",
+			password: "correct horse battery",
+		},
+		{
+			name:      "too short",
+			password:  "k9#Lm2",
+			wantRules: []string{RuleMinLength},
+		},
+		{
+			name:      "longer than bcrypt accepts",
+			password:  "correct horse battery staple correct horse battery staple correct horse battery",
+			wantRules: []string{RuleMaxBytes},
+		},
+		{
+			name: "missing character classes",
+			modify: func(c *config.Config) {
+				c.PasswordRequireUpper = true
+				c.PasswordRequireLower = true
+				c.PasswordRequireDigit = true
+				c.PasswordRequireSymbol = true
+			},
+			password:  "correcthorsebattery",
+			wantRules: []string{RuleUpper, RuleDigit, RuleSymbol},
+		},
+		{
+			name:      "contains email local part",
+			password:  "xJohnDoe42x",
+			personal:  []string{"john.doe42@example.com", "Someone Else"},
+			wantRules: []string{RulePersonal},
+		},
+		{
+			name:      "contains part of the name",
+			password:  "Kowalski-1990",
+			personal:  []string{"jan@example.com", "Jan Kowalski"},
+			wantRules: []string{RulePersonal},
+		},
+		{
+			name:     "email domain is allowed",
+			password: "example-horse-battery",
+			personal: []string{"jan@example.com"},
+		},
+		{
+			name:      "repeated characters",
+			password:  "aaaaaaaaaaaa",
+			wantRules: []string{RuleEntropy},
+		},
+		{
+			name:      "sequence",
+			password:  "123456789012",
+			wantRules: []string{RuleEntropy},
+		},
+		{
+			name:      "entropy check disabled",
+			modify:    func(c *config.Config) { c.PasswordMinEntropy = 0 },
+			password:  "aaaaaaaaaaaa",
+			wantRules: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			policy, err := NewPolicy(&cfg)
+			require.NoError(t, err)
+
+			err = policy.Check(tt.password, tt.personal...)
+			if len(tt.wantRules) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			var rules []string
+			for _, v := range policyErr.Violations {
+				assert.NotEmpty(t, v.Message)
+				rules = append(rules, v.Rule)
+			}
+			assert.Equal(t, tt.wantRules, rules)
+		})
+	}
+}
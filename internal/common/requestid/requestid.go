@@ -0,0 +1,53 @@
+// Package requestid provides helpers for carrying a request correlation ID.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header used to receive and echo the request ID.
+const Header = "X-Request-ID"
+
+// maxLength is the longest client-supplied request ID that is accepted.
+const maxLength = 128
+
+// ctxKey is the context key type for the request ID.
+type ctxKey struct{}
+
+// New generates a new random request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether id is safe to accept from a client, allowing only
+// letters, digits and the characters '-', '_', '.' and ':'.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithContext returns a copy of ctx carrying the provided request ID.
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
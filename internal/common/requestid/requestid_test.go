@@ -0,0 +1,42 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	id := New()
+	assert.True(t, Valid(id))
+	assert.NotEqual(t, id, New())
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "uuid", id: "3f2b8c1e-8d4a-4b8e-9c7a-1d2e3f4a5b6c", want: true},
+		{name: "allowed punctuation", id: "trace_1.2:3", want: true},
+		{name: "empty", id: "", want: false},
+		{name: "too long", id: strings.Repeat("a", maxLength+1), want: false},
+		{name: "comment terminator", id: "abc*/ DROP TABLE users", want: false},
+		{name: "newline", id: "abc\ndef", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Valid(tt.id))
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := WithContext(context.Background(), "req-123")
+	assert.Equal(t, "req-123", FromContext(ctx))
+	assert.Empty(t, FromContext(context.Background()))
+}
@@ -0,0 +1,26 @@
+// Package response provides helpers for writing consistent HTTP responses.
+package response
+
+import (
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// Error writes a JSON error body with the given status code, including the
+// request ID so clients can quote it when reporting problems.
+func Error(c *gin.Context, code int, message string) {
+	ErrorWithDetails(c, code, message, nil)
+}
+
+// ErrorWithDetails writes a JSON error body like Error, adding details
+// describing the problem under the "details" key when they are not nil.
+func ErrorWithDetails(c *gin.Context, code int, message string, details interface{}) {
+	body := gin.H{"error": message}
+	if details != nil {
+		body["details"] = details
+	}
+	if id := requestid.FromContext(c.Request.Context()); id != "" {
+		body["request_id"] = id
+	}
+	c.JSON(code, body)
+}
@@ -0,0 +1,67 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		wantBody  map[string]interface{}
+	}{
+		{
+			name:      "with request ID",
+			requestID: "req-123",
+			wantBody:  map[string]interface{}{"error": "bad things", "request_id": "req-123"},
+		},
+		{
+			name:     "without request ID",
+			wantBody: map[string]interface{}{"error": "bad things"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				c.Request = c.Request.WithContext(requestid.WithContext(c.Request.Context(), tt.requestID))
+			}
+
+			Error(c, http.StatusBadRequest, "bad things")
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantBody, got)
+		})
+	}
+}
+
+func TestErrorWithDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	ErrorWithDetails(c, http.StatusBadRequest, "bad things", []string{"first", "second"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, map[string]interface{}{
+		"error":   "bad things",
+		"details": []interface{}{"first", "second"},
+	}, got)
+}
@@ -0,0 +1,55 @@
+// Package worker provides the pieces shared by the background workers of the
+// application: the polling loop, the retry backoff and panic recovery.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+)
+
+// Poll calls fn until ctx is done. fn reports whether more work is due, in
+// which case it is called again at once, and otherwise after interval, which
+// is read on every wait so that a reloaded configuration takes effect. Errors
+// are logged with msg, unless ctx is done.
+func Poll(ctx context.Context, interval func() time.Duration, msg string, fn func(ctx context.Context) (bool, error)) {
+	log := logger.FromContext(ctx)
+	for {
+		more, err := fn(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error(msg, slog.Any("error", err))
+		}
+
+		if err == nil && more && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval()):
+		}
+	}
+}
+
+// Backoff returns the delay before the attempt following the given one: base
+// after the first attempt, doubled after every further one, up to limit.
+func Backoff(base, limit time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// Call runs fn, turning a panic into an error.
+func Call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
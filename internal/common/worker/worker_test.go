@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Poll(ctx, func() time.Duration { return time.Millisecond }, "failed", func(context.Context) (bool, error) {
+			calls++
+			switch calls {
+			case 1:
+				// More work is due, called again at once.
+				return true, nil
+			case 2:
+				// Errors wait for the interval whatever the result.
+				return true, errors.New("boom")
+			case 3:
+				return false, nil
+			default:
+				cancel()
+				return true, nil
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll did not return after its context was cancelled")
+	}
+	assert.Equal(t, 4, calls)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 7, want: time.Minute},
+		{attempt: 1000, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Backoff(time.Second, time.Minute, tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+func TestCall(t *testing.T) {
+	assert.NoError(t, Call(context.Background(), func(context.Context) error { return nil }))
+	assert.EqualError(t, Call(context.Background(), func(context.Context) error { return errors.New("boom") }), "boom")
+	assert.EqualError(t, Call(context.Background(), func(context.Context) error { panic("oops") }), "panic: oops")
+}
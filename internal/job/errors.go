@@ -0,0 +1,15 @@
+package job
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when no job has the given ID.
+	ErrNotFound = errors.New("job not found")
+	// ErrDuplicate is returned when enqueuing a job whose unique key is held by another pending job.
+	ErrDuplicate = errors.New("a pending job has the same unique key")
+	// ErrNotFailed is returned when retrying a job that has not failed.
+	ErrNotFailed = errors.New("only failed jobs can be retried")
+	// ErrLeaseLost is returned when recording the outcome of an attempt of a job
+	// that has been claimed again since, once the lease of the attempt expired.
+	ErrLeaseLost = errors.New("job was claimed again")
+)
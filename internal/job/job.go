@@ -0,0 +1,61 @@
+// Package job runs background work off the request path. Jobs are stored in
+// the jobs table, so they survive restarts and can be enqueued in the
+// transaction of the change that calls for them, and are run by a Runner in
+// every instance with retries and an exponential backoff.
+package job
+
+import (
+	"errors"
+	"time"
+)
+
+// Statuses of jobs.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Options adjust how an enqueued job runs. Zero fields take the defaults.
+type Options struct {
+	// RunAt is when the job is due, now by default.
+	RunAt time.Time
+	// UniqueKey makes Enqueue return ErrDuplicate while another pending job holds the same key.
+	UniqueKey string
+	// MaxAttempts is how often running the job is attempted before it fails, JobMaxAttempts by default.
+	MaxAttempts int
+}
+
+// Filter selects jobs. Zero fields match every job.
+type Filter struct {
+	Type   string
+	Status string
+	// Limit is the maximum number of jobs returned.
+	Limit int
+}
+
+// Stat is the number of jobs of a type in a status.
+type Stat struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// permanentError marks an error that retrying the job cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err, returned by a handler, so that the job fails at once instead of being retried.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// isPermanent reports whether err was wrapped by Permanent.
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,34 @@
+package job
+
+import (
+	"context"
+
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+)
+
+// TypeSendMail is the type of jobs sending a mail.Message.
+const TypeSendMail = "mail.send"
+
+// mailSender is a mail.Sender that queues messages instead of sending them.
+type mailSender struct {
+	jobs Service
+}
+
+// NewMailSender creates a new mail.Sender that enqueues a TypeSendMail job for
+// every message, so that sending is retried when the mail server fails. The
+// jobs are run by a handler registered with RegisterMail.
+func NewMailSender(jobs Service) mail.Sender {
+	return &mailSender{jobs: jobs}
+}
+
+// Send enqueues a job sending msg.
+func (s *mailSender) Send(ctx context.Context, msg mail.Message) error {
+	_, err := s.jobs.Enqueue(ctx, TypeSendMail, msg, Options{})
+	return err
+}
+
+// RegisterMail registers on r the handler of TypeSendMail jobs, which sends
+// their message through sender.
+func RegisterMail(r *Runner, sender mail.Sender) {
+	Handle(r, TypeSendMail, sender.Send)
+}
@@ -0,0 +1,33 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMailSender_Send(t *testing.T) {
+	mockService := NewMockService(gomock.NewController(t))
+	msg := mail.Message{To: "user@example.com", Subject: "Hello", Body: "Hi"}
+	mockService.EXPECT().Enqueue(gomock.Any(), TypeSendMail, msg, Options{}).Return(&model.Job{}, nil)
+
+	assert.NoError(t, NewMailSender(mockService).Send(context.Background(), msg))
+}
+
+func TestRegisterMail(t *testing.T) {
+	runner, _ := setupRunnerTest(t)
+	mockSender := mail.NewMockSender(gomock.NewController(t))
+	msg := mail.Message{To: "user@example.com", Subject: "Hello", Body: "Hi"}
+	mockSender.EXPECT().Send(gomock.Any(), msg).Return(nil)
+
+	RegisterMail(runner, mockSender)
+	payload, err := json.Marshal(msg)
+	assert.NoError(t, err)
+
+	assert.NoError(t, runner.handlers[TypeSendMail](context.Background(), payload))
+}
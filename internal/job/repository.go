@@ -0,0 +1,246 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// uniqueKeyIndex is the partial unique index on the key of pending jobs.
+const uniqueKeyIndex = "idx_jobs_unique_key"
+
+// claimSQL leases the oldest due pending job by pushing back when it is due,
+// skipping jobs leased by other workers.
+const claimSQL = `
+UPDATE jobs SET attempts = attempts + 1, run_at = ?
+WHERE id = (
+	SELECT id FROM jobs
+	WHERE status = ? AND run_at <= ?
+	ORDER BY run_at
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+//go:generate mockgen -destination=./repository_mock.go -package=job github.com/PakornBank/go-backend-example/internal/job Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	Create(ctx context.Context, job *model.Job) error
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.Job, error)
+	MarkSucceeded(ctx context.Context, id string, attempt int) error
+	MarkFailed(ctx context.Context, id string, attempt int, lastError string, runAt time.Time, failed bool) error
+	Find(ctx context.Context, id string) (*model.Job, error)
+	List(ctx context.Context, filter Filter) ([]model.Job, error)
+	Stats(ctx context.Context) ([]Stat, error)
+	Retry(ctx context.Context, id string, now time.Time) error
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// repository is a struct that provides methods to interact with the jobs in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// Create inserts a new job record into the database. It returns ErrDuplicate,
+// inserting nothing, when the unique key of job is held by a pending job.
+func (r *repository) Create(ctx context.Context, job *model.Job) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "unique_key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'pending'"}}},
+		DoNothing:   true,
+	}).Create(job)
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to create job", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+
+	return nil
+}
+
+// Claim leases the oldest pending job due at now and counts the attempt, or
+// returns nil when no job is due. A leased job is not claimed again before
+// the lease expires, so a job whose worker dies is run again once it does.
+func (r *repository) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var jobs []model.Job
+
+	err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).
+		Raw(claimSQL, now.Add(lease), StatusPending, now).Scan(&jobs).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to claim job", slog.Any("error", err))
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	return &jobs[0], nil
+}
+
+// MarkSucceeded marks the job with the given ID as succeeded after the given
+// attempt. It returns ErrLeaseLost, changing nothing, when the job has been
+// claimed again since.
+func (r *repository) MarkSucceeded(ctx context.Context, id string, attempt int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.Job{}).Where("id = ? AND attempts = ?", id, attempt).
+		Updates(map[string]interface{}{"status": StatusSucceeded, "last_error": ""})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to mark job succeeded", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// MarkFailed records the error of the given attempt of the job with the given
+// ID and schedules the next one at runAt, or gives up on the job when failed
+// is set. It returns ErrLeaseLost, changing nothing, when the job has been
+// claimed again since.
+func (r *repository) MarkFailed(ctx context.Context, id string, attempt int, lastError string, runAt time.Time, failed bool) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	status := StatusPending
+	if failed {
+		status = StatusFailed
+	}
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.Job{}).Where("id = ? AND attempts = ?", id, attempt).
+		Updates(map[string]interface{}{"status": status, "run_at": runAt, "last_error": lastError})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to mark job failed", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Find retrieves the job with the given ID.
+func (r *repository) Find(ctx context.Context, id string) (*model.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var job model.Job
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find job", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// List retrieves the jobs matching filter, most recently updated first, at most filter.Limit of them.
+func (r *repository) List(ctx context.Context, filter Filter) ([]model.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var jobs []model.Job
+
+	query := database.Conn(ctx, r.db).WithContext(ctx)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if err := query.Order("updated_at DESC").Limit(filter.Limit).Find(&jobs).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to list jobs", slog.Any("error", err))
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Stats counts the jobs by type and status.
+func (r *repository) Stats(ctx context.Context) ([]Stat, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var stats []Stat
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.Job{}).
+		Select("type, status, count(*) AS count").Group("type, status").Order("type, status").Scan(&stats).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to count jobs", slog.Any("error", err))
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Retry schedules the failed job with the given ID to run again at now, with
+// a fresh count of attempts. It returns ErrNotFound when there is no such
+// failed job and ErrDuplicate when a pending job holds its unique key.
+func (r *repository) Retry(ctx context.Context, id string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.Job{}).
+		Where("id = ? AND status = ?", id, StatusFailed).
+		Updates(map[string]interface{}{"status": StatusPending, "attempts": 0, "run_at": now})
+	if database.IsUniqueViolation(result.Error, uniqueKeyIndex) {
+		return ErrDuplicate
+	}
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to retry job", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteFinished removes the jobs that succeeded or failed before the given
+// time and returns how many.
+func (r *repository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", []string{StatusSucceeded, StatusFailed}, before).
+		Delete(&model.Job{})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to delete finished jobs", slog.Any("error", result.Error))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,406 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	jobRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, jobRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	jobRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, jobRepo)
+	assert.Equal(t, gormDB, jobRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, jobRepo.(*repository).timeout)
+}
+
+func Test_repository_Create(t *testing.T) {
+	now := time.Now()
+	key := "export:1"
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "job created",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "jobs" .* ON CONFLICT \("unique_key"\) WHERE status = 'pending' DO NOTHING RETURNING`).
+					WithArgs("mail.send", `{}`, StatusPending, 0, 5, now, &key, "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(uuid.New(), now, now))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "unique key held by a pending job",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}))
+				sqlMock.ExpectCommit()
+			},
+			errType: ErrDuplicate,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "jobs"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			job := &model.Job{Type: "mail.send", Payload: `{}`, Status: StatusPending, MaxAttempts: 5, RunAt: now, UniqueKey: &key}
+			err := jobRepo.Create(context.Background(), job)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, uuid.Nil, job.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Claim(t *testing.T) {
+	now := time.Now()
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		want    *model.Job
+		errType error
+	}{
+		{
+			name: "job claimed",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`UPDATE jobs SET attempts = attempts \+ 1, run_at = \$1\s+WHERE id = \(\s+SELECT id FROM jobs\s+WHERE status = \$2 AND run_at <= \$3\s+ORDER BY run_at\s+LIMIT 1\s+FOR UPDATE SKIP LOCKED\s+\)\s+RETURNING \*`).
+					WithArgs(now.Add(time.Minute), StatusPending, now).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "type", "attempts"}).AddRow(id, "mail.send", 1))
+			},
+			want: &model.Job{ID: id, Type: "mail.send", Attempts: 1},
+		},
+		{
+			name: "no job due",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`UPDATE jobs`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`UPDATE jobs`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := jobRepo.Claim(context.Background(), now, time.Minute)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkSucceeded(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		rows    int64
+		errType error
+	}{
+		{name: "attempt is current", rows: 1},
+		{name: "job claimed again", rows: 0, errType: ErrLeaseLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec(`UPDATE "jobs" SET "last_error"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4 AND attempts = \$5`).
+				WithArgs("", StatusSucceeded, sqlmock.AnyArg(), id, 2).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			sqlMock.ExpectCommit()
+
+			err := jobRepo.MarkSucceeded(context.Background(), id, 2)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkFailed(t *testing.T) {
+	id := uuid.New().String()
+	runAt := time.Now()
+
+	tests := []struct {
+		name       string
+		failed     bool
+		rows       int64
+		wantStatus string
+		errType    error
+	}{
+		{name: "retried", failed: false, rows: 1, wantStatus: StatusPending},
+		{name: "failed", failed: true, rows: 1, wantStatus: StatusFailed},
+		{name: "job claimed again", failed: false, rows: 0, wantStatus: StatusPending, errType: ErrLeaseLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec(`UPDATE "jobs" SET "last_error"=\$1,"run_at"=\$2,"status"=\$3,"updated_at"=\$4 WHERE id = \$5 AND attempts = \$6`).
+				WithArgs("boom", runAt, tt.wantStatus, sqlmock.AnyArg(), id, 2).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			sqlMock.ExpectCommit()
+
+			err := jobRepo.MarkFailed(context.Background(), id, 2, "boom", runAt, tt.failed)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Find(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "job found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "jobs" WHERE id = \$1 ORDER BY "jobs"."id" LIMIT \$2`).
+					WithArgs(id.String(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(id, "mail.send"))
+			},
+		},
+		{
+			name: "job not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			errType: ErrNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "jobs"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := jobRepo.Find(context.Background(), id.String())
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, id, got.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_List(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		mockFn func(sqlmock.Sqlmock)
+	}{
+		{
+			name:   "every job",
+			filter: Filter{Limit: 50},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "jobs" ORDER BY updated_at DESC LIMIT \$1`).
+					WithArgs(50).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+			},
+		},
+		{
+			name:   "filtered by type and status",
+			filter: Filter{Type: "mail.send", Status: StatusFailed, Limit: 10},
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "jobs" WHERE type = \$1 AND status = \$2 ORDER BY updated_at DESC LIMIT \$3`).
+					WithArgs("mail.send", StatusFailed, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := jobRepo.List(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Stats(t *testing.T) {
+	sqlMock, jobRepo := setupRepositoryTest(t)
+
+	sqlMock.ExpectQuery(`SELECT type, status, count\(\*\) AS count FROM "jobs" GROUP BY type, status ORDER BY type, status`).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "status", "count"}).
+			AddRow("mail.send", StatusFailed, 2).
+			AddRow("mail.send", StatusSucceeded, 40))
+
+	got, err := jobRepo.Stats(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Stat{
+		{Type: "mail.send", Status: StatusFailed, Count: 2},
+		{Type: "mail.send", Status: StatusSucceeded, Count: 40},
+	}, got)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_Retry(t *testing.T) {
+	id := uuid.New().String()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(*sqlmock.ExpectedExec)
+		errType error
+	}{
+		{
+			name:   "job rescheduled",
+			mockFn: func(e *sqlmock.ExpectedExec) { e.WillReturnResult(sqlmock.NewResult(0, 1)) },
+		},
+		{
+			name:    "no such failed job",
+			mockFn:  func(e *sqlmock.ExpectedExec) { e.WillReturnResult(sqlmock.NewResult(0, 0)) },
+			errType: ErrNotFound,
+		},
+		{
+			name: "unique key held by a pending job",
+			mockFn: func(e *sqlmock.ExpectedExec) {
+				e.WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: uniqueKeyIndex})
+			},
+			errType: ErrDuplicate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			sqlMock.ExpectBegin()
+			tt.mockFn(sqlMock.ExpectExec(`UPDATE "jobs" SET "attempts"=\$1,"run_at"=\$2,"status"=\$3,"updated_at"=\$4 WHERE id = \$5 AND status = \$6`).
+				WithArgs(0, now, StatusPending, sqlmock.AnyArg(), id, StatusFailed))
+			if tt.errType == ErrDuplicate {
+				sqlMock.ExpectRollback()
+			} else {
+				sqlMock.ExpectCommit()
+			}
+
+			err := jobRepo.Retry(context.Background(), id, now)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_DeleteFinished(t *testing.T) {
+	before := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		want    int64
+		errType error
+	}{
+		{
+			name: "jobs deleted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "jobs" WHERE status IN \(\$1,\$2\) AND updated_at < \$3`).
+					WithArgs(StatusSucceeded, StatusFailed, before).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				sqlMock.ExpectCommit()
+			},
+			want: 4,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "jobs"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, jobRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := jobRepo.DeleteFinished(context.Background(), before)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/common/worker"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Handler runs a job with its JSON payload. Jobs are run at least once, so
+// handlers must be idempotent. A handler returning an error wrapped by
+// Permanent is not retried.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Handle registers fn on r for jobs of the given type, decoding their payload
+// into a T. A payload that cannot be decoded fails the job at once.
+func Handle[T any](r *Runner, jobType string, fn func(ctx context.Context, payload T) error) {
+	r.Register(jobType, func(ctx context.Context, payload json.RawMessage) error {
+		var v T
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return fn(ctx, v)
+	})
+}
+
+// Runner runs the pending jobs with the handlers registered for their type.
+// A job is run again until its handler succeeds, with an exponential backoff
+// between attempts, and fails once the attempts are exhausted. Several
+// runners, in the same or other processes, may run at once: every job is
+// leased to one worker at a time.
+type Runner struct {
+	repository Repository
+	config     *config.Store
+	metrics    *metrics.Metrics
+	handlers   map[string]Handler
+	now        func() time.Time
+}
+
+// NewRunner creates a new Runner with the provided repository, configuration and metrics.
+func NewRunner(repository Repository, config *config.Store, m *metrics.Metrics) *Runner {
+	return &Runner{
+		repository: repository,
+		config:     config,
+		metrics:    m,
+		handlers:   map[string]Handler{},
+		now:        time.Now,
+	}
+}
+
+// Register sets handler as the handler of jobs of the given type. It must
+// not be called once Run has started.
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.handlers[jobType] = handler
+}
+
+// Run starts the configured number of workers, which run due jobs until ctx
+// is done, checking for them every poll interval, or at once while jobs are
+// due. Jobs being run when ctx is done are finished before Run returns.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.config.Get().JobConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work is the loop of one worker of Run.
+func (r *Runner) work(ctx context.Context) {
+	interval := func() time.Duration { return r.config.Get().JobPollInterval }
+	worker.Poll(ctx, interval, "failed to claim job", r.RunNext)
+}
+
+// RunNext claims the oldest due job and runs it, and reports whether there
+// was one. The job is leased for JobLease, so that it is run again when its
+// worker dies, and its handler is bounded by the shorter JobTimeout.
+func (r *Runner) RunNext(ctx context.Context) (bool, error) {
+	cfg := r.config.Get()
+
+	job, err := r.repository.Claim(ctx, r.now(), cfg.JobLease)
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	// The claimed job is leased to this worker, finish it even when ctx is cancelled.
+	r.run(context.WithoutCancel(ctx), job, cfg)
+	return true, nil
+}
+
+// run passes the job to the handler registered for its type and records the outcome.
+func (r *Runner) run(ctx context.Context, job *model.Job, cfg *config.Config) {
+	ctx, span := tracing.Start(ctx, "job.Runner.run")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("job.id", job.ID.String()),
+		attribute.String("job.type", job.Type),
+		attribute.Int("job.attempt", job.Attempts),
+	)
+
+	log := logger.FromContext(ctx).With(
+		slog.String("job_id", job.ID.String()),
+		slog.String("job_type", job.Type),
+		slog.Int("attempt", job.Attempts),
+	)
+
+	var err error
+	handler, ok := r.handlers[job.Type]
+	if !ok {
+		err = Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	} else {
+		handlerCtx, cancel := context.WithTimeout(ctx, cfg.JobTimeout)
+		err = worker.Call(handlerCtx, func(ctx context.Context) error {
+			return handler(ctx, json.RawMessage(job.Payload))
+		})
+		cancel()
+	}
+
+	now := r.now()
+	if err != nil {
+		tracing.RecordError(span, err)
+		failed := isPermanent(err) || job.Attempts >= job.MaxAttempts
+		runAt := now.Add(worker.Backoff(cfg.JobRetryBackoff, cfg.JobMaxBackoff, job.Attempts))
+		if markErr := r.repository.MarkFailed(ctx, job.ID.String(), job.Attempts, err.Error(), runAt, failed); markErr != nil {
+			r.leaseLost(log, markErr)
+			return
+		}
+		if failed {
+			log.Error("job failed", slog.Any("error", err))
+			r.metrics.ObserveJob(job.Type, metrics.JobFailed)
+		} else {
+			log.Warn("job attempt failed", slog.Any("error", err))
+			r.metrics.ObserveJob(job.Type, metrics.JobRetried)
+		}
+		return
+	}
+
+	if err := r.repository.MarkSucceeded(ctx, job.ID.String(), job.Attempts); err != nil {
+		// Otherwise the lease expires and the job is run again.
+		r.leaseLost(log, err)
+		return
+	}
+	r.metrics.ObserveJob(job.Type, metrics.JobSucceeded)
+}
+
+// leaseLost logs that the outcome of a job was discarded when err is
+// ErrLeaseLost: the job outlived its lease and another worker claimed it.
+func (r *Runner) leaseLost(log *slog.Logger, err error) {
+	if errors.Is(err, ErrLeaseLost) {
+		log.Warn("job outcome discarded, its lease expired and it was claimed again")
+	}
+}
+
+// Purge removes the succeeded and failed jobs older than JobRetention.
+func (r *Runner) Purge(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "job.Runner.Purge")
+	defer span.End()
+
+	n, err := r.repository.DeleteFinished(ctx, r.now().Add(-r.config.Get().JobRetention))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	span.SetAttributes(attribute.Int64("jobs.deleted", n))
+	logger.FromContext(ctx).Info("finished jobs purged", slog.Int64("count", n))
+	return nil
+}
@@ -0,0 +1,275 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		JobConcurrency:  2,
+		JobPollInterval: time.Millisecond,
+		JobMaxAttempts:  3,
+		JobRetryBackoff: time.Second,
+		JobMaxBackoff:   time.Minute,
+		JobTimeout:      time.Minute,
+		JobLease:        2 * time.Minute,
+		JobRetention:    24 * time.Hour,
+	}
+}
+
+func setupRunnerTest(t *testing.T) (*Runner, *MockRepository) {
+	mockRepo := NewMockRepository(gomock.NewController(t))
+	runner := NewRunner(mockRepo, config.NewStore(testConfig()), metrics.New())
+	runner.now = func() time.Time { return testNow }
+	return runner, mockRepo
+}
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+func TestNewRunner(t *testing.T) {
+	mockRepo := new(MockRepository)
+	store := config.NewStore(testConfig())
+	m := metrics.New()
+	runner := NewRunner(mockRepo, store, m)
+
+	assert.Equal(t, mockRepo, runner.repository)
+	assert.Equal(t, store, runner.config)
+	assert.Equal(t, m, runner.metrics)
+	assert.Empty(t, runner.handlers)
+	assert.NotNil(t, runner.now)
+}
+
+func TestRunner_RunNext(t *testing.T) {
+	job := model.Job{
+		ID:          uuid.New(),
+		Type:        "greet",
+		Payload:     `{"name":"Ada"}`,
+		Status:      StatusPending,
+		MaxAttempts: 3,
+	}
+
+	tests := []struct {
+		name       string
+		job        model.Job
+		handlerErr error
+		panics     bool
+		mockFn     func(*MockRepository, model.Job)
+		wantResult string
+	}{
+		{
+			name: "succeeded",
+			job:  withAttempts(job, 1),
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkSucceeded(gomock.Any(), j.ID.String(), j.Attempts).Return(nil)
+			},
+			wantResult: metrics.JobSucceeded,
+		},
+		{
+			name:       "failed and retried with backoff",
+			job:        withAttempts(job, 2),
+			handlerErr: errors.New("mail server down"),
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkFailed(gomock.Any(), j.ID.String(), j.Attempts, "mail server down", testNow.Add(2*time.Second), false).Return(nil)
+			},
+			wantResult: metrics.JobRetried,
+		},
+		{
+			name:       "attempts exhausted",
+			job:        withAttempts(job, 3),
+			handlerErr: errors.New("mail server down"),
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkFailed(gomock.Any(), j.ID.String(), j.Attempts, "mail server down", testNow.Add(4*time.Second), true).Return(nil)
+			},
+			wantResult: metrics.JobFailed,
+		},
+		{
+			name:       "permanent error not retried",
+			job:        withAttempts(job, 1),
+			handlerErr: Permanent(errors.New("no such user")),
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkFailed(gomock.Any(), j.ID.String(), j.Attempts, "no such user", testNow.Add(time.Second), true).Return(nil)
+			},
+			wantResult: metrics.JobFailed,
+		},
+		{
+			name:   "panic recovered",
+			job:    withAttempts(job, 1),
+			panics: true,
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkFailed(gomock.Any(), j.ID.String(), j.Attempts, "panic: boom", testNow.Add(time.Second), false).Return(nil)
+			},
+			wantResult: metrics.JobRetried,
+		},
+		{
+			name: "invalid payload not retried",
+			job: func() model.Job {
+				j := withAttempts(job, 1)
+				j.Payload = `{"name":1}`
+				return j
+			}(),
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkFailed(gomock.Any(), j.ID.String(), j.Attempts, gomock.Any(), testNow.Add(time.Second), true).Return(nil)
+			},
+			wantResult: metrics.JobFailed,
+		},
+		{
+			name: "unknown type not retried",
+			job: func() model.Job {
+				j := withAttempts(job, 1)
+				j.Type = "unknown"
+				return j
+			}(),
+			mockFn: func(mr *MockRepository, j model.Job) {
+				mr.EXPECT().MarkFailed(gomock.Any(), j.ID.String(), j.Attempts, `no handler registered for job type "unknown"`, testNow.Add(time.Second), true).Return(nil)
+			},
+			wantResult: metrics.JobFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, mockRepo := setupRunnerTest(t)
+			var got []greeting
+			Handle(runner, "greet", func(ctx context.Context, g greeting) error {
+				if tt.panics {
+					panic("boom")
+				}
+				got = append(got, g)
+				return tt.handlerErr
+			})
+			j := tt.job
+			mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(&j, nil)
+			tt.mockFn(mockRepo, tt.job)
+
+			ran, err := runner.RunNext(context.Background())
+
+			assert.NoError(t, err)
+			assert.True(t, ran)
+			if tt.job.Type == "greet" && tt.job.Payload == job.Payload && !tt.panics {
+				assert.Equal(t, []greeting{{Name: "Ada"}}, got)
+			}
+
+			expected := fmt.Sprintf(`
+# HELP go_auth_api_job_runs_total Total number of background job runs by job type and result.
+# TYPE go_auth_api_job_runs_total counter
+go_auth_api_job_runs_total{result="%s",type="%s"} 1
+`, tt.wantResult, tt.job.Type)
+			assert.NoError(t, testutil.GatherAndCompare(runner.metrics.Registry(), strings.NewReader(expected), "go_auth_api_job_runs_total"))
+		})
+	}
+}
+
+func TestRunner_RunNext_NoJob(t *testing.T) {
+	runner, mockRepo := setupRunnerTest(t)
+	mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(nil, nil)
+
+	ran, err := runner.RunNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, ran)
+}
+
+func TestRunner_RunNext_ClaimError(t *testing.T) {
+	runner, mockRepo := setupRunnerTest(t)
+	mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(nil, sql.ErrConnDone)
+
+	ran, err := runner.RunNext(context.Background())
+
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.False(t, ran)
+}
+
+func TestRunner_RunNext_FinishesWhenCancelled(t *testing.T) {
+	runner, mockRepo := setupRunnerTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &model.Job{ID: uuid.New(), Type: "greet", Payload: `{}`, Attempts: 1, MaxAttempts: 3}
+
+	Handle(runner, "greet", func(ctx context.Context, _ greeting) error {
+		cancel()
+		return ctx.Err()
+	})
+	mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(job, nil)
+	mockRepo.EXPECT().MarkSucceeded(gomock.Any(), job.ID.String(), 1).Return(nil)
+
+	ran, err := runner.RunNext(ctx)
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+}
+
+func TestRunner_RunNext_LeaseLost(t *testing.T) {
+	runner, mockRepo := setupRunnerTest(t)
+	job := &model.Job{ID: uuid.New(), Type: "greet", Payload: `{}`, Attempts: 1, MaxAttempts: 3}
+
+	Handle(runner, "greet", func(context.Context, greeting) error { return nil })
+	mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(job, nil)
+	mockRepo.EXPECT().MarkSucceeded(gomock.Any(), job.ID.String(), 1).Return(ErrLeaseLost)
+
+	ran, err := runner.RunNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.NoError(t, testutil.GatherAndCompare(runner.metrics.Registry(), strings.NewReader(""), "go_auth_api_job_runs_total"))
+}
+
+func TestRunner_Purge(t *testing.T) {
+	runner, mockRepo := setupRunnerTest(t)
+	mockRepo.EXPECT().DeleteFinished(gomock.Any(), testNow.Add(-24*time.Hour)).Return(int64(2), nil)
+
+	assert.NoError(t, runner.Purge(context.Background()))
+
+	runner, mockRepo = setupRunnerTest(t)
+	mockRepo.EXPECT().DeleteFinished(gomock.Any(), gomock.Any()).Return(int64(0), sql.ErrConnDone)
+
+	assert.ErrorIs(t, runner.Purge(context.Background()), sql.ErrConnDone)
+}
+
+func TestRunner_Run(t *testing.T) {
+	runner, mockRepo := setupRunnerTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &model.Job{ID: uuid.New(), Type: "greet", Payload: `{}`, Attempts: 1, MaxAttempts: 3}
+
+	Handle(runner, "greet", func(context.Context, greeting) error {
+		cancel()
+		return nil
+	})
+	mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(job, nil)
+	mockRepo.EXPECT().Claim(gomock.Any(), testNow, 2*time.Minute).Return(nil, nil).AnyTimes()
+	mockRepo.EXPECT().MarkSucceeded(gomock.Any(), job.ID.String(), 1).Return(nil)
+
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+}
+
+// withAttempts returns a copy of job counting the given attempts.
+func withAttempts(job model.Job, attempts int) model.Job {
+	job.Attempts = attempts
+	return job
+}
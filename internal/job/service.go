@@ -0,0 +1,179 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+//go:generate mockgen -destination=./service_mock.go -package=job github.com/PakornBank/go-backend-example/internal/job Service
+
+// Service defines the methods that a service must implement.
+type Service interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}, opts Options) (*model.Job, error)
+	List(ctx context.Context, filter Filter) ([]model.Job, error)
+	Get(ctx context.Context, id string) (*model.Job, error)
+	Stats(ctx context.Context) ([]Stat, error)
+	Retry(ctx context.Context, actorID, id string) error
+}
+
+// service is a struct that provides methods to interact with the job service.
+type service struct {
+	repository Repository
+	tx         database.TxManager
+	auditLog   audit.Service
+	config     *config.Store
+	now        func() time.Time
+}
+
+// NewService creates a new instance of service with the provided repository, transaction manager, audit service and configuration.
+func NewService(repository Repository, tx database.TxManager, auditLog audit.Service, config *config.Store) Service {
+	return &service{repository: repository, tx: tx, auditLog: auditLog, config: config, now: time.Now}
+}
+
+// Enqueue stores a job of the given type with payload encoded as JSON, to be
+// run by the handler registered for the type. Called within a transaction,
+// the job is only run once the transaction commits. It returns ErrDuplicate
+// when opts.UniqueKey is held by a pending job.
+func (s *service) Enqueue(ctx context.Context, jobType string, payload interface{}, opts Options) (*model.Job, error) {
+	ctx, span := tracing.Start(ctx, "job.Service.Enqueue")
+	defer span.End()
+	span.SetAttributes(attribute.String("job.type", jobType))
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	job := &model.Job{
+		Type:        jobType,
+		Payload:     string(body),
+		Status:      StatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = s.config.Get().JobMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = s.now()
+	}
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+	}
+
+	if err := s.repository.Create(ctx, job); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Debug("job enqueued", slog.String("job_id", job.ID.String()), slog.String("job_type", jobType))
+	return job, nil
+}
+
+// List returns the jobs matching filter, most recently updated first.
+func (s *service) List(ctx context.Context, filter Filter) ([]model.Job, error) {
+	ctx, span := tracing.Start(ctx, "job.Service.List")
+	defer span.End()
+
+	jobs, err := s.repository.List(ctx, filter)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Get returns the job with the given ID.
+func (s *service) Get(ctx context.Context, id string) (*model.Job, error) {
+	ctx, span := tracing.Start(ctx, "job.Service.Get")
+	defer span.End()
+
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+
+	job, err := s.repository.Find(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Stats returns the number of jobs by type and status.
+func (s *service) Stats(ctx context.Context) ([]Stat, error) {
+	ctx, span := tracing.Start(ctx, "job.Service.Stats")
+	defer span.End()
+
+	stats, err := s.repository.Stats(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Retry schedules the failed job with the given ID to run again at once, with
+// a fresh count of attempts, on behalf of the admin with the given ID. It
+// returns ErrNotFailed when the job exists but has not failed.
+func (s *service) Retry(ctx context.Context, actorID, id string) error {
+	ctx, span := tracing.Start(ctx, "job.Service.Retry")
+	defer span.End()
+	span.SetAttributes(attribute.String("job.id", id))
+
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		job, err := s.repository.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if job.Status != StatusFailed {
+			return ErrNotFailed
+		}
+		if err := s.repository.Retry(ctx, id, s.now()); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, audit.Event{
+			ActorID:    actorID,
+			Action:     audit.ActionJobRetried,
+			TargetType: audit.TargetJob,
+			TargetID:   id,
+			Metadata: map[string]interface{}{
+				"type":       job.Type,
+				"attempts":   job.Attempts,
+				"last_error": job.LastError,
+			},
+		})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	logger.FromContext(ctx).Info("job rescheduled", slog.String("job_id", id))
+	return nil
+}
+
+// validID reports whether id is a UUID, as the IDs of jobs are.
+func validID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
@@ -0,0 +1,214 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupServiceTest(t *testing.T) (*service, *MockRepository, *audit.MockService) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockRepository(ctrl)
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	mockAudit := audit.NewMockService(ctrl)
+	jobService := &service{
+		repository: mockRepo,
+		tx:         mockTx,
+		auditLog:   mockAudit,
+		config:     config.NewStore(testConfig()),
+		now:        func() time.Time { return testNow },
+	}
+	return jobService, mockRepo, mockAudit
+}
+
+func TestNewService(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTx := new(database.MockTxManager)
+	auditLog := new(audit.MockService)
+	store := config.NewStore(testConfig())
+	jobService := NewService(mockRepo, mockTx, auditLog, store)
+
+	assert.NotNil(t, jobService)
+	assert.Equal(t, mockRepo, jobService.(*service).repository)
+	assert.Equal(t, mockTx, jobService.(*service).tx)
+	assert.Equal(t, auditLog, jobService.(*service).auditLog)
+	assert.Equal(t, store, jobService.(*service).config)
+	assert.NotNil(t, jobService.(*service).now)
+}
+
+func Test_service_Enqueue(t *testing.T) {
+	runAt := testNow.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		opts    Options
+		mockFn  func(*MockRepository)
+		want    *model.Job
+		errType error
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			payload: greeting{Name: "Ada"},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			want: &model.Job{Type: "greet", Payload: `{"name":"Ada"}`, Status: StatusPending, MaxAttempts: 3, RunAt: testNow},
+		},
+		{
+			name:    "scheduled unique job",
+			payload: greeting{Name: "Ada"},
+			opts:    Options{RunAt: runAt, UniqueKey: "greet:Ada", MaxAttempts: 1},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			want: &model.Job{Type: "greet", Payload: `{"name":"Ada"}`, Status: StatusPending, MaxAttempts: 1, RunAt: runAt, UniqueKey: stringPtr("greet:Ada")},
+		},
+		{
+			name:    "duplicate",
+			payload: greeting{Name: "Ada"},
+			opts:    Options{UniqueKey: "greet:Ada"},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(ErrDuplicate)
+			},
+			errType: ErrDuplicate,
+			wantErr: true,
+		},
+		{
+			name:    "payload not encodable",
+			payload: func() {},
+			mockFn:  func(*MockRepository) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobService, mockRepo, _ := setupServiceTest(t)
+			tt.mockFn(mockRepo)
+
+			got, err := jobService.Enqueue(context.Background(), "greet", tt.payload, tt.opts)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errType != nil {
+					assert.ErrorIs(t, err, tt.errType)
+				}
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_service_List(t *testing.T) {
+	jobService, mockRepo, _ := setupServiceTest(t)
+	filter := Filter{Status: StatusFailed, Limit: 10}
+	jobs := []model.Job{{ID: uuid.New()}}
+	mockRepo.EXPECT().List(gomock.Any(), filter).Return(jobs, nil)
+
+	got, err := jobService.List(context.Background(), filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, jobs, got)
+}
+
+func Test_service_Get(t *testing.T) {
+	id := uuid.New().String()
+
+	t.Run("found", func(t *testing.T) {
+		jobService, mockRepo, _ := setupServiceTest(t)
+		job := &model.Job{ID: uuid.MustParse(id)}
+		mockRepo.EXPECT().Find(gomock.Any(), id).Return(job, nil)
+
+		got, err := jobService.Get(context.Background(), id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, job, got)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		jobService, _, _ := setupServiceTest(t)
+
+		got, err := jobService.Get(context.Background(), "not-a-uuid")
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+}
+
+func Test_service_Stats(t *testing.T) {
+	jobService, mockRepo, _ := setupServiceTest(t)
+	stats := []Stat{{Type: "greet", Status: StatusPending, Count: 3}}
+	mockRepo.EXPECT().Stats(gomock.Any()).Return(stats, nil)
+
+	got, err := jobService.Stats(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, stats, got)
+}
+
+func Test_service_Retry(t *testing.T) {
+	actorID := uuid.New().String()
+	id := uuid.New().String()
+	failed := &model.Job{ID: uuid.MustParse(id), Type: "greet", Status: StatusFailed, Attempts: 3, LastError: "boom"}
+
+	t.Run("rescheduled", func(t *testing.T) {
+		jobService, mockRepo, mockAudit := setupServiceTest(t)
+		mockRepo.EXPECT().Find(gomock.Any(), id).Return(failed, nil)
+		mockRepo.EXPECT().Retry(gomock.Any(), id, testNow).Return(nil)
+		mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Event) error {
+			assert.Equal(t, actorID, e.ActorID)
+			assert.Equal(t, audit.ActionJobRetried, e.Action)
+			assert.Equal(t, audit.TargetJob, e.TargetType)
+			assert.Equal(t, id, e.TargetID)
+			assert.Equal(t, "greet", e.Metadata["type"])
+			assert.Equal(t, "boom", e.Metadata["last_error"])
+			return nil
+		})
+
+		assert.NoError(t, jobService.Retry(context.Background(), actorID, id))
+	})
+
+	t.Run("not failed", func(t *testing.T) {
+		jobService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().Find(gomock.Any(), id).Return(&model.Job{Status: StatusPending}, nil)
+
+		assert.ErrorIs(t, jobService.Retry(context.Background(), actorID, id), ErrNotFailed)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		jobService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().Find(gomock.Any(), id).Return(nil, ErrNotFound)
+
+		assert.ErrorIs(t, jobService.Retry(context.Background(), actorID, id), ErrNotFound)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		jobService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().Find(gomock.Any(), id).Return(failed, nil)
+		mockRepo.EXPECT().Retry(gomock.Any(), id, testNow).Return(sql.ErrConnDone)
+
+		assert.ErrorIs(t, jobService.Retry(context.Background(), actorID, id), sql.ErrConnDone)
+	})
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,86 @@
+package loginhistory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=./repository_mock.go -package=loginhistory github.com/PakornBank/go-backend-example/internal/loginhistory Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	Create(ctx context.Context, attempt *model.LoginAttempt) error
+	ListByUser(ctx context.Context, userID string, limit int) ([]model.LoginAttempt, error)
+	CountSuccessful(ctx context.Context, userID, fingerprint string) (total, fromDevice int64, err error)
+}
+
+// repository is a struct that provides methods to interact with the login history in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// Create inserts a new login attempt record into the database.
+func (r *repository) Create(ctx context.Context, attempt *model.LoginAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(attempt).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to create login attempt", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// ListByUser retrieves the most recent login attempts of the user, newest first.
+func (r *repository) ListByUser(ctx context.Context, userID string, limit int) ([]model.LoginAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var attempts []model.LoginAttempt
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").Limit(limit).Find(&attempts).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to list login attempts", slog.Any("error", err))
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// CountSuccessful counts the successful logins of the user, in total and from the device with the given fingerprint.
+func (r *repository) CountSuccessful(ctx context.Context, userID, fingerprint string) (total, fromDevice int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var counts struct {
+		Total      int64
+		FromDevice int64
+	}
+
+	err = database.Conn(ctx, r.db).WithContext(ctx).Model(&model.LoginAttempt{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE fingerprint = ?) AS from_device", fingerprint).
+		Where("user_id = ? AND success", userID).
+		Scan(&counts).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to count successful logins", slog.Any("error", err))
+		return 0, 0, err
+	}
+
+	return counts.Total, counts.FromDevice, nil
+}
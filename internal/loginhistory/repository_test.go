@@ -0,0 +1,183 @@
+package loginhistory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	historyRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, historyRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	historyRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, historyRepo)
+	assert.Equal(t, gormDB, historyRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, historyRepo.(*repository).timeout)
+}
+
+func Test_repository_Create(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "attempt created",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "login_attempts"`).
+					WithArgs(&userID, "user@example.com", true, "", "192.0.2.1", "curl/8.4.0", "curl", "fingerprint", now).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "login_attempts"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, historyRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			attempt := &model.LoginAttempt{
+				UserID:      &userID,
+				Email:       "user@example.com",
+				Success:     true,
+				IP:          "192.0.2.1",
+				UserAgent:   "curl/8.4.0",
+				Device:      "curl",
+				Fingerprint: "fingerprint",
+				CreatedAt:   now,
+			}
+			err := historyRepo.Create(context.Background(), attempt)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, uuid.Nil, attempt.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ListByUser(t *testing.T) {
+	userID := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantLen int
+		errType error
+	}{
+		{
+			name: "attempts listed",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "success"}).
+					AddRow(uuid.New(), true).
+					AddRow(uuid.New(), false)
+				sqlMock.ExpectQuery(`SELECT \* FROM "login_attempts" WHERE user_id = \$1 ORDER BY created_at DESC LIMIT \$2`).
+					WithArgs(userID, 20).
+					WillReturnRows(rows)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "login_attempts"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, historyRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := historyRepo.ListByUser(context.Background(), userID, 20)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_CountSuccessful(t *testing.T) {
+	userID := uuid.New().String()
+
+	tests := []struct {
+		name           string
+		mockFn         func(sqlmock.Sqlmock)
+		wantTotal      int64
+		wantFromDevice int64
+		errType        error
+	}{
+		{
+			name: "logins counted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS total, COUNT\(\*\) FILTER \(WHERE fingerprint = \$1\) AS from_device FROM "login_attempts" WHERE user_id = \$2 AND success`).
+					WithArgs("fingerprint", userID).
+					WillReturnRows(sqlmock.NewRows([]string{"total", "from_device"}).AddRow(5, 2))
+			},
+			wantTotal:      5,
+			wantFromDevice: 2,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT COUNT`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, historyRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			total, fromDevice, err := historyRepo.CountSuccessful(context.Background(), userID, "fingerprint")
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Equal(t, tt.wantFromDevice, fromDevice)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package loginhistory records login attempts and alerts users of logins from new devices.
+package loginhistory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/session"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Reasons recorded for failed login attempts.
+const (
+	ReasonInvalidEmail  = "invalid_email"
+	ReasonUnknownEmail  = "unknown_email"
+	ReasonWrongPassword = "wrong_password"
+	ReasonDisabled      = "disabled"
+	ReasonError         = "error"
+)
+
+// alertTimeout bounds how long sending a new device alert may take.
+const alertTimeout = 30 * time.Second
+
+// Attempt describes a login attempt to record.
+type Attempt struct {
+	// Email is the address the login was attempted with.
+	Email string
+	// User is the user of the address, nil when it is unknown.
+	User   *model.User
+	Client session.Client
+	// Reason is one of the Reason constants for failed attempts, empty for successful ones.
+	Reason string
+}
+
+//go:generate mockgen -destination=./service_mock.go -package=loginhistory github.com/PakornBank/go-backend-example/internal/loginhistory Service
+
+// Service defines the methods that a service must implement.
+type Service interface {
+	Record(ctx context.Context, attempt Attempt) error
+	List(ctx context.Context, userID string, limit int) ([]model.LoginAttempt, error)
+}
+
+// service is a struct that provides methods to interact with the login history service.
+type service struct {
+	repository Repository
+	mailer     mail.Sender
+	config     *config.Store
+	// async runs fn in the background, alerts are sent without delaying the login.
+	async func(fn func())
+}
+
+// NewService creates a new instance of service with the provided repository, mail sender and configuration.
+func NewService(repository Repository, mailer mail.Sender, config *config.Store) Service {
+	return &service{
+		repository: repository,
+		mailer:     mailer,
+		config:     config,
+		async:      func(fn func()) { go fn() },
+	}
+}
+
+// Record stores the login attempt. When a user logs in successfully from a
+// device never used for a successful login before, and it is not their first
+// login, an alert is emailed to them.
+func (s *service) Record(ctx context.Context, attempt Attempt) error {
+	ctx, span := tracing.Start(ctx, "loginhistory.Service.Record")
+	defer span.End()
+
+	entry := &model.LoginAttempt{
+		Email:       truncate(attempt.Email, 255),
+		Success:     attempt.Reason == "",
+		Reason:      attempt.Reason,
+		IP:          attempt.Client.IP,
+		UserAgent:   truncate(attempt.Client.UserAgent, 512),
+		Device:      attempt.Client.Device(),
+		Fingerprint: attempt.Client.Fingerprint(),
+		CreatedAt:   time.Now(),
+	}
+	if attempt.User != nil {
+		entry.UserID = &attempt.User.ID
+		span.SetAttributes(attribute.String("user_id", attempt.User.ID.String()))
+	}
+
+	newDevice := false
+	if entry.Success && attempt.User != nil && s.config.Get().LoginAlertNewDevice {
+		total, fromDevice, err := s.repository.CountSuccessful(ctx, attempt.User.ID.String(), entry.Fingerprint)
+		if err != nil {
+			logger.FromContext(ctx).Warn("failed to check for a new device", slog.Any("error", err))
+		}
+		newDevice = err == nil && total > 0 && fromDevice == 0
+	}
+
+	if err := s.repository.Create(ctx, entry); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	if newDevice {
+		s.alert(ctx, attempt.User, entry)
+	}
+	return nil
+}
+
+// List returns the limit most recent login attempts of the user, newest first.
+func (s *service) List(ctx context.Context, userID string, limit int) ([]model.LoginAttempt, error) {
+	ctx, span := tracing.Start(ctx, "loginhistory.Service.List")
+	defer span.End()
+
+	attempts, err := s.repository.ListByUser(ctx, userID, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// alert emails user in the background about the login from a new device.
+// Failing to do so is logged.
+func (s *service) alert(ctx context.Context, user *model.User, entry *model.LoginAttempt) {
+	ctx = context.WithoutCancel(ctx)
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "New login to your account",
+		Body:    newDeviceBody(user, entry),
+	}
+
+	s.async(func() {
+		ctx, cancel := context.WithTimeout(ctx, alertTimeout)
+		defer cancel()
+
+		log := logger.FromContext(ctx).With(slog.String("user_id", user.ID.String()))
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Error("failed to send new device alert", slog.Any("error", err))
+			return
+		}
+		log.Info("new device alert sent", slog.String("device", entry.Device))
+	})
+}
+
+// newDeviceBody renders the text of the alert about the login recorded in entry.
+func newDeviceBody(user *model.User, entry *model.LoginAttempt) string {
+	return fmt.Sprintf(`Hi %s,
+
+Your account was just accessed from a device we have not seen before:
+
+  Device:     %s
+  IP address: %s
+  Time:       %s
+
+If this was you, you can ignore this email. If not, change your password
+and revoke the session from the list of your active sessions.
+`, user.FullName, entry.Device, entry.IP, entry.CreatedAt.UTC().Format("2006-01-02 15:04 MST"))
+}
+
+// truncate shortens s to at most n bytes, dropping a character cut in half.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
@@ -0,0 +1,222 @@
+package loginhistory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/PakornBank/go-backend-example/internal/session"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func setupServiceTest(t *testing.T, alertNewDevice bool) (*service, *MockRepository, *mail.MockSender) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockRepository(ctrl)
+	mockMailer := mail.NewMockSender(ctrl)
+	historyService := &service{
+		repository: mockRepo,
+		mailer:     mockMailer,
+		config:     config.NewStore(&config.Config{LoginAlertNewDevice: alertNewDevice}),
+		async:      func(fn func()) { fn() },
+	}
+	return historyService, mockRepo, mockMailer
+}
+
+func TestNewService(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockMailer := new(mail.MockSender)
+	store := config.NewStore(&config.Config{})
+	historyService := NewService(mockRepo, mockMailer, store)
+
+	assert.NotNil(t, historyService)
+	assert.Equal(t, mockRepo, historyService.(*service).repository)
+	assert.Equal(t, mockMailer, historyService.(*service).mailer)
+	assert.Equal(t, store, historyService.(*service).config)
+	assert.NotNil(t, historyService.(*service).async)
+}
+
+func Test_service_Record(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	client := session.Client{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:130.0) Gecko/20100101 Firefox/130.0", IP: "192.0.2.1"}
+	userID := mockUser.ID.String()
+
+	tests := []struct {
+		name           string
+		attempt        Attempt
+		alertNewDevice bool
+		mockFn         func(*MockRepository, *mail.MockSender)
+		wantSuccess    bool
+		wantErr        bool
+	}{
+		{
+			name:           "login from a new device alerts the user",
+			attempt:        Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+			alertNewDevice: true,
+			mockFn: func(mr *MockRepository, ms *mail.MockSender) {
+				mr.EXPECT().CountSuccessful(gomock.Any(), userID, client.Fingerprint()).Return(int64(3), int64(0), nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				ms.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+					assert.Equal(t, mockUser.Email, msg.To)
+					assert.Equal(t, "New login to your account", msg.Subject)
+					assert.Contains(t, msg.Body, "Firefox on Linux")
+					assert.Contains(t, msg.Body, "192.0.2.1")
+					return nil
+				})
+			},
+			wantSuccess: true,
+		},
+		{
+			name:           "login from a known device",
+			attempt:        Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+			alertNewDevice: true,
+			mockFn: func(mr *MockRepository, _ *mail.MockSender) {
+				mr.EXPECT().CountSuccessful(gomock.Any(), userID, client.Fingerprint()).Return(int64(3), int64(1), nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantSuccess: true,
+		},
+		{
+			name:           "first login",
+			attempt:        Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+			alertNewDevice: true,
+			mockFn: func(mr *MockRepository, _ *mail.MockSender) {
+				mr.EXPECT().CountSuccessful(gomock.Any(), userID, client.Fingerprint()).Return(int64(0), int64(0), nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantSuccess: true,
+		},
+		{
+			name:    "alerts disabled",
+			attempt: Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+			mockFn: func(mr *MockRepository, _ *mail.MockSender) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantSuccess: true,
+		},
+		{
+			name:           "failed login",
+			attempt:        Attempt{Email: mockUser.Email, User: &mockUser, Client: client, Reason: ReasonWrongPassword},
+			alertNewDevice: true,
+			mockFn: func(mr *MockRepository, _ *mail.MockSender) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:           "failure to check the device skips the alert",
+			attempt:        Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+			alertNewDevice: true,
+			mockFn: func(mr *MockRepository, _ *mail.MockSender) {
+				mr.EXPECT().CountSuccessful(gomock.Any(), userID, gomock.Any()).Return(int64(0), int64(0), gorm.ErrInvalidDB)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantSuccess: true,
+		},
+		{
+			name:           "alert failure is not returned",
+			attempt:        Attempt{Email: mockUser.Email, User: &mockUser, Client: client},
+			alertNewDevice: true,
+			mockFn: func(mr *MockRepository, ms *mail.MockSender) {
+				mr.EXPECT().CountSuccessful(gomock.Any(), userID, gomock.Any()).Return(int64(1), int64(0), nil)
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				ms.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+			},
+			wantSuccess: true,
+		},
+		{
+			name:    "repository error",
+			attempt: Attempt{Email: "unknown@example.com", Client: client, Reason: ReasonUnknownEmail},
+			mockFn: func(mr *MockRepository, _ *mail.MockSender) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(gorm.ErrInvalidDB)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			historyService, mockRepo, mockMailer := setupServiceTest(t, tt.alertNewDevice)
+			tt.mockFn(mockRepo, mockMailer)
+
+			err := historyService.Record(context.Background(), tt.attempt)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_service_Record_Entry(t *testing.T) {
+	historyService, mockRepo, _ := setupServiceTest(t, false)
+	client := session.Client{UserAgent: strings.Repeat("é", 300), IP: "198.51.100.7"}
+
+	var got *model.LoginAttempt
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a *model.LoginAttempt) error {
+		got = a
+		return nil
+	})
+
+	err := historyService.Record(context.Background(), Attempt{Email: "unknown@example.com", Client: client, Reason: ReasonUnknownEmail})
+
+	assert.NoError(t, err)
+	assert.Nil(t, got.UserID)
+	assert.Equal(t, "unknown@example.com", got.Email)
+	assert.False(t, got.Success)
+	assert.Equal(t, ReasonUnknownEmail, got.Reason)
+	assert.Equal(t, "198.51.100.7", got.IP)
+	assert.Equal(t, strings.Repeat("é", 256), got.UserAgent)
+	assert.Equal(t, client.Fingerprint(), got.Fingerprint)
+	assert.NotZero(t, got.CreatedAt)
+}
+
+func Test_service_List(t *testing.T) {
+	userID := testutil.NewMockUser().ID.String()
+
+	tests := []struct {
+		name    string
+		mockFn  func(*MockRepository)
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name: "attempts listed",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().ListByUser(gomock.Any(), userID, 10).Return(make([]model.LoginAttempt, 2), nil)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "repository error",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().ListByUser(gomock.Any(), userID, 10).Return(nil, gorm.ErrInvalidDB)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			historyService, mockRepo, _ := setupServiceTest(t, false)
+			tt.mockFn(mockRepo)
+
+			got, err := historyService.List(context.Background(), userID, 10)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+		})
+	}
+}
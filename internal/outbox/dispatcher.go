@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/common/worker"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// subscription is a handler registered for a type of events.
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+// Dispatcher delivers the events of the outbox to the handlers subscribed to
+// their type. An event is delivered again until every handler succeeds, with
+// an exponential backoff between attempts, and moved to the dead-letter state
+// once the attempts are exhausted. Several dispatchers, in the same or other
+// processes, may run at once: every event is leased to one of them at a time.
+type Dispatcher struct {
+	repository Repository
+	config     *config.Store
+	metrics    *metrics.Metrics
+	handlers   map[string][]subscription
+	now        func() time.Time
+}
+
+// NewDispatcher creates a new Dispatcher with the provided repository, configuration and metrics.
+func NewDispatcher(repository Repository, config *config.Store, m *metrics.Metrics) *Dispatcher {
+	return &Dispatcher{
+		repository: repository,
+		config:     config,
+		metrics:    m,
+		handlers:   map[string][]subscription{},
+		now:        time.Now,
+	}
+}
+
+// Subscribe registers handler, identified by name in logs and errors, for
+// events of the given type. It must not be called once Run has started.
+func (d *Dispatcher) Subscribe(eventType, name string, handler Handler) {
+	d.handlers[eventType] = append(d.handlers[eventType], subscription{name: name, handler: handler})
+}
+
+// Run delivers due events until ctx is done, checking the outbox every poll
+// interval, or at once while batches come back full. Events being delivered
+// when ctx is done are finished before Run returns.
+func (d *Dispatcher) Run(ctx context.Context) {
+	interval := func() time.Duration { return d.config.Get().OutboxPollInterval }
+	worker.Poll(ctx, interval, "failed to dispatch outbox events", func(ctx context.Context) (bool, error) {
+		n, err := d.Dispatch(ctx)
+		return n == d.config.Get().OutboxBatchSize, err
+	})
+}
+
+// Dispatch delivers one batch of due events and returns how many it claimed.
+// Events not reached before the lease of the batch expires are left to be
+// claimed again.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	cfg := d.config.Get()
+
+	claimedAt := d.now()
+	events, err := d.repository.Claim(ctx, cfg.OutboxBatchSize, claimedAt, cfg.OutboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// Claimed events are leased to this dispatcher, finish them even when ctx is cancelled.
+	ctx = context.WithoutCancel(ctx)
+	expiresAt := claimedAt.Add(cfg.OutboxLease)
+	for i := range events {
+		if !d.now().Before(expiresAt) {
+			logger.FromContext(ctx).Warn("outbox lease expired", slog.Int("undelivered", len(events)-i))
+			break
+		}
+		d.deliver(ctx, &events[i], expiresAt, cfg)
+	}
+
+	return len(events), nil
+}
+
+// Purge removes the delivered and dead events older than OutboxRetention.
+// Pending events are kept however old they are.
+func (d *Dispatcher) Purge(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "outbox.Dispatcher.Purge")
+	defer span.End()
+
+	n, err := d.repository.DeleteFinished(ctx, d.now().Add(-d.config.Get().OutboxRetention))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	span.SetAttributes(attribute.Int64("outbox.deleted", n))
+	logger.FromContext(ctx).Info("finished outbox events purged", slog.Int64("count", n))
+	return nil
+}
+
+// deliver passes the event to every handler subscribed to its type, which
+// must finish by expiresAt, and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, e *model.OutboxEvent, expiresAt time.Time, cfg *config.Config) {
+	ctx, span := tracing.Start(ctx, "outbox.Dispatcher.deliver")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("outbox.event_id", e.ID.String()),
+		attribute.String("outbox.event_type", e.Type),
+		attribute.Int("outbox.attempt", e.Attempts),
+	)
+
+	log := logger.FromContext(ctx).With(
+		slog.String("event_id", e.ID.String()),
+		slog.String("event_type", e.Type),
+		slog.Int("attempt", e.Attempts),
+	)
+
+	event := Event{
+		ID:        e.ID,
+		Type:      e.Type,
+		Payload:   json.RawMessage(e.Payload),
+		CreatedAt: e.CreatedAt,
+		Attempt:   e.Attempts,
+	}
+
+	handlerCtx, cancel := context.WithTimeout(ctx, expiresAt.Sub(d.now()))
+	var errs []error
+	for _, sub := range d.handlers[e.Type] {
+		err := worker.Call(handlerCtx, func(ctx context.Context) error {
+			return sub.handler(ctx, event)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+	cancel()
+
+	now := d.now()
+	if err := errors.Join(errs...); err != nil {
+		tracing.RecordError(span, err)
+		dead := e.Attempts >= cfg.OutboxMaxAttempts
+		next := now.Add(worker.Backoff(cfg.OutboxRetryBackoff, cfg.OutboxMaxBackoff, e.Attempts))
+		if markErr := d.repository.MarkFailed(ctx, e.ID.String(), e.Attempts, err.Error(), next, dead); markErr != nil {
+			d.leaseLost(log, markErr)
+			return
+		}
+		if dead {
+			log.Error("outbox event dead-lettered", slog.Any("error", err))
+			d.metrics.ObserveOutbox(e.Type, metrics.OutboxDead)
+		} else {
+			log.Warn("outbox event delivery failed", slog.Any("error", err))
+			d.metrics.ObserveOutbox(e.Type, metrics.OutboxRetried)
+		}
+		return
+	}
+
+	if err := d.repository.MarkDelivered(ctx, e.ID.String(), e.Attempts, now); err != nil {
+		// Otherwise the lease expires and the event is delivered again.
+		d.leaseLost(log, err)
+		return
+	}
+	d.metrics.ObserveOutbox(e.Type, metrics.OutboxDelivered)
+}
+
+// leaseLost logs that the outcome of an event was discarded when err is
+// ErrLeaseLost: the event outlived its lease and another dispatcher claimed it.
+func (d *Dispatcher) leaseLost(log *slog.Logger, err error) {
+	if errors.Is(err, ErrLeaseLost) {
+		log.Warn("outbox event outcome discarded, its lease expired and it was claimed again")
+	}
+}
@@ -0,0 +1,247 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		OutboxPollInterval: time.Millisecond,
+		OutboxBatchSize:    10,
+		OutboxMaxAttempts:  3,
+		OutboxRetryBackoff: time.Second,
+		OutboxMaxBackoff:   time.Minute,
+		OutboxLease:        time.Minute,
+		OutboxRetention:    24 * time.Hour,
+	}
+}
+
+func setupDispatcherTest(t *testing.T) (*Dispatcher, *MockRepository) {
+	mockRepo := NewMockRepository(gomock.NewController(t))
+	dispatcher := NewDispatcher(mockRepo, config.NewStore(testConfig()), metrics.New())
+	dispatcher.now = func() time.Time { return testNow }
+	return dispatcher, mockRepo
+}
+
+func TestNewDispatcher(t *testing.T) {
+	mockRepo := new(MockRepository)
+	store := config.NewStore(testConfig())
+	m := metrics.New()
+	dispatcher := NewDispatcher(mockRepo, store, m)
+
+	assert.Equal(t, mockRepo, dispatcher.repository)
+	assert.Equal(t, store, dispatcher.config)
+	assert.Equal(t, m, dispatcher.metrics)
+	assert.NotNil(t, dispatcher.now)
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	event := model.OutboxEvent{
+		ID:        uuid.New(),
+		Type:      EventUserRegistered,
+		Payload:   `{"user_id":"id","email":"user@example.com","full_name":"User"}`,
+		Status:    StatusPending,
+		CreatedAt: testNow.Add(-time.Minute),
+	}
+	ok := func(context.Context, Event) error { return nil }
+	fail := func(context.Context, Event) error { return errors.New("boom") }
+
+	tests := []struct {
+		name       string
+		attempts   int
+		handlers   []Handler
+		mockFn     func(*MockRepository, int)
+		wantResult string
+	}{
+		{
+			name:     "every handler succeeds",
+			attempts: 1,
+			handlers: []Handler{ok, ok},
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkDelivered(gomock.Any(), event.ID.String(), attempt, testNow).Return(nil)
+			},
+			wantResult: metrics.OutboxDelivered,
+		},
+		{
+			name:     "no handler",
+			attempts: 1,
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkDelivered(gomock.Any(), event.ID.String(), attempt, testNow).Return(nil)
+			},
+			wantResult: metrics.OutboxDelivered,
+		},
+		{
+			name:     "handler fails",
+			attempts: 2,
+			handlers: []Handler{ok, fail},
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), event.ID.String(), attempt, "handler-1: boom", testNow.Add(2*time.Second), false).Return(nil)
+			},
+			wantResult: metrics.OutboxRetried,
+		},
+		{
+			name:     "handler panics",
+			attempts: 1,
+			handlers: []Handler{func(context.Context, Event) error { panic("oops") }},
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), event.ID.String(), attempt, "handler-0: panic: oops", testNow.Add(time.Second), false).Return(nil)
+			},
+			wantResult: metrics.OutboxRetried,
+		},
+		{
+			name:     "attempts exhausted",
+			attempts: 3,
+			handlers: []Handler{fail},
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), event.ID.String(), attempt, "handler-0: boom", testNow.Add(4*time.Second), true).Return(nil)
+			},
+			wantResult: metrics.OutboxDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher, mockRepo := setupDispatcherTest(t)
+			var delivered []Event
+			for i, h := range tt.handlers {
+				dispatcher.Subscribe(EventUserRegistered, fmt.Sprintf("handler-%d", i), func(ctx context.Context, e Event) error {
+					delivered = append(delivered, e)
+					return h(ctx, e)
+				})
+			}
+			dispatcher.Subscribe(EventUserDeleted, "other", fail)
+
+			claimed := event
+			claimed.Attempts = tt.attempts
+			mockRepo.EXPECT().Claim(gomock.Any(), 10, testNow, time.Minute).Return([]model.OutboxEvent{claimed}, nil)
+			tt.mockFn(mockRepo, tt.attempts)
+
+			n, err := dispatcher.Dispatch(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Len(t, delivered, len(tt.handlers))
+			for _, e := range delivered {
+				assert.Equal(t, event.ID, e.ID)
+				assert.Equal(t, tt.attempts, e.Attempt)
+				var payload UserRegistered
+				assert.NoError(t, e.Decode(&payload))
+				assert.Equal(t, "user@example.com", payload.Email)
+			}
+			expected := fmt.Sprintf(`
+# HELP go_auth_api_outbox_deliveries_total Total number of outbox event delivery attempts by event type and result.
+# TYPE go_auth_api_outbox_deliveries_total counter
+go_auth_api_outbox_deliveries_total{result=%q,type="user.registered"} 1
+`, tt.wantResult)
+			assert.NoError(t, testutil.GatherAndCompare(dispatcher.metrics.Registry(), strings.NewReader(expected), "go_auth_api_outbox_deliveries_total"))
+		})
+	}
+}
+
+func TestDispatcher_Dispatch_LeaseLost(t *testing.T) {
+	dispatcher, mockRepo := setupDispatcherTest(t)
+	event := model.OutboxEvent{ID: uuid.New(), Type: EventUserRegistered, Attempts: 2}
+	dispatcher.Subscribe(EventUserRegistered, "handler", func(context.Context, Event) error { return nil })
+	mockRepo.EXPECT().Claim(gomock.Any(), 10, testNow, time.Minute).Return([]model.OutboxEvent{event}, nil)
+	mockRepo.EXPECT().MarkDelivered(gomock.Any(), event.ID.String(), 2, testNow).Return(ErrLeaseLost)
+
+	n, err := dispatcher.Dispatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NoError(t, testutil.GatherAndCompare(dispatcher.metrics.Registry(), strings.NewReader(""), "go_auth_api_outbox_deliveries_total"))
+}
+
+func TestDispatcher_Dispatch_ClaimError(t *testing.T) {
+	dispatcher, mockRepo := setupDispatcherTest(t)
+	mockRepo.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+
+	n, err := dispatcher.Dispatch(context.Background())
+
+	assert.Error(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDispatcher_Purge(t *testing.T) {
+	dispatcher, mockRepo := setupDispatcherTest(t)
+	mockRepo.EXPECT().DeleteFinished(gomock.Any(), testNow.Add(-24*time.Hour)).Return(int64(2), nil)
+
+	assert.NoError(t, dispatcher.Purge(context.Background()))
+
+	dispatcher, mockRepo = setupDispatcherTest(t)
+	mockRepo.EXPECT().DeleteFinished(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("db error"))
+
+	assert.Error(t, dispatcher.Purge(context.Background()))
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	dispatcher, mockRepo := setupDispatcherTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	full := make([]model.OutboxEvent, 10)
+	for i := range full {
+		full[i] = model.OutboxEvent{ID: uuid.New(), Type: EventUserDeleted, Attempts: 1}
+	}
+	gomock.InOrder(
+		mockRepo.EXPECT().Claim(gomock.Any(), 10, testNow, time.Minute).Return(full, nil),
+		mockRepo.EXPECT().Claim(gomock.Any(), 10, testNow, time.Minute).Return(nil, errors.New("db error")),
+		mockRepo.EXPECT().Claim(gomock.Any(), 10, testNow, time.Minute).DoAndReturn(
+			func(context.Context, int, time.Time, time.Duration) ([]model.OutboxEvent, error) {
+				cancel()
+				return nil, nil
+			}),
+	)
+	mockRepo.EXPECT().MarkDelivered(gomock.Any(), gomock.Any(), 1, testNow).Return(nil).Times(len(full))
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
+
+func TestDispatcher_Dispatch_LeaseExpired(t *testing.T) {
+	dispatcher, mockRepo := setupDispatcherTest(t)
+	now := testNow
+	dispatcher.now = func() time.Time { return now }
+
+	events := []model.OutboxEvent{
+		{ID: uuid.New(), Type: EventUserRegistered, Attempts: 1},
+		{ID: uuid.New(), Type: EventUserRegistered, Attempts: 1},
+	}
+	var deadline time.Time
+	dispatcher.Subscribe(EventUserRegistered, "slow", func(ctx context.Context, _ Event) error {
+		deadline, _ = ctx.Deadline()
+		now = now.Add(time.Minute)
+		return nil
+	})
+	mockRepo.EXPECT().Claim(gomock.Any(), 10, testNow, time.Minute).Return(events, nil)
+	mockRepo.EXPECT().MarkDelivered(gomock.Any(), events[0].ID.String(), 1, testNow.Add(time.Minute)).Return(nil)
+
+	n, err := dispatcher.Dispatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+}
@@ -0,0 +1,7 @@
+package outbox
+
+import "errors"
+
+// ErrLeaseLost is returned when recording the outcome of an attempt of an
+// event that has been claimed again since, once the lease of the attempt expired.
+var ErrLeaseLost = errors.New("outbox event was claimed again")
@@ -0,0 +1,75 @@
+// Package outbox publishes domain events reliably: events are written to the
+// outbox_events table in the transaction of the change they describe and a
+// Dispatcher delivers them to in-process handlers after it commits.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of the domain events.
+const (
+	EventUserRegistered   = "user.registered"
+	EventUserEmailChanged = "user.email_changed"
+	EventUserDeleted      = "user.deleted"
+	EventLoginFailed      = "auth.login_failed"
+)
+
+// Statuses of outbox events.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// UserRegistered is the payload of EventUserRegistered.
+type UserRegistered struct {
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	FullName string `json:"full_name"`
+}
+
+// UserEmailChanged is the payload of EventUserEmailChanged.
+type UserEmailChanged struct {
+	UserID   string `json:"user_id"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+// UserDeleted is the payload of EventUserDeleted.
+type UserDeleted struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// LoginFailed is the payload of EventLoginFailed. UserID is empty for unknown email addresses.
+type LoginFailed struct {
+	UserID string `json:"user_id,omitempty"`
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+	IP     string `json:"ip"`
+}
+
+// Event is a domain event as delivered to handlers.
+type Event struct {
+	// ID identifies the event across redeliveries, so that handlers can skip events they already processed.
+	ID        uuid.UUID
+	Type      string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	// Attempt is 1 on the first delivery and increases with every retry.
+	Attempt int
+}
+
+// Decode unmarshals the payload of the event into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler processes a delivered event. Events are delivered at least once, so
+// handlers must tolerate duplicates. An error schedules a retry of the event.
+type Handler func(ctx context.Context, event Event) error
@@ -0,0 +1,56 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+//go:generate mockgen -destination=./publisher_mock.go -package=outbox github.com/PakornBank/go-backend-example/internal/outbox Publisher
+
+// Publisher defines the methods that a publisher must implement.
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, payload interface{}) error
+}
+
+// publisher is a struct that writes domain events to the outbox.
+type publisher struct {
+	repository Repository
+}
+
+// NewPublisher creates a new instance of publisher with the provided repository.
+func NewPublisher(repository Repository) Publisher {
+	return &publisher{repository: repository}
+}
+
+// Publish writes an event of the given type with payload encoded as JSON to
+// the outbox. Called within a transaction, the event is only delivered if the
+// transaction commits.
+func (p *publisher) Publish(ctx context.Context, eventType string, payload interface{}) error {
+	ctx, span := tracing.Start(ctx, "outbox.Publisher.Publish")
+	defer span.End()
+	span.SetAttributes(attribute.String("outbox.event_type", eventType))
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("invalid %s payload: %w", eventType, err)
+	}
+
+	event := &model.OutboxEvent{
+		Type:          eventType,
+		Payload:       string(data),
+		Status:        StatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := p.repository.Create(ctx, event); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
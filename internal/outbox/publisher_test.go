@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewPublisher(t *testing.T) {
+	mockRepo := new(MockRepository)
+	events := NewPublisher(mockRepo)
+
+	assert.NotNil(t, events)
+	assert.Equal(t, mockRepo, events.(*publisher).repository)
+}
+
+func Test_publisher_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		mockFn  func(*MockRepository)
+		wantErr bool
+	}{
+		{
+			name:    "event written",
+			payload: UserDeleted{UserID: "id", Email: "user@example.com"},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *model.OutboxEvent) error {
+					assert.Equal(t, EventUserDeleted, e.Type)
+					assert.JSONEq(t, `{"user_id":"id","email":"user@example.com"}`, e.Payload)
+					assert.Equal(t, StatusPending, e.Status)
+					assert.WithinDuration(t, time.Now(), e.NextAttemptAt, time.Second)
+					return nil
+				})
+			},
+		},
+		{
+			name:    "invalid payload",
+			payload: func() {},
+			mockFn:  func(*MockRepository) {},
+			wantErr: true,
+		},
+		{
+			name:    "database error",
+			payload: UserDeleted{UserID: "id"},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRepository(gomock.NewController(t))
+			tt.mockFn(mockRepo)
+
+			err := NewPublisher(mockRepo).Publish(context.Background(), EventUserDeleted, tt.payload)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+)
+
+// claimSQL leases the oldest due pending events by pushing back their next
+// attempt, skipping events leased by other dispatchers.
+const claimSQL = `
+UPDATE outbox_events SET attempts = attempts + 1, next_attempt_at = ?
+WHERE id IN (
+	SELECT id FROM outbox_events
+	WHERE status = ? AND next_attempt_at <= ?
+	ORDER BY next_attempt_at
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+//go:generate mockgen -destination=./repository_mock.go -package=outbox github.com/PakornBank/go-backend-example/internal/outbox Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	Create(ctx context.Context, event *model.OutboxEvent) error
+	Claim(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]model.OutboxEvent, error)
+	MarkDelivered(ctx context.Context, id string, attempt int, at time.Time) error
+	MarkFailed(ctx context.Context, id string, attempt int, lastError string, nextAttemptAt time.Time, dead bool) error
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// repository is a struct that provides methods to interact with the outbox events in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// Create inserts a new outbox event record into the database.
+func (r *repository) Create(ctx context.Context, event *model.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(event).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to create outbox event", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// Claim leases at most limit pending events due at now, oldest first, and
+// counts the attempt. Leased events are not claimed again before the lease
+// expires, so an event whose dispatcher dies is retried once it does.
+func (r *repository) Claim(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]model.OutboxEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var events []model.OutboxEvent
+
+	err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).
+		Raw(claimSQL, now.Add(lease), StatusPending, now, limit).Scan(&events).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to claim outbox events", slog.Any("error", err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkDelivered marks the event with the given ID as delivered by the given
+// attempt. It returns ErrLeaseLost, changing nothing, when the event has been
+// claimed again since.
+func (r *repository) MarkDelivered(ctx context.Context, id string, attempt int, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.OutboxEvent{}).Where("id = ? AND attempts = ?", id, attempt).
+		Updates(map[string]interface{}{"status": StatusDelivered, "delivered_at": at, "last_error": ""})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to mark outbox event delivered", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// MarkFailed records the error of the given attempt of the event with the
+// given ID and schedules the next one, or moves the event to the dead-letter
+// state when dead is set. It returns ErrLeaseLost, changing nothing, when the
+// event has been claimed again since.
+func (r *repository) MarkFailed(ctx context.Context, id string, attempt int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	status := StatusPending
+	if dead {
+		status = StatusDead
+	}
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.OutboxEvent{}).Where("id = ? AND attempts = ?", id, attempt).
+		Updates(map[string]interface{}{"status": status, "next_attempt_at": nextAttemptAt, "last_error": lastError})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to mark outbox event failed", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// DeleteFinished removes the events delivered before the given time, and the
+// dead-lettered ones created before it, and returns how many.
+func (r *repository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("(status = ? AND delivered_at < ?) OR (status = ? AND created_at < ?)", StatusDelivered, before, StatusDead, before).
+		Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to delete finished outbox events", slog.Any("error", result.Error))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,293 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	outboxRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, outboxRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	outboxRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, outboxRepo)
+	assert.Equal(t, gormDB, outboxRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, outboxRepo.(*repository).timeout)
+}
+
+func Test_repository_Create(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "event created",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "outbox_events"`).
+					WithArgs(EventUserRegistered, `{"user_id":"id"}`, StatusPending, 0, now, "", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "outbox_events"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, outboxRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			event := &model.OutboxEvent{
+				Type:          EventUserRegistered,
+				Payload:       `{"user_id":"id"}`,
+				Status:        StatusPending,
+				NextAttemptAt: now,
+			}
+			err := outboxRepo.Create(context.Background(), event)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, uuid.Nil, event.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Claim(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantLen int
+		errType error
+	}{
+		{
+			name: "events claimed",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "type", "attempts"}).
+					AddRow(uuid.New(), EventUserRegistered, 1).
+					AddRow(uuid.New(), EventLoginFailed, 3)
+				sqlMock.ExpectQuery(`UPDATE outbox_events SET attempts = attempts \+ 1, next_attempt_at = \$1\s+WHERE id IN \(\s+SELECT id FROM outbox_events\s+WHERE status = \$2 AND next_attempt_at <= \$3\s+ORDER BY next_attempt_at\s+LIMIT \$4\s+FOR UPDATE SKIP LOCKED\s+\)\s+RETURNING \*`).
+					WithArgs(now.Add(time.Minute), StatusPending, now, 10).
+					WillReturnRows(rows)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`UPDATE outbox_events`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, outboxRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := outboxRepo.Claim(context.Background(), 10, now, time.Minute)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkDelivered(t *testing.T) {
+	id := uuid.New().String()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "event marked delivered",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events" SET "delivered_at"=\$1,"last_error"=\$2,"status"=\$3 WHERE id = \$4 AND attempts = \$5`).
+					WithArgs(now, "", StatusDelivered, id, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "event claimed again",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: ErrLeaseLost,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, outboxRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := outboxRepo.MarkDelivered(context.Background(), id, 2, now)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkFailed(t *testing.T) {
+	id := uuid.New().String()
+	next := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name       string
+		dead       bool
+		mockFn     func(sqlmock.Sqlmock)
+		errType    error
+		wantStatus string
+	}{
+		{
+			name: "retry scheduled",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events" SET "last_error"=\$1,"next_attempt_at"=\$2,"status"=\$3 WHERE id = \$4 AND attempts = \$5`).
+					WithArgs("handler: boom", next, StatusPending, id, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "event dead-lettered",
+			dead: true,
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events"`).
+					WithArgs("handler: boom", next, StatusDead, id, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "event claimed again",
+			dead: true,
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: ErrLeaseLost,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "outbox_events"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, outboxRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := outboxRepo.MarkFailed(context.Background(), id, 2, "handler: boom", next, tt.dead)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_DeleteFinished(t *testing.T) {
+	before := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		want    int64
+		errType error
+	}{
+		{
+			name: "events deleted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "outbox_events" WHERE \(status = \$1 AND delivered_at < \$2\) OR \(status = \$3 AND created_at < \$4\)`).
+					WithArgs(StatusDelivered, before, StatusDead, before).
+					WillReturnResult(sqlmock.NewResult(0, 5))
+				sqlMock.ExpectCommit()
+			},
+			want: 5,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "outbox_events"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, outboxRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := outboxRepo.DeleteFinished(context.Background(), before)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
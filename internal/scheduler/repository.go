@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"gorm.io/gorm"
+)
+
+// acquireSQL takes the lease with the given name when it is free, expired or
+// already held by the holder, extending it by the given number of
+// milliseconds. Expiry is judged by the clock of the database, so that the
+// clocks of the instances do not need to agree.
+const acquireSQL = `
+INSERT INTO scheduler_leases (name, holder, expires_at)
+VALUES (?, ?, now() + ? * interval '1 millisecond')
+ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+WHERE scheduler_leases.holder = EXCLUDED.holder OR scheduler_leases.expires_at <= now()`
+
+// releaseSQL ends the lease with the given name when it is held by the holder.
+const releaseSQL = `UPDATE scheduler_leases SET expires_at = now() WHERE name = ? AND holder = ?`
+
+//go:generate mockgen -destination=./repository_mock.go -package=scheduler github.com/PakornBank/go-backend-example/internal/scheduler Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	Acquire(ctx context.Context, name, holder string, lease time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+}
+
+// repository is a struct that provides methods to interact with the scheduler leases in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// Acquire takes or renews the lease with the given name for holder, and
+// reports whether holder has it for the next lease.
+func (r *repository) Acquire(ctx context.Context, name, holder string, lease time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).
+		Exec(acquireSQL, name, holder, lease.Milliseconds())
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to acquire scheduler lease", slog.Any("error", result.Error))
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Release ends the lease with the given name if holder has it, so that
+// another instance can take it over at once.
+func (r *repository) Release(ctx context.Context, name, holder string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).Exec(releaseSQL, name, holder).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to release scheduler lease", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	schedulerRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, schedulerRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	schedulerRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, schedulerRepo)
+	assert.Equal(t, gormDB, schedulerRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, schedulerRepo.(*repository).timeout)
+}
+
+func Test_repository_Acquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		mockFn  func(*sqlmock.ExpectedExec)
+		want    bool
+		errType error
+	}{
+		{
+			name:   "lease acquired",
+			mockFn: func(e *sqlmock.ExpectedExec) { e.WillReturnResult(sqlmock.NewResult(0, 1)) },
+			want:   true,
+		},
+		{
+			name:   "lease held by another instance",
+			mockFn: func(e *sqlmock.ExpectedExec) { e.WillReturnResult(sqlmock.NewResult(0, 0)) },
+			want:   false,
+		},
+		{
+			name:    "database error",
+			mockFn:  func(e *sqlmock.ExpectedExec) { e.WillReturnError(sql.ErrConnDone) },
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, schedulerRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock.ExpectExec(`INSERT INTO scheduler_leases .* ON CONFLICT \(name\) DO UPDATE`).
+				WithArgs("scheduler", "host-1", int64(30000)))
+
+			got, err := schedulerRepo.Acquire(context.Background(), "scheduler", "host-1", 30*time.Second)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Release(t *testing.T) {
+	tests := []struct {
+		name    string
+		mockFn  func(*sqlmock.ExpectedExec)
+		errType error
+	}{
+		{
+			name:   "lease released",
+			mockFn: func(e *sqlmock.ExpectedExec) { e.WillReturnResult(sqlmock.NewResult(0, 1)) },
+		},
+		{
+			name:    "database error",
+			mockFn:  func(e *sqlmock.ExpectedExec) { e.WillReturnError(sql.ErrConnDone) },
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, schedulerRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock.ExpectExec(`UPDATE scheduler_leases SET expires_at = now\(\) WHERE name = \$1 AND holder = \$2`).
+				WithArgs("scheduler", "host-1"))
+
+			err := schedulerRepo.Release(context.Background(), "scheduler", "host-1")
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
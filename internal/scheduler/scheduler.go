@@ -0,0 +1,248 @@
+// Package scheduler runs periodic tasks on cron schedules. Every instance
+// runs a Scheduler, but only the one holding the lease in the
+// scheduler_leases table runs the tasks, so that a task runs once per
+// activation however many replicas are deployed.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/cron"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/common/worker"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// leaseName is the name of the lease held by the leader.
+const leaseName = "scheduler"
+
+// tickInterval is how often the scheduler checks for due tasks.
+const tickInterval = time.Second
+
+// Task is the work of a scheduled task. Its context is cancelled when the
+// instance stops leading.
+type Task func(ctx context.Context) error
+
+// task is a registered task and the time of its next run.
+type task struct {
+	name     string
+	schedule *cron.Schedule
+	fn       Task
+	next     time.Time
+	running  atomic.Bool
+}
+
+// Scheduler runs the registered tasks when their schedule fires while this
+// instance leads. The leader renews its lease every third of the lease, and
+// its running tasks are cancelled as soon as the lease expires unrenewed.
+// An instance taking over schedules the next runs from the time it took the
+// lease: runs that fell due while no instance led are skipped.
+type Scheduler struct {
+	repository Repository
+	config     *config.Store
+	metrics    *metrics.Metrics
+	holder     string
+	tasks      []*task
+	now        func() time.Time
+
+	leader    bool
+	expiresAt time.Time
+	renewAt   time.Time
+	expiry    *time.Timer
+	runCtx    context.Context
+	stopRuns  context.CancelFunc
+	runs      sync.WaitGroup
+}
+
+// NewScheduler creates a new Scheduler with the provided repository, configuration and metrics.
+func NewScheduler(repository Repository, config *config.Store, m *metrics.Metrics) *Scheduler {
+	return &Scheduler{
+		repository: repository,
+		config:     config,
+		metrics:    m,
+		holder:     instanceID(),
+		now:        time.Now,
+	}
+}
+
+// Register adds the task fn, identified by name in logs and metrics, to run
+// whenever the cron expression fires. It must not be called once Run has started.
+func (s *Scheduler) Register(name, expr string, fn Task) error {
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of task %s: %w", name, err)
+	}
+	s.tasks = append(s.tasks, &task{name: name, schedule: schedule, fn: fn})
+	return nil
+}
+
+// Run competes for the lease and runs due tasks while leading until ctx is
+// done. Tasks running when ctx is done are finished before Run releases the
+// lease and returns.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.stop(ctx)
+			return
+		case <-time.After(tickInterval):
+		}
+	}
+}
+
+// tick renews or takes the lease when due and starts the tasks due at now.
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.now()
+	if !s.leader || !now.Before(s.renewAt) {
+		s.elect(ctx)
+		now = s.now()
+	}
+	if !s.leader {
+		return
+	}
+
+	for _, t := range s.tasks {
+		if t.next.IsZero() || now.Before(t.next) {
+			continue
+		}
+		s.start(t, t.next)
+		t.next = t.schedule.Next(now)
+	}
+}
+
+// elect takes or renews the lease, and starts or stops leading accordingly.
+func (s *Scheduler) elect(ctx context.Context) {
+	lease := s.config.Get().SchedulerLease
+	// The lease runs from before Acquire, at the latest.
+	start := s.now()
+	ok, err := s.repository.Acquire(ctx, leaseName, s.holder, lease)
+	// Acquire takes up to the repository timeout, the lease may have expired meanwhile.
+	now := s.now()
+	if s.leader && !now.Before(s.expiresAt) {
+		s.stepDown(ctx, "lease expired")
+	}
+
+	switch {
+	case err != nil:
+		// Keep leading until the lease expires, the next tick tries again.
+	case ok:
+		s.expiresAt = start.Add(lease)
+		s.renewAt = start.Add(lease / 3)
+		// The timer may have fired since the check above, cancelling the runs:
+		// lead again with fresh ones.
+		if s.leader && !s.expiry.Reset(s.expiresAt.Sub(now)) {
+			s.stepDown(ctx, "lease expired")
+		}
+		if !s.leader {
+			s.lead(ctx, now)
+		}
+	case s.leader:
+		s.stepDown(ctx, "lease taken over")
+	}
+}
+
+// lead makes this instance the leader and schedules the next run of every
+// task. The runs are cancelled once the lease expires, unless it is renewed.
+func (s *Scheduler) lead(ctx context.Context, now time.Time) {
+	s.leader = true
+	// Runs outlive ctx so that they finish on shutdown, but not the leadership.
+	s.runCtx, s.stopRuns = context.WithCancel(context.WithoutCancel(ctx))
+	s.expiry = time.AfterFunc(s.expiresAt.Sub(now), s.stopRuns)
+	for _, t := range s.tasks {
+		t.next = t.schedule.Next(now)
+	}
+	s.metrics.SetSchedulerLeader(true)
+	logger.FromContext(ctx).Info("leading scheduled tasks", slog.String("holder", s.holder))
+}
+
+// stepDown stops leading and cancels the running tasks.
+func (s *Scheduler) stepDown(ctx context.Context, reason string) {
+	s.leader = false
+	s.expiry.Stop()
+	s.stopRuns()
+	s.metrics.SetSchedulerLeader(false)
+	logger.FromContext(ctx).Warn("stopped leading scheduled tasks", slog.String("reason", reason))
+}
+
+// stop waits for the running tasks and releases the lease.
+func (s *Scheduler) stop(ctx context.Context) {
+	s.runs.Wait()
+	if !s.leader {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if err := s.repository.Release(ctx, leaseName, s.holder); err == nil {
+		s.leader = false
+		s.expiry.Stop()
+		s.stopRuns()
+		s.metrics.SetSchedulerLeader(false)
+		logger.FromContext(ctx).Info("released scheduler lease")
+	}
+}
+
+// start runs t in the background for its activation at the given time,
+// unless its previous run has not finished yet.
+func (s *Scheduler) start(t *task, scheduledAt time.Time) {
+	log := logger.FromContext(s.runCtx).With(slog.String("task", t.name))
+	if !t.running.CompareAndSwap(false, true) {
+		log.Warn("scheduled task skipped, its previous run has not finished", slog.Time("scheduled_at", scheduledAt))
+		return
+	}
+
+	ctx := s.runCtx
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer t.running.Store(false)
+		s.run(ctx, t, scheduledAt)
+	}()
+}
+
+// run runs t and records the outcome.
+func (s *Scheduler) run(ctx context.Context, t *task, scheduledAt time.Time) {
+	ctx, span := tracing.Start(ctx, "scheduler.Scheduler.run")
+	defer span.End()
+	span.SetAttributes(attribute.String("scheduler.task", t.name))
+
+	log := logger.FromContext(ctx).With(slog.String("task", t.name), slog.Time("scheduled_at", scheduledAt))
+
+	start := s.now()
+	err := worker.Call(ctx, t.fn)
+	end := s.now()
+	if err != nil {
+		tracing.RecordError(span, err)
+		log.Error("scheduled task failed", slog.Duration("duration", end.Sub(start)), slog.Any("error", err))
+		s.metrics.ObserveScheduledRun(t.name, metrics.ScheduledRunFailed, end)
+		return
+	}
+
+	log.Info("scheduled task finished", slog.Duration("duration", end.Sub(start)))
+	s.metrics.ObserveScheduledRun(t.name, metrics.ScheduledRunSucceeded, end)
+}
+
+// instanceID identifies this instance as the holder of the lease: its host
+// name, the name of the pod on Kubernetes, with a random suffix telling apart
+// restarts and instances sharing a host.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
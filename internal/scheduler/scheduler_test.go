@@ -0,0 +1,261 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func setupSchedulerTest(t *testing.T) (*Scheduler, *MockRepository, *clock) {
+	mockRepo := NewMockRepository(gomock.NewController(t))
+	s := NewScheduler(mockRepo, config.NewStore(&config.Config{SchedulerLease: 2 * time.Minute}), metrics.New())
+	s.holder = "host-1"
+	c := &clock{t: testNow}
+	s.now = c.now
+	return s, mockRepo, c
+}
+
+func TestNewScheduler(t *testing.T) {
+	mockRepo := new(MockRepository)
+	store := config.NewStore(&config.Config{})
+	m := metrics.New()
+	s := NewScheduler(mockRepo, store, m)
+
+	assert.Equal(t, mockRepo, s.repository)
+	assert.Equal(t, store, s.config)
+	assert.Equal(t, m, s.metrics)
+	assert.NotEmpty(t, s.holder)
+	assert.Empty(t, s.tasks)
+	assert.NotNil(t, s.now)
+}
+
+func TestScheduler_Register(t *testing.T) {
+	s, _, _ := setupSchedulerTest(t)
+
+	assert.NoError(t, s.Register("valid", "*/5 * * * *", func(context.Context) error { return nil }))
+	assert.ErrorContains(t, s.Register("invalid", "* * *", func(context.Context) error { return nil }), "invalid schedule of task invalid")
+	assert.Len(t, s.tasks, 1)
+	assert.Equal(t, "valid", s.tasks[0].name)
+}
+
+func TestScheduler_tick(t *testing.T) {
+	tests := []struct {
+		name       string
+		taskErr    error
+		panics     bool
+		wantResult string
+	}{
+		{name: "succeeded", wantResult: metrics.ScheduledRunSucceeded},
+		{name: "failed", taskErr: errors.New("boom"), wantResult: metrics.ScheduledRunFailed},
+		{name: "panicked", panics: true, wantResult: metrics.ScheduledRunFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo, c := setupSchedulerTest(t)
+			runs := 0
+			assert.NoError(t, s.Register("purge", "* * * * *", func(context.Context) error {
+				runs++
+				if tt.panics {
+					panic("boom")
+				}
+				return tt.taskErr
+			}))
+			mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(true, nil).Times(2)
+
+			// Taking the lease schedules the task at the next minute.
+			s.tick(context.Background())
+			assert.True(t, s.leader)
+			assert.Equal(t, testNow.Add(time.Minute), s.tasks[0].next)
+
+			// The lease is renewed after a third of it, and the task runs when due.
+			c.t = testNow.Add(time.Minute)
+			s.tick(context.Background())
+			s.runs.Wait()
+
+			assert.Equal(t, 1, runs)
+			assert.Equal(t, testNow.Add(2*time.Minute), s.tasks[0].next)
+			expected := fmt.Sprintf(`
+# HELP go_auth_api_scheduler_runs_total Total number of runs of scheduled tasks by task and result.
+# TYPE go_auth_api_scheduler_runs_total counter
+go_auth_api_scheduler_runs_total{result="%s",task="purge"} 1
+`, tt.wantResult)
+			assert.NoError(t, testutil.GatherAndCompare(s.metrics.Registry(), strings.NewReader(expected), "go_auth_api_scheduler_runs_total"))
+		})
+	}
+}
+
+func TestScheduler_tick_NotLeader(t *testing.T) {
+	s, mockRepo, c := setupSchedulerTest(t)
+	assert.NoError(t, s.Register("purge", "* * * * *", func(context.Context) error {
+		t.Error("task ran without the lease")
+		return nil
+	}))
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(false, nil)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(false, sql.ErrConnDone)
+
+	s.tick(context.Background())
+	c.t = testNow.Add(time.Minute)
+	s.tick(context.Background())
+	s.runs.Wait()
+
+	assert.False(t, s.leader)
+}
+
+func TestScheduler_tick_LeaseTakenOver(t *testing.T) {
+	s, mockRepo, c := setupSchedulerTest(t)
+	started := make(chan struct{})
+	assert.NoError(t, s.Register("purge", "* * * * *", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(true, nil).Times(2)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(false, nil)
+
+	s.tick(context.Background())
+	c.t = testNow.Add(time.Minute)
+	s.tick(context.Background())
+	<-started
+
+	// Losing the lease cancels the running task.
+	c.t = testNow.Add(time.Minute + 40*time.Second)
+	s.tick(context.Background())
+	s.runs.Wait()
+
+	assert.False(t, s.leader)
+	expected := `
+# HELP go_auth_api_scheduler_runs_total Total number of runs of scheduled tasks by task and result.
+# TYPE go_auth_api_scheduler_runs_total counter
+go_auth_api_scheduler_runs_total{result="failed",task="purge"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(s.metrics.Registry(), strings.NewReader(expected), "go_auth_api_scheduler_runs_total"))
+}
+
+func TestScheduler_tick_RenewalFailed(t *testing.T) {
+	s, mockRepo, c := setupSchedulerTest(t)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(true, nil)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(false, sql.ErrConnDone).Times(2)
+
+	s.tick(context.Background())
+
+	// A failed renewal keeps the leadership while the lease lasts.
+	c.t = testNow.Add(40 * time.Second)
+	s.tick(context.Background())
+	assert.True(t, s.leader)
+
+	c.t = testNow.Add(2 * time.Minute)
+	s.tick(context.Background())
+	assert.False(t, s.leader)
+}
+
+func TestScheduler_tick_LeaseExpiredDuringAcquire(t *testing.T) {
+	s, mockRepo, c := setupSchedulerTest(t)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(true, nil)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).DoAndReturn(
+		func(context.Context, string, string, time.Duration) (bool, error) {
+			c.t = testNow.Add(2 * time.Minute)
+			return false, sql.ErrConnDone
+		})
+
+	s.tick(context.Background())
+
+	// The failed renewal started before the lease expired but returned after.
+	c.t = testNow.Add(40 * time.Second)
+	s.tick(context.Background())
+
+	assert.False(t, s.leader)
+	assert.Error(t, s.runCtx.Err())
+}
+
+func TestScheduler_tick_LeaseExpiry(t *testing.T) {
+	s, mockRepo, _ := setupSchedulerTest(t)
+	s.config = config.NewStore(&config.Config{SchedulerLease: 50 * time.Millisecond})
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 50*time.Millisecond).Return(true, nil)
+
+	s.tick(context.Background())
+
+	// Running tasks are cancelled when the lease expires, before the next tick.
+	select {
+	case <-s.runCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("runs were not cancelled when the lease expired")
+	}
+}
+
+func TestScheduler_tick_RenewedAfterExpiry(t *testing.T) {
+	s, mockRepo, c := setupSchedulerTest(t)
+	s.config = config.NewStore(&config.Config{SchedulerLease: 50 * time.Millisecond})
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 50*time.Millisecond).Return(true, nil)
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 50*time.Millisecond).DoAndReturn(
+		func(context.Context, string, string, time.Duration) (bool, error) {
+			// The expiry timer fires while the renewal runs.
+			<-s.runCtx.Done()
+			return true, nil
+		})
+
+	s.tick(context.Background())
+	expired := s.runCtx
+	c.t = testNow.Add(20 * time.Millisecond)
+	s.tick(context.Background())
+
+	// The instance leads again with runs that are not cancelled.
+	assert.True(t, s.leader)
+	assert.Error(t, expired.Err())
+	assert.NoError(t, s.runCtx.Err())
+	s.expiry.Stop()
+}
+
+func TestScheduler_tick_SkipsOverlappingRun(t *testing.T) {
+	s, mockRepo, c := setupSchedulerTest(t)
+	release := make(chan struct{})
+	runs := 0
+	assert.NoError(t, s.Register("purge", "* * * * *", func(context.Context) error {
+		runs++
+		<-release
+		return nil
+	}))
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).Return(true, nil).Times(3)
+
+	s.tick(context.Background())
+	c.t = testNow.Add(time.Minute)
+	s.tick(context.Background())
+	c.t = testNow.Add(2 * time.Minute)
+	s.tick(context.Background())
+	close(release)
+	s.runs.Wait()
+
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, testNow.Add(3*time.Minute), s.tasks[0].next)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s, mockRepo, _ := setupSchedulerTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepo.EXPECT().Acquire(gomock.Any(), leaseName, "host-1", 2*time.Minute).DoAndReturn(
+		func(context.Context, string, string, time.Duration) (bool, error) {
+			cancel()
+			return true, nil
+		})
+	mockRepo.EXPECT().Release(gomock.Any(), leaseName, "host-1").Return(nil)
+
+	s.Run(ctx)
+
+	assert.False(t, s.leader)
+}
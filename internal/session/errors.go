@@ -0,0 +1,11 @@
+package session
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the user has no active session with the given ID.
+	ErrNotFound = errors.New("session not found")
+	// ErrInvalid is returned when validating a session that does not exist, belongs to another user,
+	// has expired or has been revoked.
+	ErrInvalid = errors.New("session expired or revoked")
+)
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=./repository_mock.go -package=session github.com/PakornBank/go-backend-example/internal/session Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	Create(ctx context.Context, session *model.Session) error
+	FindByID(ctx context.Context, id string) (*model.Session, error)
+	ListActive(ctx context.Context, userID string, now time.Time) ([]model.Session, error)
+	Revoke(ctx context.Context, userID, id string, at time.Time) error
+	Touch(ctx context.Context, id string, at time.Time) error
+	DeleteInactive(ctx context.Context, before time.Time) (int64, error)
+}
+
+// repository is a struct that provides methods to interact with the session data in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// Create inserts a new session record into the database.
+func (r *repository) Create(ctx context.Context, session *model.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(session).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to create session", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// FindByID retrieves a session from the database by its ID. It reads from
+// the primary, so that a session revoked a moment ago is not found active on
+// a lagging replica.
+func (r *repository) FindByID(ctx context.Context, id string) (*model.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var session model.Session
+
+	if err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).Where("id = ?", id).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.FromContext(ctx).Error("failed to find session", slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ListActive retrieves the sessions of the user that are neither revoked nor expired, most recently used first.
+func (r *repository) ListActive(ctx context.Context, userID string, now time.Time) ([]model.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var sessions []model.Session
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to list sessions", slog.Any("error", err))
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Revoke marks the session of the user as revoked. It returns ErrNotFound
+// when the user has no such session or it is already revoked.
+func (r *repository) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to revoke session", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Touch records that the session was used at the given time.
+func (r *repository) Touch(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.Session{}).
+		Where("id = ?", id).Update("last_seen_at", at).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to touch session", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteInactive removes the sessions that expired or were revoked before the given time and returns how many.
+func (r *repository) DeleteInactive(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("expires_at <= ? OR revoked_at <= ?", before, before).
+		Delete(&model.Session{})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to delete inactive sessions", slog.Any("error", result.Error))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,302 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	sessionRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, sessionRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	sessionRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, sessionRepo)
+	assert.Equal(t, gormDB, sessionRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, sessionRepo.(*repository).timeout)
+}
+
+func Test_repository_Create(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "session created",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(sessionID, now)
+				sqlMock.ExpectQuery(`INSERT INTO "sessions"`).
+					WithArgs(userID, "curl/8.0", "curl", "192.0.2.1", now, now.Add(time.Hour), nil).
+					WillReturnRows(rows)
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "sessions"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, sessionRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			session := &model.Session{
+				UserID:     userID,
+				UserAgent:  "curl/8.0",
+				Device:     "curl",
+				IP:         "192.0.2.1",
+				LastSeenAt: now,
+				ExpiresAt:  now.Add(time.Hour),
+			}
+			err := sessionRepo.Create(context.Background(), session)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, sessionID, session.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_FindByID(t *testing.T) {
+	sessionID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "session found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "device"}).AddRow(sessionID, userID, "curl")
+				sqlMock.ExpectQuery(`SELECT .* FROM "sessions" WHERE id = \$1 (.+) LIMIT \$2`).
+					WithArgs(sessionID.String(), 1).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "session not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT .* FROM "sessions"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, sessionRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := sessionRepo.FindByID(context.Background(), sessionID.String())
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, sessionID, got.ID)
+				assert.Equal(t, userID, got.UserID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ListActive(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantLen int
+		errType error
+	}{
+		{
+			name: "active sessions listed",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "user_id"}).
+					AddRow(uuid.New(), userID).
+					AddRow(uuid.New(), userID)
+				sqlMock.ExpectQuery(`SELECT \* FROM "sessions" WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > \$2 ORDER BY last_seen_at DESC`).
+					WithArgs(userID.String(), now).
+					WillReturnRows(rows)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "sessions"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, sessionRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := sessionRepo.ListActive(context.Background(), userID.String(), now)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Revoke(t *testing.T) {
+	userID := uuid.New().String()
+	sessionID := uuid.New().String()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "session revoked",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "sessions" SET "revoked_at"=\$1 WHERE id = \$2 AND user_id = \$3 AND revoked_at IS NULL`).
+					WithArgs(now, sessionID, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "session not found or already revoked",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "sessions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: ErrNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "sessions"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, sessionRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := sessionRepo.Revoke(context.Background(), userID, sessionID, now)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Touch(t *testing.T) {
+	sessionID := uuid.New().String()
+	now := time.Now()
+
+	sqlMock, sessionRepo := setupRepositoryTest(t)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`UPDATE "sessions" SET "last_seen_at"=\$1 WHERE id = \$2`).
+		WithArgs(now, sessionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	assert.NoError(t, sessionRepo.Touch(context.Background(), sessionID, now))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_DeleteInactive(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		want    int64
+		errType error
+	}{
+		{
+			name: "sessions deleted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "sessions" WHERE expires_at <= \$1 OR revoked_at <= \$2`).
+					WithArgs(now, now).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				sqlMock.ExpectCommit()
+			},
+			want: 3,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "sessions"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, sessionRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := sessionRepo.DeleteInactive(context.Background(), now)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package session manages the login sessions of users.
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// touchInterval is how stale the last-seen time of a session may become
+// before a request updates it, so that not every request writes.
+const touchInterval = time.Minute
+
+// maxUserAgentLength is the longest user agent stored with a session.
+const maxUserAgentLength = 512
+
+// Client describes the device a login comes from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Device summarizes the user agent of the client, such as "Chrome on macOS".
+func (c Client) Device() string {
+	return describeDevice(c.UserAgent)
+}
+
+// Fingerprint identifies the device of the client across logins. It combines
+// the browser and platform with the network of the IP address, a /24 for IPv4
+// and a /48 for IPv6, so that browser updates and address changes within the
+// same network do not make a known device look new.
+func (c Client) Fingerprint() string {
+	network := c.IP
+	if addr, err := netip.ParseAddr(c.IP); err == nil {
+		bits := 48
+		if addr.Unmap().Is4() {
+			addr, bits = addr.Unmap(), 24
+		}
+		if prefix, err := addr.Prefix(bits); err == nil {
+			network = prefix.String()
+		}
+	}
+	sum := sha256.Sum256([]byte(c.Device() + "|" + network))
+	return hex.EncodeToString(sum[:])
+}
+
+//go:generate mockgen -destination=./service_mock.go -package=session github.com/PakornBank/go-backend-example/internal/session Service
+
+// Service defines the methods that a service must implement.
+type Service interface {
+	Create(ctx context.Context, userID uuid.UUID, client Client, expiresAt time.Time) (*model.Session, error)
+	List(ctx context.Context, userID string) ([]model.Session, error)
+	Revoke(ctx context.Context, userID, sessionID string) error
+	Validate(ctx context.Context, userID, sessionID string) error
+	PurgeInactive(ctx context.Context) error
+}
+
+// service is a struct that provides methods to interact with the session service.
+type service struct {
+	repository Repository
+	tx         database.TxManager
+	auditLog   audit.Service
+}
+
+// NewService creates a new instance of service with the provided repository, transaction manager and audit service.
+func NewService(repository Repository, tx database.TxManager, auditLog audit.Service) Service {
+	return &service{repository: repository, tx: tx, auditLog: auditLog}
+}
+
+// Create records a new session of the user, valid until expiresAt.
+func (s *service) Create(ctx context.Context, userID uuid.UUID, client Client, expiresAt time.Time) (*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "session.Service.Create")
+	defer span.End()
+
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now()
+	session := &model.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		Device:     Client{UserAgent: userAgent}.Device(),
+		IP:         client.IP,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.repository.Create(ctx, session); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("session_id", session.ID.String()))
+	return session, nil
+}
+
+// List returns the sessions of the user that are neither revoked nor expired.
+func (s *service) List(ctx context.Context, userID string) ([]model.Session, error) {
+	ctx, span := tracing.Start(ctx, "session.Service.List")
+	defer span.End()
+
+	sessions, err := s.repository.ListActive(ctx, userID, time.Now())
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Revoke revokes the session of the user, invalidating every token issued for it.
+func (s *service) Revoke(ctx context.Context, userID, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "session.Service.Revoke")
+	defer span.End()
+	span.SetAttributes(attribute.String("session_id", sessionID))
+
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrNotFound
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.Revoke(ctx, userID, sessionID, time.Now()); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, audit.Event{
+			ActorID:    userID,
+			Action:     audit.ActionSessionRevoked,
+			TargetType: audit.TargetSession,
+			TargetID:   sessionID,
+		})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	logger.FromContext(ctx).Info("session revoked", slog.String("user_id", userID), slog.String("session_id", sessionID))
+	return nil
+}
+
+// Validate returns ErrInvalid unless the session exists, belongs to the user
+// and is neither revoked nor expired. It records the use of the session.
+func (s *service) Validate(ctx context.Context, userID, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "session.Service.Validate")
+	defer span.End()
+
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrInvalid
+	}
+
+	session, err := s.repository.FindByID(ctx, sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalid
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	now := time.Now()
+	if session.UserID.String() != userID || session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return ErrInvalid
+	}
+
+	if now.Sub(session.LastSeenAt) >= touchInterval {
+		if err := s.repository.Touch(ctx, sessionID, now); err != nil {
+			logger.FromContext(ctx).Warn("failed to record session use", slog.String("session_id", sessionID), slog.Any("error", err))
+		}
+	}
+
+	return nil
+}
+
+// PurgeInactive removes the sessions that have expired or been revoked, which
+// can no longer be used nor are listed.
+func (s *service) PurgeInactive(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "session.Service.PurgeInactive")
+	defer span.End()
+
+	n, err := s.repository.DeleteInactive(ctx, time.Now())
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	span.SetAttributes(attribute.Int64("sessions.deleted", n))
+	logger.FromContext(ctx).Info("inactive sessions purged", slog.Int64("count", n))
+	return nil
+}
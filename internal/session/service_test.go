@@ -0,0 +1,372 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func setupServiceTest(t *testing.T) (Service, *MockRepository) {
+	sessionService, mockRepo, mockAudit := setupServiceTestWithAudit(t)
+	mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return sessionService, mockRepo
+}
+
+func setupServiceTestWithAudit(t *testing.T) (Service, *MockRepository, *audit.MockService) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockRepository(ctrl)
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	mockAudit := audit.NewMockService(ctrl)
+	return &service{repository: mockRepo, tx: mockTx, auditLog: mockAudit}, mockRepo, mockAudit
+}
+
+func TestNewService(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTx := new(database.MockTxManager)
+	mockAudit := new(audit.MockService)
+	sessionService := NewService(mockRepo, mockTx, mockAudit)
+
+	assert.NotNil(t, sessionService)
+	assert.Equal(t, mockRepo, sessionService.(*service).repository)
+	assert.Equal(t, mockTx, sessionService.(*service).tx)
+	assert.Equal(t, mockAudit, sessionService.(*service).auditLog)
+}
+
+func Test_service_Create(t *testing.T) {
+	userID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		client     Client
+		mockFn     func(*MockRepository)
+		wantDevice string
+		wantAgent  string
+		wantErr    bool
+	}{
+		{
+			name:   "session created",
+			client: Client{UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) Chrome/129.0 Safari/537.36", IP: "192.0.2.1"},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *model.Session) error {
+					s.ID = uuid.New()
+					return nil
+				})
+			},
+			wantDevice: "Chrome on macOS",
+			wantAgent:  "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) Chrome/129.0 Safari/537.36",
+		},
+		{
+			name:   "long user agent truncated",
+			client: Client{UserAgent: strings.Repeat("x", maxUserAgentLength+10)},
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantDevice: "Unknown device",
+			wantAgent:  strings.Repeat("x", maxUserAgentLength),
+		},
+		{
+			name: "repository error",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(gorm.ErrInvalidDB)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionService, mockRepo := setupServiceTest(t)
+			tt.mockFn(mockRepo)
+
+			got, err := sessionService.Create(context.Background(), userID, tt.client, expiresAt)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, userID, got.UserID)
+			assert.Equal(t, tt.wantAgent, got.UserAgent)
+			assert.Equal(t, tt.wantDevice, got.Device)
+			assert.Equal(t, tt.client.IP, got.IP)
+			assert.Equal(t, expiresAt, got.ExpiresAt)
+			assert.WithinDuration(t, time.Now(), got.LastSeenAt, time.Second)
+		})
+	}
+}
+
+func Test_service_Revoke(t *testing.T) {
+	userID := uuid.New().String()
+	sessionID := uuid.New().String()
+
+	tests := []struct {
+		name      string
+		sessionID string
+		mockFn    func(*MockRepository)
+		errType   error
+	}{
+		{
+			name:      "session revoked",
+			sessionID: sessionID,
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Revoke(gomock.Any(), userID, sessionID, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:      "session not found",
+			sessionID: sessionID,
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().Revoke(gomock.Any(), userID, sessionID, gomock.Any()).Return(ErrNotFound)
+			},
+			errType: ErrNotFound,
+		},
+		{
+			name:      "malformed session id",
+			sessionID: "not-a-uuid",
+			errType:   ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionService, mockRepo := setupServiceTest(t)
+			if tt.mockFn != nil {
+				tt.mockFn(mockRepo)
+			}
+
+			err := sessionService.Revoke(context.Background(), userID, tt.sessionID)
+			assert.ErrorIs(t, err, tt.errType)
+		})
+	}
+}
+
+func Test_service_Revoke_Audit(t *testing.T) {
+	userID := uuid.New().String()
+	sessionID := uuid.New().String()
+
+	tests := []struct {
+		name     string
+		auditErr error
+	}{
+		{name: "revocation recorded"},
+		{name: "revocation fails with its audit event", auditErr: gorm.ErrInvalidDB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionService, mockRepo, mockAudit := setupServiceTestWithAudit(t)
+			mockRepo.EXPECT().Revoke(gomock.Any(), userID, sessionID, gomock.Any()).Return(nil)
+			mockAudit.EXPECT().Record(gomock.Any(), audit.Event{
+				ActorID:    userID,
+				Action:     audit.ActionSessionRevoked,
+				TargetType: audit.TargetSession,
+				TargetID:   sessionID,
+			}).Return(tt.auditErr)
+
+			err := sessionService.Revoke(context.Background(), userID, sessionID)
+			assert.ErrorIs(t, err, tt.auditErr)
+		})
+	}
+}
+
+func Test_service_Validate(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	active := func() *model.Session {
+		return &model.Session{ID: sessionID, UserID: userID, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	}
+
+	tests := []struct {
+		name      string
+		userID    string
+		sessionID string
+		mockFn    func(*MockRepository)
+		errType   error
+		wantErr   bool
+	}{
+		{
+			name: "active session",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(active(), nil)
+			},
+		},
+		{
+			name: "stale last seen time is touched",
+			mockFn: func(mr *MockRepository) {
+				s := active()
+				s.LastSeenAt = now.Add(-2 * touchInterval)
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(s, nil)
+				mr.EXPECT().Touch(gomock.Any(), sessionID.String(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "touch failure does not reject the session",
+			mockFn: func(mr *MockRepository) {
+				s := active()
+				s.LastSeenAt = now.Add(-2 * touchInterval)
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(s, nil)
+				mr.EXPECT().Touch(gomock.Any(), sessionID.String(), gomock.Any()).Return(gorm.ErrInvalidDB)
+			},
+		},
+		{
+			name: "revoked session",
+			mockFn: func(mr *MockRepository) {
+				s := active()
+				s.RevokedAt = &revokedAt
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(s, nil)
+			},
+			errType: ErrInvalid,
+		},
+		{
+			name: "expired session",
+			mockFn: func(mr *MockRepository) {
+				s := active()
+				s.ExpiresAt = now.Add(-time.Second)
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(s, nil)
+			},
+			errType: ErrInvalid,
+		},
+		{
+			name:   "session of another user",
+			userID: uuid.New().String(),
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(active(), nil)
+			},
+			errType: ErrInvalid,
+		},
+		{
+			name: "unknown session",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errType: ErrInvalid,
+		},
+		{
+			name:      "malformed session id",
+			sessionID: "not-a-uuid",
+			errType:   ErrInvalid,
+		},
+		{
+			name: "repository error",
+			mockFn: func(mr *MockRepository) {
+				mr.EXPECT().FindByID(gomock.Any(), sessionID.String()).Return(nil, gorm.ErrInvalidDB)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionService, mockRepo := setupServiceTest(t)
+			if tt.mockFn != nil {
+				tt.mockFn(mockRepo)
+			}
+			if tt.userID == "" {
+				tt.userID = userID.String()
+			}
+			if tt.sessionID == "" {
+				tt.sessionID = sessionID.String()
+			}
+
+			err := sessionService.Validate(context.Background(), tt.userID, tt.sessionID)
+
+			switch {
+			case tt.errType != nil:
+				assert.ErrorIs(t, err, tt.errType)
+			case tt.wantErr:
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, ErrInvalid))
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_describeDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/537.36 Chrome/129.0 Safari/537.36", "Chrome on macOS"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/129.0 Safari/537.36 Edg/129.0", "Edge on Windows"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:130.0) Gecko/20100101 Firefox/130.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Version/17.0 Mobile Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Linux; Android 14) Chrome/129.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.4.0", "curl"},
+		{"Mozilla/5.0 (X11; Linux x86_64)", "Unknown browser on Linux"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, describeDevice(tt.userAgent))
+		})
+	}
+}
+
+func TestClient_Fingerprint(t *testing.T) {
+	const chrome = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) Chrome/129.0 Safari/537.36"
+	const chromeUpdated = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_1) Chrome/130.0 Safari/537.36"
+	const firefox = "Mozilla/5.0 (X11; Linux x86_64; rv:130.0) Gecko/20100101 Firefox/130.0"
+
+	tests := []struct {
+		name      string
+		a, b      Client
+		wantEqual bool
+	}{
+		{"browser update", Client{chrome, "192.0.2.1"}, Client{chromeUpdated, "192.0.2.1"}, true},
+		{"same IPv4 network", Client{chrome, "192.0.2.1"}, Client{chrome, "192.0.2.200"}, true},
+		{"IPv4-mapped IPv6 address", Client{chrome, "192.0.2.1"}, Client{chrome, "::ffff:192.0.2.9"}, true},
+		{"same IPv6 network", Client{chrome, "2001:db8:1:2::1"}, Client{chrome, "2001:db8:1:3::1"}, true},
+		{"other IPv4 network", Client{chrome, "192.0.2.1"}, Client{chrome, "198.51.100.1"}, false},
+		{"other IPv6 network", Client{chrome, "2001:db8:1::1"}, Client{chrome, "2001:db8:2::1"}, false},
+		{"other browser", Client{chrome, "192.0.2.1"}, Client{firefox, "192.0.2.1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, tt.a.Fingerprint(), 64)
+			assert.Equal(t, tt.wantEqual, tt.a.Fingerprint() == tt.b.Fingerprint())
+		})
+	}
+}
+
+func Test_service_PurgeInactive(t *testing.T) {
+	t.Run("purged", func(t *testing.T) {
+		sessionService, mockRepo := setupServiceTest(t)
+		before := time.Now()
+		mockRepo.EXPECT().DeleteInactive(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, at time.Time) (int64, error) {
+			assert.False(t, at.Before(before))
+			return 2, nil
+		})
+
+		assert.NoError(t, sessionService.PurgeInactive(context.Background()))
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		sessionService, mockRepo := setupServiceTest(t)
+		mockRepo.EXPECT().DeleteInactive(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("database error"))
+
+		assert.Error(t, sessionService.PurgeInactive(context.Background()))
+	})
+}
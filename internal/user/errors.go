@@ -0,0 +1,12 @@
+package user
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the user does not exist.
+	ErrNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when changing the email address to one that is already in use.
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrWrongPassword is returned when the password confirming a change is incorrect.
+	ErrWrongPassword = errors.New("password is incorrect")
+)
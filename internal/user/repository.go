@@ -2,17 +2,62 @@ package user
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
 	"gorm.io/gorm"
 )
 
+// activitySQL selects the users who are not disabled with the time they were
+// last active. A user is active when they register, log in or use a session.
+const activitySQL = `
+SELECT users.*, GREATEST(
+	users.created_at,
+	(SELECT max(created_at) FROM login_attempts WHERE user_id = users.id AND success),
+	(SELECT max(last_seen_at) FROM sessions WHERE user_id = users.id)
+) AS last_active_at
+FROM users
+WHERE disabled_at IS NULL`
+
+// inactiveSQL selects the users who were last active before the first
+// argument, and were not notified since, or were notified before the second
+// argument.
+const inactiveSQL = `
+SELECT * FROM (` + activitySQL + `) AS activity
+WHERE last_active_at < ?
+AND (retention_notice_at IS NULL OR retention_notice_at < last_active_at OR retention_notice_at <= ?)
+ORDER BY last_active_at
+LIMIT ?`
+
+// noticedSQL selects the ID of the user given as the first argument if they
+// were last active before the second argument and were notified since, before
+// the third argument.
+const noticedSQL = `
+SELECT id FROM (` + activitySQL + `) AS activity
+WHERE id = ? AND last_active_at < ?
+AND retention_notice_at >= last_active_at AND retention_notice_at <= ?`
+
 //go:generate mockgen -destination=./repository_mock.go -package=user github.com/PakornBank/go-backend-example/internal/user Repository
 
 // Repository defines the methods that a repository must implement.
 type Repository interface {
 	FindByID(ctx context.Context, id string) (*model.User, error)
+	UpdateEmail(ctx context.Context, id, email string) error
+	Delete(ctx context.Context, id string) error
+	ListInactive(ctx context.Context, activeBefore, noticedBefore time.Time, limit int) ([]InactiveUser, error)
+	MarkNotified(ctx context.Context, id string, at time.Time) error
+	DisableInactive(ctx context.Context, id string, at, activeBefore, noticedBefore time.Time) error
+	DeleteInactive(ctx context.Context, id string, activeBefore, noticedBefore time.Time) error
+}
+
+// InactiveUser is a user returned by ListInactive with the time they were last active.
+type InactiveUser struct {
+	model.User
+	LastActiveAt time.Time
 }
 
 // repository is a struct that provides methods to interact with the user data in the database.
@@ -21,9 +66,10 @@ type repository struct {
 	timeout time.Duration
 }
 
-// NewRepository creates a new instance of repository with the provided gorm.DB connection.
-func NewRepository(db *gorm.DB) Repository {
-	return &repository{db: db, timeout: 5 * time.Second}
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
 }
 
 // FindByID retrieves a user from the database by their ID.
@@ -33,9 +79,140 @@ func (r *repository) FindByID(ctx context.Context, id string) (*model.User, erro
 
 	var user model.User
 
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.FromContext(ctx).Error("failed to find user", slog.String("by", "id"), slog.Any("error", err))
+		}
 		return nil, err
 	}
 
 	return &user, nil
 }
+
+// UpdateEmail replaces the email address of the user with the given ID. It
+// returns ErrEmailTaken when another user has the address, ignoring case.
+func (r *repository) UpdateEmail(ctx context.Context, id, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", id).Update("email", email)
+	if result.Error != nil {
+		if database.IsUniqueViolation(result.Error, model.EmailIndex, model.LegacyEmailIndex) {
+			return ErrEmailTaken
+		}
+		logger.FromContext(ctx).Error("failed to update email", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the user with the given ID, together with their sessions,
+// password history and login attempts.
+func (r *repository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Where("id = ?", id).Delete(&model.User{})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to delete user", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ListInactive returns at most limit users who are not disabled and were last
+// active before activeBefore, least recently active first. Users notified by
+// MarkNotified since they were last active are left out until noticedBefore
+// is past the notice. It reads from the primary, so that recent activity is
+// not missed.
+func (r *repository) ListInactive(ctx context.Context, activeBefore, noticedBefore time.Time, limit int) ([]InactiveUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var users []InactiveUser
+	err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).Raw(inactiveSQL, activeBefore, noticedBefore, limit).Scan(&users).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to list inactive users", slog.Any("error", err))
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// MarkNotified records that the user with the given ID was told at the given
+// time that their inactive account is about to be disabled or deleted.
+func (r *repository) MarkNotified(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", id).Update("retention_notice_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to record retention notice", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DisableInactive disables the account of the user with the given ID and
+// revokes their sessions, provided the user was last active before
+// activeBefore and notified by MarkNotified since, before noticedBefore. It
+// returns gorm.ErrRecordNotFound when there is no such user, the account is
+// already disabled or the user was active since.
+func (r *repository) DisableInactive(ctx context.Context, id string, at, activeBefore, noticedBefore time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	conn := database.Conn(ctx, r.db).WithContext(ctx)
+	result := conn.Model(&model.User{}).Where("id IN (?)", gorm.Expr(noticedSQL, id, activeBefore, noticedBefore)).
+		Update("disabled_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to disable user", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	err := conn.Model(&model.Session{}).Where("user_id = ? AND revoked_at IS NULL", id).Update("revoked_at", at).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to revoke sessions of disabled user", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteInactive removes the user with the given ID, like Delete, provided the
+// user was last active before activeBefore and notified by MarkNotified
+// since, before noticedBefore. It returns gorm.ErrRecordNotFound when there
+// is no such user, the account is disabled or the user was active since.
+func (r *repository) DeleteInactive(ctx context.Context, id string, activeBefore, noticedBefore time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("id IN (?)", gorm.Expr(noticedSQL, id, activeBefore, noticedBefore)).Delete(&model.User{})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to delete inactive user", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
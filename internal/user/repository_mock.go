@@ -12,6 +12,7 @@ package user
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/PakornBank/go-backend-example/internal/common/model"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +42,48 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, id)
+}
+
+// DeleteInactive mocks base method.
+func (m *MockRepository) DeleteInactive(ctx context.Context, id string, activeBefore, noticedBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInactive", ctx, id, activeBefore, noticedBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInactive indicates an expected call of DeleteInactive.
+func (mr *MockRepositoryMockRecorder) DeleteInactive(ctx, id, activeBefore, noticedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInactive", reflect.TypeOf((*MockRepository)(nil).DeleteInactive), ctx, id, activeBefore, noticedBefore)
+}
+
+// DisableInactive mocks base method.
+func (m *MockRepository) DisableInactive(ctx context.Context, id string, at, activeBefore, noticedBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableInactive", ctx, id, at, activeBefore, noticedBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableInactive indicates an expected call of DisableInactive.
+func (mr *MockRepositoryMockRecorder) DisableInactive(ctx, id, at, activeBefore, noticedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableInactive", reflect.TypeOf((*MockRepository)(nil).DisableInactive), ctx, id, at, activeBefore, noticedBefore)
+}
+
 // FindByID mocks base method.
 func (m *MockRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -55,3 +98,46 @@ func (mr *MockRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockRepository)(nil).FindByID), ctx, id)
 }
+
+// ListInactive mocks base method.
+func (m *MockRepository) ListInactive(ctx context.Context, activeBefore, noticedBefore time.Time, limit int) ([]InactiveUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInactive", ctx, activeBefore, noticedBefore, limit)
+	ret0, _ := ret[0].([]InactiveUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInactive indicates an expected call of ListInactive.
+func (mr *MockRepositoryMockRecorder) ListInactive(ctx, activeBefore, noticedBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInactive", reflect.TypeOf((*MockRepository)(nil).ListInactive), ctx, activeBefore, noticedBefore, limit)
+}
+
+// MarkNotified mocks base method.
+func (m *MockRepository) MarkNotified(ctx context.Context, id string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotified", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotified indicates an expected call of MarkNotified.
+func (mr *MockRepositoryMockRecorder) MarkNotified(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotified", reflect.TypeOf((*MockRepository)(nil).MarkNotified), ctx, id, at)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockRepository) UpdateEmail(ctx context.Context, id, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockRepositoryMockRecorder) UpdateEmail(ctx, id, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockRepository)(nil).UpdateEmail), ctx, id, email)
+}
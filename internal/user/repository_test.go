@@ -2,27 +2,31 @@ package user
 
 import (
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
 	"github.com/PakornBank/go-backend-example/internal/common/testutil"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
 func setupRepositoryTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, Repository) {
 	_, gormDB, sqlMock := testutil.DBMock(t)
-	userRepo := NewRepository(gormDB)
+	userRepo := NewRepository(gormDB, 5*time.Second)
 	return gormDB, sqlMock, userRepo
 }
 
 func TestNewRepository(t *testing.T) {
 	_, gormDB, _ := testutil.DBMock(t)
-	userRepo := NewRepository(gormDB)
+	userRepo := NewRepository(gormDB, 5*time.Second)
 	assert.NotNil(t, userRepo)
 	assert.Equal(t, gormDB, userRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, userRepo.(*repository).timeout)
 }
 
 func Test_repository_FindByID(t *testing.T) {
@@ -84,3 +88,348 @@ func Test_repository_FindByID(t *testing.T) {
 		})
 	}
 }
+
+func Test_repository_UpdateEmail(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "email updated",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users" SET "email"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+					WithArgs("new@example.com", sqlmock.AnyArg(), mockUser.ID.String()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "email taken",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: model.EmailIndex})
+				sqlMock.ExpectRollback()
+			},
+			errType: ErrEmailTaken,
+		},
+		{
+			name: "email taken on the legacy index",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: model.LegacyEmailIndex})
+				sqlMock.ExpectRollback()
+			},
+			errType: ErrEmailTaken,
+		},
+		{
+			name: "user not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sqlMock, userRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := userRepo.UpdateEmail(context.Background(), mockUser.ID.String(), "new@example.com")
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_Delete(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "user deleted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "users" WHERE id = \$1`).
+					WithArgs(mockUser.ID.String()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "user not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sqlMock, userRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := userRepo.Delete(context.Background(), mockUser.ID.String())
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ListInactive(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	activeBefore := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	noticedBefore := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	lastActive := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mockFn  func(*sqlmock.ExpectedQuery)
+		want    []InactiveUser
+		errType error
+	}{
+		{
+			name: "inactive users found",
+			mockFn: func(e *sqlmock.ExpectedQuery) {
+				e.WillReturnRows(sqlmock.NewRows([]string{"id", "email", "full_name", "last_active_at"}).
+					AddRow(mockUser.ID, mockUser.Email, mockUser.FullName, lastActive))
+			},
+			want: []InactiveUser{{
+				User:         model.User{ID: mockUser.ID, Email: mockUser.Email, FullName: mockUser.FullName},
+				LastActiveAt: lastActive,
+			}},
+		},
+		{
+			name: "database error",
+			mockFn: func(e *sqlmock.ExpectedQuery) {
+				e.WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sqlMock, userRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock.ExpectQuery(`SELECT \* FROM \(.*\) AS activity WHERE last_active_at < \$1 .* LIMIT \$3`).
+				WithArgs(activeBefore, noticedBefore, 10))
+
+			users, err := userRepo.ListInactive(context.Background(), activeBefore, noticedBefore, 10)
+
+			assert.Equal(t, tt.errType, err)
+			assert.Equal(t, tt.want, users)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkNotified(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	at := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "notice recorded",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users" SET "retention_notice_at"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+					WithArgs(at, sqlmock.AnyArg(), mockUser.ID.String()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "user not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sqlMock, userRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := userRepo.MarkNotified(context.Background(), mockUser.ID.String(), at)
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_DisableInactive(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	at := time.Now()
+	activeBefore := at.AddDate(-1, 0, 0)
+	noticedBefore := at.AddDate(0, 0, -14)
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "user disabled and sessions revoked",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users" SET "disabled_at"=\$1,"updated_at"=\$2 WHERE id IN \(\s*SELECT id FROM \(.*\) AS activity\s+WHERE id = \$3 AND last_active_at < \$4\s+AND retention_notice_at >= last_active_at AND retention_notice_at <= \$5\)`).
+					WithArgs(at, sqlmock.AnyArg(), mockUser.ID.String(), activeBefore, noticedBefore).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "sessions" SET "revoked_at"=\$1 WHERE user_id = \$2 AND revoked_at IS NULL`).
+					WithArgs(at, mockUser.ID.String()).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "user not found, disabled or active since",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+		{
+			name: "revoking sessions fails",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "sessions"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sqlMock, userRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := userRepo.DisableInactive(context.Background(), mockUser.ID.String(), at, activeBefore, noticedBefore)
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_DeleteInactive(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	activeBefore := time.Now().AddDate(-1, 0, 0)
+	noticedBefore := time.Now().AddDate(0, 0, -14)
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "user deleted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "users" WHERE id IN \(\s*SELECT id FROM \(.*\) AS activity\s+WHERE id = \$1 AND last_active_at < \$2\s+AND retention_notice_at >= last_active_at AND retention_notice_at <= \$3\)`).
+					WithArgs(mockUser.ID.String(), activeBefore, noticedBefore).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "user not found, disabled or active since",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "users"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sqlMock, userRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := userRepo.DeleteInactive(context.Background(), mockUser.ID.String(), activeBefore, noticedBefore)
+
+			assert.Equal(t, tt.errType, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// Actions of the retention policy on an inactive account.
+const (
+	RetentionNotify  = "notify"
+	RetentionDisable = "disable"
+	RetentionDelete  = "delete"
+)
+
+// errNoLongerInactive is returned by apply when the account was used, or
+// disabled, since it was listed, in which case it is left alone.
+var errNoLongerInactive = errors.New("account is no longer inactive")
+
+// RetentionCandidate is an inactive account and the action the retention policy takes on it.
+type RetentionCandidate struct {
+	InactiveUser
+	Action string
+}
+
+// Retention applies the retention policy of inactive accounts: the owner of
+// an account not used for RetentionInactiveMonths is notified by email, and
+// the account is disabled or deleted, following RetentionAction, once
+// RetentionNotice has passed without the owner logging in. Logging in after
+// the notice keeps the account and starts over.
+type Retention struct {
+	repository Repository
+	tx         database.TxManager
+	mailer     mail.Sender
+	auditLog   audit.Service
+	events     outbox.Publisher
+	config     *config.Store
+	now        func() time.Time
+}
+
+// NewRetention creates a new Retention with the provided repository, transaction manager, mail sender,
+// audit service, event publisher and configuration.
+func NewRetention(repository Repository, tx database.TxManager, mailer mail.Sender, auditLog audit.Service, events outbox.Publisher, config *config.Store) *Retention {
+	return &Retention{
+		repository: repository,
+		tx:         tx,
+		mailer:     mailer,
+		auditLog:   auditLog,
+		events:     events,
+		config:     config,
+		now:        time.Now,
+	}
+}
+
+// Candidates returns the accounts handled by the next run of the policy, at
+// most RetentionBatchSize, least recently active first. It returns none when
+// RetentionInactiveMonths is zero.
+func (r *Retention) Candidates(ctx context.Context) ([]RetentionCandidate, error) {
+	cfg := r.config.Get()
+	if cfg.RetentionInactiveMonths == 0 {
+		return nil, nil
+	}
+
+	now := r.now()
+	users, err := r.repository.ListInactive(ctx, now.AddDate(0, -cfg.RetentionInactiveMonths, 0), now.Add(-cfg.RetentionNotice), cfg.RetentionBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]RetentionCandidate, len(users))
+	for i, u := range users {
+		action := cfg.RetentionAction
+		// Users not notified since they were last active are notified first.
+		if u.RetentionNoticeAt == nil || u.RetentionNoticeAt.Before(u.LastActiveAt) {
+			action = RetentionNotify
+		}
+		candidates[i] = RetentionCandidate{InactiveUser: u, Action: action}
+	}
+	return candidates, nil
+}
+
+// Run takes the action of every candidate, or only logs them when
+// RetentionDryRun is set. A candidate failing is logged and does not stop
+// the others; Run then returns an error counting the failures.
+func (r *Retention) Run(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "user.Retention.Run")
+	defer span.End()
+
+	log := logger.FromContext(ctx)
+
+	candidates, err := r.Candidates(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.Int("retention.candidates", len(candidates)))
+
+	cfg := r.config.Get()
+	counts := make(map[string]int)
+	skipped, failed := 0, 0
+	for _, c := range candidates {
+		attrs := []any{
+			slog.String("user_id", c.ID.String()),
+			slog.String("action", c.Action),
+			slog.Time("last_active_at", c.LastActiveAt),
+		}
+		if cfg.RetentionDryRun {
+			log.Info("retention dry run, account not changed", attrs...)
+			counts[c.Action]++
+			continue
+		}
+
+		if err := r.apply(ctx, c, cfg); err != nil {
+			if errors.Is(err, errNoLongerInactive) {
+				log.Info("retention policy skipped, account used since listed", attrs...)
+				skipped++
+				continue
+			}
+			log.Error("failed to apply retention policy", append(attrs, slog.Any("error", err))...)
+			failed++
+			continue
+		}
+		log.Info("retention policy applied", attrs...)
+		counts[c.Action]++
+	}
+
+	log.Info("retention run finished",
+		slog.Bool("dry_run", cfg.RetentionDryRun),
+		slog.Int("notified", counts[RetentionNotify]),
+		slog.Int("disabled", counts[RetentionDisable]),
+		slog.Int("deleted", counts[RetentionDelete]),
+		slog.Int("skipped", skipped),
+		slog.Int("failed", failed),
+	)
+	if failed > 0 {
+		err := fmt.Errorf("retention policy failed for %d of %d accounts", failed, len(candidates))
+		tracing.RecordError(span, err)
+		return err
+	}
+	return nil
+}
+
+// apply takes the action of c within a transaction, together with its audit
+// event. Accounts are only disabled or deleted if they are still inactive and
+// notified, otherwise apply returns errNoLongerInactive.
+func (r *Retention) apply(ctx context.Context, c RetentionCandidate, cfg *config.Config) error {
+	id := c.ID.String()
+	now := r.now()
+	activeBefore, noticedBefore := now.AddDate(0, -cfg.RetentionInactiveMonths, 0), now.Add(-cfg.RetentionNotice)
+
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		switch c.Action {
+		case RetentionNotify:
+			if err := r.repository.MarkNotified(ctx, id, now); err != nil {
+				return err
+			}
+			// The mail is queued in the transaction, so it is sent once the notice is recorded.
+			err := r.mailer.Send(ctx, mail.Message{
+				To:      c.Email,
+				Subject: "Your account is about to be " + pastTense(cfg.RetentionAction),
+				Body:    noticeBody(c, cfg.RetentionAction, now.Add(cfg.RetentionNotice)),
+			})
+			if err != nil {
+				return err
+			}
+			return r.auditLog.Record(ctx, audit.Event{
+				Action:     audit.ActionRetentionNotice,
+				TargetType: audit.TargetUser,
+				TargetID:   id,
+				Metadata:   map[string]interface{}{"email": c.Email, "action": cfg.RetentionAction},
+			})
+		case RetentionDisable:
+			if err := r.repository.DisableInactive(ctx, id, now, activeBefore, noticedBefore); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errNoLongerInactive
+				}
+				return err
+			}
+			return r.auditLog.Record(ctx, audit.Event{
+				Action:     audit.ActionUserDisabled,
+				TargetType: audit.TargetUser,
+				TargetID:   id,
+				Metadata:   map[string]interface{}{"email": c.Email, "reason": "inactive"},
+			})
+		case RetentionDelete:
+			if err := r.repository.DeleteInactive(ctx, id, activeBefore, noticedBefore); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errNoLongerInactive
+				}
+				return err
+			}
+			err := r.auditLog.Record(ctx, audit.Event{
+				Action:     audit.ActionUserDeleted,
+				TargetType: audit.TargetUser,
+				TargetID:   id,
+				Metadata:   map[string]interface{}{"email": c.Email, "reason": "inactive"},
+			})
+			if err != nil {
+				return err
+			}
+			return r.events.Publish(ctx, outbox.EventUserDeleted, outbox.UserDeleted{UserID: id, Email: c.Email})
+		default:
+			return fmt.Errorf("unknown retention action %q", c.Action)
+		}
+	})
+}
+
+// pastTense returns the past participle of a retention action.
+func pastTense(action string) string {
+	if action == RetentionDelete {
+		return "deleted"
+	}
+	return "disabled"
+}
+
+// noticeBody renders the text of the notice that the account of c is about to
+// be disabled or deleted at the given time.
+func noticeBody(c RetentionCandidate, action string, at time.Time) string {
+	return fmt.Sprintf(`Hi %s,
+
+You have not used your account since %s. Unless you log in before
+%s, your account will be %s.
+
+If you no longer need the account, you can ignore this email.
+`, c.FullName, c.LastActiveAt.UTC().Format("2006-01-02"), at.UTC().Format("2006-01-02 15:04 MST"), pastTense(action))
+}
@@ -0,0 +1,249 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/mail"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+var (
+	retentionNow           = time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	retentionActiveBefore  = retentionNow.AddDate(-1, 0, 0)
+	retentionNoticedBefore = retentionNow.Add(-14 * 24 * time.Hour)
+)
+
+type retentionMocks struct {
+	repo   *MockRepository
+	mailer *mail.MockSender
+	audit  *audit.MockService
+	events *outbox.MockPublisher
+}
+
+func setupRetentionTest(t *testing.T, cfg *config.Config) (*Retention, retentionMocks) {
+	ctrl := gomock.NewController(t)
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	m := retentionMocks{
+		repo:   NewMockRepository(ctrl),
+		mailer: mail.NewMockSender(ctrl),
+		audit:  audit.NewMockService(ctrl),
+		events: outbox.NewMockPublisher(ctrl),
+	}
+	retention := NewRetention(m.repo, mockTx, m.mailer, m.audit, m.events, config.NewStore(cfg))
+	retention.now = func() time.Time { return retentionNow }
+	return retention, m
+}
+
+func retentionConfig(action string) *config.Config {
+	return &config.Config{
+		RetentionInactiveMonths: 12,
+		RetentionNotice:         14 * 24 * time.Hour,
+		RetentionAction:         action,
+		RetentionBatchSize:      10,
+	}
+}
+
+func inactiveUser(lastActive time.Time, noticeAt *time.Time) InactiveUser {
+	u := testutil.NewMockUser()
+	u.RetentionNoticeAt = noticeAt
+	return InactiveUser{User: u, LastActiveAt: lastActive}
+}
+
+func TestNewRetention(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTx := new(database.MockTxManager)
+	mockMailer := new(mail.MockSender)
+	mockAudit := new(audit.MockService)
+	mockEvents := new(outbox.MockPublisher)
+	store := config.NewStore(&config.Config{})
+	retention := NewRetention(mockRepo, mockTx, mockMailer, mockAudit, mockEvents, store)
+
+	assert.Equal(t, mockRepo, retention.repository)
+	assert.Equal(t, mockTx, retention.tx)
+	assert.Equal(t, mockMailer, retention.mailer)
+	assert.Equal(t, mockAudit, retention.auditLog)
+	assert.Equal(t, mockEvents, retention.events)
+	assert.Equal(t, store, retention.config)
+	assert.NotNil(t, retention.now)
+}
+
+func TestRetention_Candidates(t *testing.T) {
+	lastActive := retentionNow.AddDate(-2, 0, 0)
+	staleNotice := lastActive.Add(-time.Hour)
+	notice := retentionNow.AddDate(0, 0, -20)
+
+	never := inactiveUser(lastActive, nil)
+	stale := inactiveUser(lastActive, &staleNotice)
+	notified := inactiveUser(lastActive, &notice)
+
+	retention, m := setupRetentionTest(t, retentionConfig(RetentionDelete))
+	m.repo.EXPECT().ListInactive(gomock.Any(), retentionNow.AddDate(-1, 0, 0), retentionNow.AddDate(0, 0, -14), 10).
+		Return([]InactiveUser{never, stale, notified}, nil)
+
+	candidates, err := retention.Candidates(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []RetentionCandidate{
+		{InactiveUser: never, Action: RetentionNotify},
+		{InactiveUser: stale, Action: RetentionNotify},
+		{InactiveUser: notified, Action: RetentionDelete},
+	}, candidates)
+}
+
+func TestRetention_Candidates_Disabled(t *testing.T) {
+	cfg := retentionConfig(RetentionDisable)
+	cfg.RetentionInactiveMonths = 0
+	retention, _ := setupRetentionTest(t, cfg)
+
+	candidates, err := retention.Candidates(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, candidates)
+}
+
+func TestRetention_Run(t *testing.T) {
+	lastActive := retentionNow.AddDate(-2, 0, 0)
+	notice := retentionNow.AddDate(0, 0, -20)
+
+	tests := []struct {
+		name        string
+		action      string
+		user        InactiveUser
+		mockFn      func(retentionMocks, string)
+		errContains string
+	}{
+		{
+			name:   "owner notified",
+			action: RetentionDisable,
+			user:   inactiveUser(lastActive, nil),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().MarkNotified(gomock.Any(), id, retentionNow).Return(nil)
+				m.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+					assert.Equal(t, "Your account is about to be disabled", msg.Subject)
+					assert.Contains(t, msg.Body, "You have not used your account since 2024-07-01.")
+					assert.Contains(t, msg.Body, "2026-07-15 12:00 UTC, your account will be disabled.")
+					return nil
+				})
+				m.audit.EXPECT().Record(gomock.Any(), audit.Event{
+					Action:     audit.ActionRetentionNotice,
+					TargetType: audit.TargetUser,
+					TargetID:   id,
+					Metadata:   map[string]interface{}{"email": "test@example.com", "action": RetentionDisable},
+				}).Return(nil)
+			},
+		},
+		{
+			name:   "account disabled",
+			action: RetentionDisable,
+			user:   inactiveUser(lastActive, &notice),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().DisableInactive(gomock.Any(), id, retentionNow, retentionActiveBefore, retentionNoticedBefore).Return(nil)
+				m.audit.EXPECT().Record(gomock.Any(), audit.Event{
+					Action:     audit.ActionUserDisabled,
+					TargetType: audit.TargetUser,
+					TargetID:   id,
+					Metadata:   map[string]interface{}{"email": "test@example.com", "reason": "inactive"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:   "account deleted",
+			action: RetentionDelete,
+			user:   inactiveUser(lastActive, &notice),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().DeleteInactive(gomock.Any(), id, retentionActiveBefore, retentionNoticedBefore).Return(nil)
+				m.audit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+				m.events.EXPECT().Publish(gomock.Any(), outbox.EventUserDeleted, outbox.UserDeleted{UserID: id, Email: "test@example.com"}).Return(nil)
+			},
+		},
+		{
+			name:   "action fails",
+			action: RetentionDelete,
+			user:   inactiveUser(lastActive, &notice),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().DeleteInactive(gomock.Any(), id, retentionActiveBefore, retentionNoticedBefore).Return(sql.ErrConnDone)
+			},
+			errContains: "retention policy failed for 1 of 1 accounts",
+		},
+		{
+			name:   "account used since listed is not disabled",
+			action: RetentionDisable,
+			user:   inactiveUser(lastActive, &notice),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().DisableInactive(gomock.Any(), id, retentionNow, retentionActiveBefore, retentionNoticedBefore).Return(gorm.ErrRecordNotFound)
+			},
+		},
+		{
+			name:   "account used since listed is not deleted",
+			action: RetentionDelete,
+			user:   inactiveUser(lastActive, &notice),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().DeleteInactive(gomock.Any(), id, retentionActiveBefore, retentionNoticedBefore).Return(gorm.ErrRecordNotFound)
+			},
+		},
+		{
+			name:   "sending the notice fails",
+			action: RetentionDelete,
+			user:   inactiveUser(lastActive, nil),
+			mockFn: func(m retentionMocks, id string) {
+				m.repo.EXPECT().MarkNotified(gomock.Any(), id, retentionNow).Return(nil)
+				m.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(sql.ErrConnDone)
+			},
+			errContains: "retention policy failed for 1 of 1 accounts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retention, m := setupRetentionTest(t, retentionConfig(tt.action))
+			m.repo.EXPECT().ListInactive(gomock.Any(), gomock.Any(), gomock.Any(), 10).Return([]InactiveUser{tt.user}, nil)
+			tt.mockFn(m, tt.user.ID.String())
+
+			err := retention.Run(context.Background())
+
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetention_Run_DryRun(t *testing.T) {
+	lastActive := retentionNow.AddDate(-2, 0, 0)
+	notice := retentionNow.AddDate(0, 0, -20)
+	cfg := retentionConfig(RetentionDelete)
+	cfg.RetentionDryRun = true
+	retention, m := setupRetentionTest(t, cfg)
+	m.repo.EXPECT().ListInactive(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+		Return([]InactiveUser{inactiveUser(lastActive, nil), inactiveUser(lastActive, &notice)}, nil)
+
+	// No other call is expected: a dry run changes nothing.
+	err := retention.Run(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestRetention_Run_ListFails(t *testing.T) {
+	retention, m := setupRetentionTest(t, retentionConfig(RetentionDisable))
+	m.repo.EXPECT().ListInactive(gomock.Any(), gomock.Any(), gomock.Any(), 10).Return(nil, sql.ErrConnDone)
+
+	err := retention.Run(context.Background())
+
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+}
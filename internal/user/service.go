@@ -2,9 +2,20 @@ package user
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
 //go:generate mockgen -destination=./service_mock.go -package=user github.com/PakornBank/go-backend-example/internal/user Service
@@ -12,23 +23,168 @@ import (
 // Service defines the methods that a service must implement.
 type Service interface {
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	ChangeEmail(ctx context.Context, id, currentPassword, newEmail string) (*model.User, error)
+	Delete(ctx context.Context, id, currentPassword string) error
 }
 
 // service is a struct that provides methods to interact with the user service.
 type service struct {
 	repository  Repository
+	tx          database.TxManager
+	emails      *email.Normalizer
+	hasher      password.Hasher
+	auditLog    audit.Service
+	events      outbox.Publisher
 	jwtSecret   []byte
 	tokenExpiry time.Duration
 }
 
-// NewService creates a new instance of service with the provided repository and configuration.
-func NewService(repository Repository) Service {
+// NewService creates a new instance of service with the provided repository, transaction manager,
+// email normalizer, password hasher, audit service and event publisher.
+func NewService(repository Repository, tx database.TxManager, emails *email.Normalizer, hasher password.Hasher, auditLog audit.Service, events outbox.Publisher) Service {
 	return &service{
 		repository: repository,
+		tx:         tx,
+		emails:     emails,
+		hasher:     hasher,
+		auditLog:   auditLog,
+		events:     events,
 	}
 }
 
 // GetUserByID find and return user data with the given id.
 func (s *service) GetUserByID(ctx context.Context, id string) (*model.User, error) {
-	return s.repository.FindByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "user.Service.GetUserByID")
+	defer span.End()
+
+	user, err := s.repository.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ChangeEmail replaces the email address of the user after verifying their password.
+func (s *service) ChangeEmail(ctx context.Context, id, currentPassword, newEmail string) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Service.ChangeEmail")
+	defer span.End()
+	span.SetAttributes(attribute.String("user_id", id))
+
+	log := logger.FromContext(ctx)
+
+	address, err := s.emails.Normalize(newEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repository.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	if !s.verifyPassword(ctx, currentPassword, user.PasswordHash) {
+		log.Warn("email change failed", slog.String("reason", "wrong password"), slog.String("user_id", id))
+		return nil, ErrWrongPassword
+	}
+
+	if address == user.Email {
+		return user, nil
+	}
+
+	oldEmail := user.Email
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.UpdateEmail(ctx, id, address); err != nil {
+			return err
+		}
+		err := s.auditLog.Record(ctx, audit.Event{
+			ActorID:    id,
+			Action:     audit.ActionEmailChanged,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Metadata:   map[string]interface{}{"old_email": oldEmail, "new_email": address},
+		})
+		if err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, outbox.EventUserEmailChanged, outbox.UserEmailChanged{
+			UserID:   id,
+			OldEmail: oldEmail,
+			NewEmail: address,
+		})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	user.Email = address
+	log.Info("email changed", slog.String("user_id", id))
+	return user, nil
+}
+
+// Delete removes the account of the user after verifying their password.
+func (s *service) Delete(ctx context.Context, id, currentPassword string) error {
+	ctx, span := tracing.Start(ctx, "user.Service.Delete")
+	defer span.End()
+	span.SetAttributes(attribute.String("user_id", id))
+
+	log := logger.FromContext(ctx)
+
+	user, err := s.repository.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	if !s.verifyPassword(ctx, currentPassword, user.PasswordHash) {
+		log.Warn("account deletion failed", slog.String("reason", "wrong password"), slog.String("user_id", id))
+		return ErrWrongPassword
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.Delete(ctx, id); err != nil {
+			return err
+		}
+		err := s.auditLog.Record(ctx, audit.Event{
+			ActorID:    id,
+			Action:     audit.ActionUserDeleted,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Metadata:   map[string]interface{}{"email": user.Email},
+		})
+		if err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, outbox.EventUserDeleted, outbox.UserDeleted{UserID: id, Email: user.Email})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	log.Info("user deleted", slog.String("user_id", id))
+	return nil
+}
+
+// verifyPassword reports whether password matches the stored hash.
+func (s *service) verifyPassword(ctx context.Context, password, hash string) bool {
+	_, span := tracing.Start(ctx, "password.Verify")
+	ok, err := s.hasher.Verify(password, hash)
+	span.End()
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to verify password", slog.Any("error", err))
+	}
+	return ok
 }
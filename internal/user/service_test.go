@@ -2,33 +2,73 @@ package user
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/email"
 	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/password"
 	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 func setupServiceTest(t *testing.T) (Service, *MockRepository) {
+	userService, mockRepo, mockAudit, mockEvents := setupServiceTestWithEvents(t)
+	mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockEvents.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return userService, mockRepo
+}
+
+func setupServiceTestWithEvents(t *testing.T) (Service, *MockRepository, *audit.MockService, *outbox.MockPublisher) {
 	ctrl := gomock.NewController(t)
 	mockRepo := NewMockRepository(ctrl)
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	mockAudit := audit.NewMockService(ctrl)
+	mockEvents := outbox.NewMockPublisher(ctrl)
 	userService := &service{
-		repository:  mockRepo,
+		repository: mockRepo,
+		tx:         mockTx,
+		emails:     email.NewNormalizer(&config.Config{EmailPunycodeDomain: true}),
+		hasher: password.NewHasher(&config.Config{
+			PasswordHashAlgorithm: password.AlgorithmBcrypt,
+			PasswordBcryptCost:    bcrypt.MinCost,
+		}),
+		auditLog:    mockAudit,
+		events:      mockEvents,
 		jwtSecret:   []byte("test-secret"),
 		tokenExpiry: time.Hour * 24,
 	}
-	return userService, mockRepo
+	return userService, mockRepo, mockAudit, mockEvents
 }
 
 func TestNewService(t *testing.T) {
 	mockRepo := new(MockRepository)
-	userService := NewService(mockRepo)
+	mockTx := new(database.MockTxManager)
+	emails := email.NewNormalizer(&config.Config{})
+	hasher := password.NewHasher(&config.Config{PasswordHashAlgorithm: password.AlgorithmBcrypt})
+	auditLog := new(audit.MockService)
+	events := new(outbox.MockPublisher)
+	userService := NewService(mockRepo, mockTx, emails, hasher, auditLog, events)
 
 	assert.NotNil(t, userService)
 	assert.Equal(t, mockRepo, userService.(*service).repository)
+	assert.Equal(t, mockTx, userService.(*service).tx)
+	assert.Equal(t, emails, userService.(*service).emails)
+	assert.Equal(t, hasher, userService.(*service).hasher)
+	assert.Equal(t, auditLog, userService.(*service).auditLog)
+	assert.Equal(t, events, userService.(*service).events)
 }
 
 func Test_service_GetUserByID(t *testing.T) {
@@ -58,7 +98,7 @@ func Test_service_GetUserByID(t *testing.T) {
 				mr.EXPECT().FindByID(gomock.Any(), mockUser.ID.String()).Return(nil, gorm.ErrRecordNotFound)
 			},
 			wantErr: true,
-			errType: gorm.ErrRecordNotFound,
+			errType: ErrNotFound,
 		},
 	}
 
@@ -79,3 +119,192 @@ func Test_service_GetUserByID(t *testing.T) {
 		})
 	}
 }
+
+func Test_service_ChangeEmail(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockUser.PasswordHash = string(hashedPassword)
+	userID := mockUser.ID.String()
+	wantEvent := outbox.UserEmailChanged{UserID: userID, OldEmail: mockUser.Email, NewEmail: "new@example.com"}
+
+	tests := []struct {
+		name     string
+		newEmail string
+		password string
+		mockFn   func(*MockRepository, *audit.MockService, *outbox.MockPublisher)
+		want     string
+		errType  error
+	}{
+		{
+			name:     "email changed",
+			newEmail: "new@EXAMPLE.com",
+			password: "password",
+			mockFn: func(mr *MockRepository, ma *audit.MockService, me *outbox.MockPublisher) {
+				user := mockUser
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&user, nil)
+				mr.EXPECT().UpdateEmail(gomock.Any(), userID, "new@example.com").Return(nil)
+				ma.EXPECT().Record(gomock.Any(), audit.Event{
+					ActorID:    userID,
+					Action:     audit.ActionEmailChanged,
+					TargetType: audit.TargetUser,
+					TargetID:   userID,
+					Metadata:   map[string]interface{}{"old_email": mockUser.Email, "new_email": "new@example.com"},
+				}).Return(nil)
+				me.EXPECT().Publish(gomock.Any(), outbox.EventUserEmailChanged, wantEvent).Return(nil)
+			},
+			want: "new@example.com",
+		},
+		{
+			name:     "same email",
+			newEmail: mockUser.Email,
+			password: "password",
+			mockFn: func(mr *MockRepository, _ *audit.MockService, _ *outbox.MockPublisher) {
+				user := mockUser
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&user, nil)
+			},
+			want: mockUser.Email,
+		},
+		{
+			name:     "invalid email",
+			newEmail: "not an email",
+			password: "password",
+			mockFn:   func(*MockRepository, *audit.MockService, *outbox.MockPublisher) {},
+			errType:  email.ErrInvalid,
+		},
+		{
+			name:     "wrong password",
+			newEmail: "new@example.com",
+			password: "wrong password",
+			mockFn: func(mr *MockRepository, _ *audit.MockService, _ *outbox.MockPublisher) {
+				user := mockUser
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&user, nil)
+			},
+			errType: ErrWrongPassword,
+		},
+		{
+			name:     "email taken",
+			newEmail: "new@example.com",
+			password: "password",
+			mockFn: func(mr *MockRepository, _ *audit.MockService, _ *outbox.MockPublisher) {
+				user := mockUser
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&user, nil)
+				mr.EXPECT().UpdateEmail(gomock.Any(), userID, "new@example.com").Return(ErrEmailTaken)
+			},
+			errType: ErrEmailTaken,
+		},
+		{
+			name:     "publish error",
+			newEmail: "new@example.com",
+			password: "password",
+			mockFn: func(mr *MockRepository, ma *audit.MockService, me *outbox.MockPublisher) {
+				user := mockUser
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&user, nil)
+				mr.EXPECT().UpdateEmail(gomock.Any(), userID, "new@example.com").Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+				me.EXPECT().Publish(gomock.Any(), outbox.EventUserEmailChanged, wantEvent).Return(gorm.ErrInvalidDB)
+			},
+			errType: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, mockRepo, mockAudit, mockEvents := setupServiceTestWithEvents(t)
+			tt.mockFn(mockRepo, mockAudit, mockEvents)
+
+			got, err := userService.ChangeEmail(context.Background(), userID, tt.password, tt.newEmail)
+
+			if tt.errType != nil {
+				assert.True(t, errors.Is(err, tt.errType), "got %v", err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got.Email)
+			}
+		})
+	}
+}
+
+func Test_service_Delete(t *testing.T) {
+	mockUser := testutil.NewMockUser()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockUser.PasswordHash = string(hashedPassword)
+	userID := mockUser.ID.String()
+	wantEvent := outbox.UserDeleted{UserID: userID, Email: mockUser.Email}
+
+	tests := []struct {
+		name     string
+		password string
+		mockFn   func(*MockRepository, *audit.MockService, *outbox.MockPublisher)
+		errType  error
+	}{
+		{
+			name:     "user deleted",
+			password: "password",
+			mockFn: func(mr *MockRepository, ma *audit.MockService, me *outbox.MockPublisher) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().Delete(gomock.Any(), userID).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), audit.Event{
+					ActorID:    userID,
+					Action:     audit.ActionUserDeleted,
+					TargetType: audit.TargetUser,
+					TargetID:   userID,
+					Metadata:   map[string]interface{}{"email": mockUser.Email},
+				}).Return(nil)
+				me.EXPECT().Publish(gomock.Any(), outbox.EventUserDeleted, wantEvent).Return(nil)
+			},
+		},
+		{
+			name:     "user not found",
+			password: "password",
+			mockFn: func(mr *MockRepository, _ *audit.MockService, _ *outbox.MockPublisher) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errType: ErrNotFound,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong password",
+			mockFn: func(mr *MockRepository, _ *audit.MockService, _ *outbox.MockPublisher) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+			},
+			errType: ErrWrongPassword,
+		},
+		{
+			name:     "audit error",
+			password: "password",
+			mockFn: func(mr *MockRepository, ma *audit.MockService, _ *outbox.MockPublisher) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().Delete(gomock.Any(), userID).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).Return(gorm.ErrInvalidDB)
+			},
+			errType: gorm.ErrInvalidDB,
+		},
+		{
+			name:     "publish error",
+			password: "password",
+			mockFn: func(mr *MockRepository, ma *audit.MockService, me *outbox.MockPublisher) {
+				mr.EXPECT().FindByID(gomock.Any(), userID).Return(&mockUser, nil)
+				mr.EXPECT().Delete(gomock.Any(), userID).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+				me.EXPECT().Publish(gomock.Any(), outbox.EventUserDeleted, wantEvent).Return(gorm.ErrInvalidDB)
+			},
+			errType: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, mockRepo, mockAudit, mockEvents := setupServiceTestWithEvents(t)
+			tt.mockFn(mockRepo, mockAudit, mockEvents)
+
+			err := userService.Delete(context.Background(), userID, tt.password)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/common/worker"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxDrainBytes is how much of a response body is read, and discarded, so
+// that its connection can be reused.
+const maxDrainBytes = 4096
+
+// userAgent identifies delivery requests.
+const userAgent = "go-auth-api-webhooks/1"
+
+// errInactive fails the deliveries of subscriptions deactivated or deleted
+// after the delivery was scheduled.
+var errInactive = errors.New("webhook is inactive")
+
+// Deliverer sends the pending webhook deliveries to the endpoints of their
+// subscriptions. A delivery is sent again until the endpoint answers with a
+// 2xx status, with an exponential backoff between attempts, and fails once
+// the attempts are exhausted. Redirects are not followed, and requests to
+// addresses of the internal network are refused. Several deliverers,
+// in the same or other processes, may run at once: every delivery is leased
+// to one of them at a time.
+type Deliverer struct {
+	repository Repository
+	config     *config.Store
+	metrics    *metrics.Metrics
+	client     *http.Client
+	now        func() time.Time
+}
+
+// NewDeliverer creates a new Deliverer with the provided repository, configuration and metrics.
+func NewDeliverer(repository Repository, config *config.Store, m *metrics.Metrics) *Deliverer {
+	return &Deliverer{
+		repository: repository,
+		config:     config,
+		metrics:    m,
+		client:     newClient(checkTarget),
+		now:        time.Now,
+	}
+}
+
+// Run sends due deliveries until ctx is done, checking for them every poll
+// interval, or at once while batches come back full. Deliveries being sent
+// when ctx is done are finished before Run returns.
+func (d *Deliverer) Run(ctx context.Context) {
+	interval := func() time.Duration { return d.config.Get().WebhookPollInterval }
+	worker.Poll(ctx, interval, "failed to send webhook deliveries", func(ctx context.Context) (bool, error) {
+		n, err := d.Deliver(ctx)
+		return n == d.config.Get().WebhookBatchSize, err
+	})
+}
+
+// Deliver sends one batch of due deliveries and returns how many it claimed.
+// Deliveries not reached before the lease of the batch expires are left to
+// be claimed again.
+func (d *Deliverer) Deliver(ctx context.Context) (int, error) {
+	cfg := d.config.Get()
+
+	claimedAt := d.now()
+	deliveries, err := d.repository.ClaimDeliveries(ctx, cfg.WebhookBatchSize, claimedAt, cfg.WebhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// Claimed deliveries are leased to this deliverer, finish them even when ctx is cancelled.
+	ctx = context.WithoutCancel(ctx)
+	expiresAt := claimedAt.Add(cfg.WebhookLease)
+	subs := map[string]*model.WebhookSubscription{}
+	for i := range deliveries {
+		if !d.now().Before(expiresAt) {
+			logger.FromContext(ctx).Warn("webhook lease expired", slog.Int("unsent", len(deliveries)-i))
+			break
+		}
+
+		id := deliveries[i].SubscriptionID.String()
+		sub, ok := subs[id]
+		if !ok {
+			sub, err = d.repository.FindSubscription(ctx, id)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				// The lease expires and the delivery is sent again.
+				continue
+			}
+			subs[id] = sub
+		}
+		d.send(ctx, &deliveries[i], sub, expiresAt, cfg)
+	}
+
+	return len(deliveries), nil
+}
+
+// send posts the delivery to the endpoint of sub, which must answer by
+// expiresAt, and records the outcome. A nil sub has been deleted.
+func (d *Deliverer) send(ctx context.Context, delivery *model.WebhookDelivery, sub *model.WebhookSubscription, expiresAt time.Time, cfg *config.Config) {
+	ctx, span := tracing.Start(ctx, "webhook.Deliverer.send")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("webhook.delivery_id", delivery.ID.String()),
+		attribute.String("webhook.id", delivery.SubscriptionID.String()),
+		attribute.String("outbox.event_type", delivery.EventType),
+		attribute.Int("webhook.attempt", delivery.Attempts),
+	)
+
+	log := logger.FromContext(ctx).With(
+		slog.String("delivery_id", delivery.ID.String()),
+		slog.String("webhook_id", delivery.SubscriptionID.String()),
+		slog.String("event_type", delivery.EventType),
+		slog.Int("attempt", delivery.Attempts),
+	)
+
+	attempt := &model.WebhookAttempt{DeliveryID: delivery.ID}
+	var err error
+	if sub == nil || !sub.Active {
+		err = errInactive
+	} else {
+		start := d.now()
+		err = d.post(ctx, delivery, sub, min(cfg.WebhookTimeout, expiresAt.Sub(start)), attempt)
+		attempt.DurationMS = d.now().Sub(start).Milliseconds()
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if sub != nil {
+		if createErr := d.repository.CreateAttempt(ctx, attempt); createErr != nil {
+			return
+		}
+	}
+
+	now := d.now()
+	if err != nil {
+		tracing.RecordError(span, err)
+		failed := errors.Is(err, errInactive) || delivery.Attempts >= cfg.WebhookMaxAttempts
+		next := now.Add(worker.Backoff(cfg.WebhookRetryBackoff, cfg.WebhookMaxBackoff, delivery.Attempts))
+		if markErr := d.repository.MarkFailed(ctx, delivery.ID.String(), delivery.Attempts, err.Error(), next, failed); markErr != nil {
+			d.leaseLost(log, markErr)
+			return
+		}
+		if failed {
+			log.Error("webhook delivery failed", slog.Any("error", err))
+			d.metrics.ObserveWebhook(delivery.EventType, metrics.WebhookFailed)
+		} else {
+			log.Warn("webhook delivery attempt failed", slog.Any("error", err))
+			d.metrics.ObserveWebhook(delivery.EventType, metrics.WebhookRetried)
+		}
+		return
+	}
+
+	if err := d.repository.MarkSucceeded(ctx, delivery.ID.String(), delivery.Attempts, now); err != nil {
+		// Otherwise the lease expires and the delivery is sent again.
+		d.leaseLost(log, err)
+		return
+	}
+	d.metrics.ObserveWebhook(delivery.EventType, metrics.WebhookSucceeded)
+}
+
+// leaseLost logs that the outcome of a delivery was discarded when err is
+// ErrLeaseLost: the delivery outlived its lease and another deliverer claimed
+// it, or it was redelivered.
+func (d *Deliverer) leaseLost(log *slog.Logger, err error) {
+	if errors.Is(err, ErrLeaseLost) {
+		log.Warn("webhook delivery outcome discarded, its lease expired and it was claimed again")
+	}
+}
+
+// post sends one signed request of the delivery and records the status of
+// the response in attempt. The response body is not kept. It fails unless
+// the endpoint answers with a 2xx status.
+func (d *Deliverer) post(ctx context.Context, delivery *model.WebhookDelivery, sub *model.WebhookSubscription, timeout time.Duration, attempt *model.WebhookAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderID, delivery.EventID.String())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	attempt.StatusCode = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/config"
+	"github.com/PakornBank/go-backend-example/internal/common/metrics"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		WebhookPollInterval: time.Millisecond,
+		WebhookBatchSize:    10,
+		WebhookMaxAttempts:  3,
+		WebhookRetryBackoff: time.Second,
+		WebhookMaxBackoff:   time.Minute,
+		WebhookTimeout:      time.Second,
+		WebhookLease:        time.Minute,
+	}
+}
+
+func setupDelivererTest(t *testing.T) (*Deliverer, *MockRepository) {
+	mockRepo := NewMockRepository(gomock.NewController(t))
+	deliverer := NewDeliverer(mockRepo, config.NewStore(testConfig()), metrics.New())
+	deliverer.now = func() time.Time { return testNow }
+	// The receivers of the tests listen on loopback addresses.
+	deliverer.client = newClient(nil)
+	return deliverer, mockRepo
+}
+
+// receiver is an endpoint that verifies the signature of the requests it
+// receives and answers with status.
+type receiver struct {
+	*httptest.Server
+	secret   string
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func newReceiver(t *testing.T, secret string, status int) *receiver {
+	r := &receiver{secret: secret, status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, string(body))
+
+		err := Verify(r.secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, 5*time.Minute, testNow)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(r.status)
+		_, _ = fmt.Fprint(w, "received")
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestNewDeliverer(t *testing.T) {
+	mockRepo := new(MockRepository)
+	store := config.NewStore(testConfig())
+	m := metrics.New()
+	deliverer := NewDeliverer(mockRepo, store, m)
+
+	assert.Equal(t, mockRepo, deliverer.repository)
+	assert.Equal(t, store, deliverer.config)
+	assert.Equal(t, m, deliverer.metrics)
+	assert.NotNil(t, deliverer.client)
+	assert.NotNil(t, deliverer.now)
+}
+
+func TestDeliverer_Deliver(t *testing.T) {
+	subID := uuid.New()
+	delivery := model.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: subID,
+		EventID:        uuid.New(),
+		EventType:      "user.registered",
+		Payload:        `{"id":"1","type":"user.registered"}`,
+		Status:         StatusPending,
+	}
+
+	tests := []struct {
+		name          string
+		attempts      int
+		status        int
+		signWith      string
+		inactive      bool
+		deleted       bool
+		mockFn        func(*MockRepository, int)
+		wantRequests  int
+		wantStatus    int
+		wantError     string
+		wantResult    string
+		wantNoAttempt bool
+	}{
+		{
+			name:     "accepted",
+			attempts: 1,
+			status:   http.StatusNoContent,
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkSucceeded(gomock.Any(), delivery.ID.String(), attempt, testNow).Return(nil)
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusNoContent,
+			wantResult:   metrics.WebhookSucceeded,
+		},
+		{
+			name:     "rejected and retried",
+			attempts: 2,
+			status:   http.StatusInternalServerError,
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), attempt, "unexpected response status 500", testNow.Add(2*time.Second), false).Return(nil)
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusInternalServerError,
+			wantError:    "unexpected response status 500",
+			wantResult:   metrics.WebhookRetried,
+		},
+		{
+			name:     "redirect not followed",
+			attempts: 1,
+			status:   http.StatusFound,
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), attempt, "unexpected response status 302", testNow.Add(time.Second), false).Return(nil)
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusFound,
+			wantError:    "unexpected response status 302",
+			wantResult:   metrics.WebhookRetried,
+		},
+		{
+			name:     "signature rejected on the last attempt",
+			attempts: 3,
+			status:   http.StatusOK,
+			signWith: "rotated",
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), attempt, "unexpected response status 401", testNow.Add(4*time.Second), true).Return(nil)
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusUnauthorized,
+			wantError:    "unexpected response status 401",
+			wantResult:   metrics.WebhookFailed,
+		},
+		{
+			name:     "inactive subscription",
+			attempts: 1,
+			inactive: true,
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), attempt, errInactive.Error(), testNow.Add(time.Second), true).Return(nil)
+			},
+			wantError:  errInactive.Error(),
+			wantResult: metrics.WebhookFailed,
+		},
+		{
+			name:     "deleted subscription",
+			attempts: 1,
+			deleted:  true,
+			mockFn: func(mr *MockRepository, attempt int) {
+				mr.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), attempt, errInactive.Error(), testNow.Add(time.Second), true).Return(nil)
+			},
+			wantResult:    metrics.WebhookFailed,
+			wantNoAttempt: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliverer, mockRepo := setupDelivererTest(t)
+			recv := newReceiver(t, "secret", tt.status)
+			if tt.signWith != "" {
+				recv.secret = tt.signWith
+			}
+
+			d := delivery
+			d.Attempts = tt.attempts
+			mockRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, testNow, time.Minute).Return([]model.WebhookDelivery{d}, nil)
+			if tt.deleted {
+				mockRepo.EXPECT().FindSubscription(gomock.Any(), subID.String()).Return(nil, ErrNotFound)
+			} else {
+				mockRepo.EXPECT().FindSubscription(gomock.Any(), subID.String()).
+					Return(&model.WebhookSubscription{ID: subID, URL: recv.URL, Secret: "secret", Active: !tt.inactive}, nil)
+			}
+			var attempt *model.WebhookAttempt
+			if !tt.wantNoAttempt {
+				mockRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a *model.WebhookAttempt) error {
+					attempt = a
+					return nil
+				})
+			}
+			tt.mockFn(mockRepo, tt.attempts)
+
+			n, err := deliverer.Deliver(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Len(t, recv.requests, tt.wantRequests)
+			if tt.wantRequests > 0 {
+				req := recv.requests[0]
+				assert.Equal(t, http.MethodPost, req.Method)
+				assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+				assert.Equal(t, d.EventID.String(), req.Header.Get(HeaderID))
+				assert.Equal(t, "user.registered", req.Header.Get(HeaderEvent))
+				assert.Equal(t, fmt.Sprint(testNow.Unix()), req.Header.Get(HeaderTimestamp))
+				assert.Equal(t, d.Payload, recv.bodies[0])
+			}
+			if attempt != nil {
+				assert.Equal(t, d.ID, attempt.DeliveryID)
+				assert.Equal(t, tt.wantStatus, attempt.StatusCode)
+				assert.Equal(t, tt.wantError, attempt.Error)
+			}
+
+			expected := fmt.Sprintf(`
+# HELP go_auth_api_webhook_deliveries_total Total number of webhook delivery attempts by event type and result.
+# TYPE go_auth_api_webhook_deliveries_total counter
+go_auth_api_webhook_deliveries_total{result="%s",type="user.registered"} 1
+`, tt.wantResult)
+			assert.NoError(t, testutil.GatherAndCompare(deliverer.metrics.Registry(), strings.NewReader(expected), "go_auth_api_webhook_deliveries_total"))
+		})
+	}
+}
+
+func TestDeliverer_Deliver_ForbiddenTarget(t *testing.T) {
+	deliverer, mockRepo := setupDelivererTest(t)
+	deliverer.client = newClient(checkTarget)
+	recv := newReceiver(t, "secret", http.StatusOK)
+
+	delivery := model.WebhookDelivery{ID: uuid.New(), SubscriptionID: uuid.New(), Attempts: 1}
+	mockRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, testNow, time.Minute).Return([]model.WebhookDelivery{delivery}, nil)
+	mockRepo.EXPECT().FindSubscription(gomock.Any(), delivery.SubscriptionID.String()).
+		Return(&model.WebhookSubscription{URL: recv.URL, Secret: "secret", Active: true}, nil)
+	mockRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a *model.WebhookAttempt) error {
+		assert.Zero(t, a.StatusCode)
+		assert.Contains(t, a.Error, errForbiddenTarget.Error())
+		return nil
+	})
+	mockRepo.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), 1, gomock.Any(), testNow.Add(time.Second), false).Return(nil)
+
+	_, err := deliverer.Deliver(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, recv.requests)
+}
+
+func TestDeliverer_Deliver_Timeout(t *testing.T) {
+	deliverer, mockRepo := setupDelivererTest(t)
+	cfg := testConfig()
+	cfg.WebhookTimeout = 10 * time.Millisecond
+	deliverer.config = config.NewStore(cfg)
+
+	release := make(chan struct{})
+	recv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-release }))
+	defer recv.Close()
+	defer close(release)
+
+	delivery := model.WebhookDelivery{ID: uuid.New(), SubscriptionID: uuid.New(), Attempts: 1}
+	mockRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, testNow, time.Minute).Return([]model.WebhookDelivery{delivery}, nil)
+	mockRepo.EXPECT().FindSubscription(gomock.Any(), delivery.SubscriptionID.String()).
+		Return(&model.WebhookSubscription{URL: recv.URL, Active: true}, nil)
+	mockRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a *model.WebhookAttempt) error {
+		assert.Zero(t, a.StatusCode)
+		assert.Contains(t, a.Error, "context deadline exceeded")
+		return nil
+	})
+	mockRepo.EXPECT().MarkFailed(gomock.Any(), delivery.ID.String(), 1, gomock.Any(), testNow.Add(time.Second), false).Return(nil)
+
+	_, err := deliverer.Deliver(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestDeliverer_Deliver_Error(t *testing.T) {
+	deliverer, mockRepo := setupDelivererTest(t)
+	mockRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, testNow, time.Minute).Return(nil, errors.New("database error"))
+
+	n, err := deliverer.Deliver(context.Background())
+
+	assert.Error(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDeliverer_Run(t *testing.T) {
+	deliverer, mockRepo := setupDelivererTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, testNow, time.Minute).Return(nil, nil)
+	mockRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, testNow, time.Minute).DoAndReturn(
+		func(context.Context, int, time.Time, time.Duration) ([]model.WebhookDelivery, error) {
+			cancel()
+			return nil, nil
+		})
+
+	done := make(chan struct{})
+	go func() {
+		deliverer.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,25 @@
+package webhook
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when no webhook subscription has the given ID.
+	ErrNotFound = errors.New("webhook not found")
+	// ErrDeliveryNotFound is returned when the webhook subscription has no delivery with the given ID.
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrDeliveryPending is returned when redelivering a delivery that is being sent or waits for its next attempt.
+	ErrDeliveryPending = errors.New("webhook delivery is pending")
+	// ErrLeaseLost is returned when recording the outcome of an attempt of a
+	// delivery that has been claimed again since, once the lease of the attempt expired.
+	ErrLeaseLost = errors.New("webhook delivery was claimed again")
+	// ErrInvalidURL is returned when the URL of a subscription is not an absolute http or https URL.
+	ErrInvalidURL = errors.New("url must be an absolute http or https URL")
+	// ErrForbiddenURL is returned when the URL of a subscription points at the internal network.
+	ErrForbiddenURL = errors.New("url must not point at a loopback, private or link-local address")
+	// ErrInvalidEventTypes is returned when a subscription has no event types or an unknown one.
+	ErrInvalidEventTypes = errors.New("event_types must list known event types")
+	// ErrInvalidSignature is returned by Verify when the signature does not match the request.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleTimestamp is returned by Verify when the request was signed too long ago, or in the future.
+	ErrStaleTimestamp = errors.New("webhook timestamp outside of tolerance")
+)
@@ -0,0 +1,348 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimSQL leases the oldest due pending deliveries by pushing back their
+// next attempt, skipping deliveries leased by other deliverers.
+const claimSQL = `
+UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = ?
+WHERE id IN (
+	SELECT id FROM webhook_deliveries
+	WHERE status = ? AND next_attempt_at <= ?
+	ORDER BY next_attempt_at
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+//go:generate mockgen -destination=./repository_mock.go -package=webhook github.com/PakornBank/go-backend-example/internal/webhook Repository
+
+// Repository defines the methods that a repository must implement.
+type Repository interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	FindSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	ActiveSubscriptions(ctx context.Context, eventType string) ([]model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+	CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	ClaimDeliveries(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]model.WebhookDelivery, error)
+	MarkSucceeded(ctx context.Context, id string, attempt int, at time.Time) error
+	MarkFailed(ctx context.Context, id string, attempt int, lastError string, nextAttemptAt time.Time, failed bool) error
+	ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]model.WebhookDelivery, error)
+	FindDelivery(ctx context.Context, subscriptionID, id string) (*model.WebhookDelivery, error)
+	ResetDelivery(ctx context.Context, subscriptionID, id string, now time.Time) error
+	CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error
+	ListAttempts(ctx context.Context, deliveryID string) ([]model.WebhookAttempt, error)
+}
+
+// repository is a struct that provides methods to interact with the webhook records in the database.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository creates a new instance of repository with the provided gorm.DB connection and query timeout.
+// Queries join the transaction carried by the context, if any, see database.TxManager.
+func NewRepository(db *gorm.DB, timeout time.Duration) Repository {
+	return &repository{db: db, timeout: timeout}
+}
+
+// CreateSubscription inserts a new webhook subscription record into the database.
+func (r *repository) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(sub).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to create webhook subscription", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// ListSubscriptions retrieves every webhook subscription, oldest first.
+func (r *repository) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var subs []model.WebhookSubscription
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Order("created_at").Find(&subs).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to list webhook subscriptions", slog.Any("error", err))
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// FindSubscription retrieves the webhook subscription with the given ID.
+func (r *repository) FindSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var sub model.WebhookSubscription
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).First(&sub, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find webhook subscription", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// ActiveSubscriptions retrieves the active webhook subscriptions to the given type of events.
+func (r *repository) ActiveSubscriptions(ctx context.Context, eventType string) ([]model.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var subs []model.WebhookSubscription
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).
+		Where("active AND ? = ANY(string_to_array(event_types, ','))", eventType).Find(&subs).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find webhook subscriptions", slog.Any("error", err))
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// UpdateSubscription stores the URL, event types, secret and state of the given webhook subscription.
+func (r *repository) UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.WebhookSubscription{}).Where("id = ?", sub.ID).
+		Updates(map[string]interface{}{
+			"url":         sub.URL,
+			"event_types": sub.EventTypes,
+			"secret":      sub.Secret,
+			"active":      sub.Active,
+		})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to update webhook subscription", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteSubscription deletes the webhook subscription with the given ID and, through the foreign keys, its deliveries.
+func (r *repository) DeleteSubscription(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Where("id = ?", id).Delete(&model.WebhookSubscription{})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to delete webhook subscription", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateDeliveries inserts the given webhook deliveries, skipping those of
+// events already delivered to their subscription.
+func (r *repository) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create webhook deliveries", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDeliveries leases at most limit pending deliveries due at now, oldest
+// first, and counts the attempt. Leased deliveries are not claimed again
+// before the lease expires, so a delivery whose deliverer dies is retried once
+// it does.
+func (r *repository) ClaimDeliveries(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var deliveries []model.WebhookDelivery
+
+	err := database.Conn(ctx, r.db).WithContext(database.UsePrimary(ctx)).
+		Raw(claimSQL, now.Add(lease), StatusPending, now, limit).Scan(&deliveries).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to claim webhook deliveries", slog.Any("error", err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// MarkSucceeded marks the delivery with the given ID as accepted by its
+// endpoint on the given attempt. It returns ErrLeaseLost, changing nothing,
+// when the delivery has been claimed again or redelivered since.
+func (r *repository) MarkSucceeded(ctx context.Context, id string, attempt int, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ? AND attempts = ?", id, attempt).
+		Updates(map[string]interface{}{"status": StatusSucceeded, "delivered_at": at, "last_error": ""})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to mark webhook delivery succeeded", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// MarkFailed records the error of the given attempt of the delivery with the
+// given ID and schedules the next one, or gives up on the delivery when
+// failed is set. It returns ErrLeaseLost, changing nothing, when the delivery
+// has been claimed again or redelivered since.
+func (r *repository) MarkFailed(ctx context.Context, id string, attempt int, lastError string, nextAttemptAt time.Time, failed bool) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	status := StatusPending
+	if failed {
+		status = StatusFailed
+	}
+
+	result := database.Conn(ctx, r.db).WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ? AND attempts = ?", id, attempt).
+		Updates(map[string]interface{}{"status": status, "next_attempt_at": nextAttemptAt, "last_error": lastError})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to mark webhook delivery failed", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the deliveries of the given webhook subscription,
+// newest first, at most limit of them. A non-empty status only matches
+// deliveries in that status.
+func (r *repository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var deliveries []model.WebhookDelivery
+
+	query := database.Conn(ctx, r.db).WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to list webhook deliveries", slog.Any("error", err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// FindDelivery retrieves the delivery with the given ID of the given webhook subscription.
+func (r *repository) FindDelivery(ctx context.Context, subscriptionID, id string) (*model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var delivery model.WebhookDelivery
+
+	err := database.Conn(ctx, r.db).WithContext(ctx).
+		First(&delivery, "id = ? AND subscription_id = ?", id, subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find webhook delivery", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// ResetDelivery schedules the delivery with the given ID of the given webhook
+// subscription to be sent again at now, with a fresh count of attempts. It
+// returns ErrDeliveryPending, changing nothing, when the delivery is pending
+// and not due before now: it is leased to a deliverer, which may be sending
+// it, or waits for its next attempt.
+func (r *repository) ResetDelivery(ctx context.Context, subscriptionID, id string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	conn := database.Conn(ctx, r.db).WithContext(ctx)
+	result := conn.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND subscription_id = ?", id, subscriptionID).
+		Where("NOT (status = ? AND next_attempt_at > ?)", StatusPending, now).
+		Updates(map[string]interface{}{"status": StatusPending, "attempts": 0, "next_attempt_at": now})
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("failed to reset webhook delivery", slog.Any("error", result.Error))
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := conn.Model(&model.WebhookDelivery{}).Where("id = ? AND subscription_id = ?", id, subscriptionID).Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to find webhook delivery", slog.Any("error", err))
+		return err
+	}
+	if count == 0 {
+		return ErrDeliveryNotFound
+	}
+
+	return ErrDeliveryPending
+}
+
+// CreateAttempt inserts a new webhook attempt record into the database.
+func (r *repository) CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Create(attempt).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to create webhook attempt", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// ListAttempts retrieves the attempts of the delivery with the given ID, oldest first.
+func (r *repository) ListAttempts(ctx context.Context, deliveryID string) ([]model.WebhookAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var attempts []model.WebhookAttempt
+
+	if err := database.Conn(ctx, r.db).WithContext(ctx).Where("delivery_id = ?", deliveryID).Order("created_at").Find(&attempts).Error; err != nil {
+		logger.FromContext(ctx).Error("failed to list webhook attempts", slog.Any("error", err))
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,516 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRepositoryTest(t *testing.T) (sqlmock.Sqlmock, Repository) {
+	_, gormDB, sqlMock := testutil.DBMock(t)
+	webhookRepo := NewRepository(gormDB, 5*time.Second)
+	return sqlMock, webhookRepo
+}
+
+func TestNewRepository(t *testing.T) {
+	_, gormDB, _ := testutil.DBMock(t)
+	webhookRepo := NewRepository(gormDB, 5*time.Second)
+	assert.NotNil(t, webhookRepo)
+	assert.Equal(t, gormDB, webhookRepo.(*repository).db)
+	assert.Equal(t, 5*time.Second, webhookRepo.(*repository).timeout)
+}
+
+func Test_repository_CreateSubscription(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "subscription created",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "webhook_subscriptions"`).
+					WithArgs("https://example.com", "user.registered", "secret", true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(uuid.New(), now, now))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`INSERT INTO "webhook_subscriptions"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			sub := &model.WebhookSubscription{URL: "https://example.com", EventTypes: "user.registered", Secret: "secret", Active: true}
+			err := webhookRepo.CreateSubscription(context.Background(), sub)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, uuid.Nil, sub.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_FindSubscription(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "subscription found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions" WHERE id = \$1 ORDER BY "webhook_subscriptions"."id" LIMIT \$2`).
+					WithArgs(id.String(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "url"}).AddRow(id, "https://example.com"))
+			},
+		},
+		{
+			name: "subscription not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			errType: ErrNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions"`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := webhookRepo.FindSubscription(context.Background(), id.String())
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, id, got.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ActiveSubscriptions(t *testing.T) {
+	sqlMock, webhookRepo := setupRepositoryTest(t)
+	sqlMock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions" WHERE active AND \$1 = ANY\(string_to_array\(event_types, ','\)\)`).
+		WithArgs("user.deleted").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()))
+
+	got, err := webhookRepo.ActiveSubscriptions(context.Background(), "user.deleted")
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_UpdateSubscription(t *testing.T) {
+	sub := &model.WebhookSubscription{ID: uuid.New(), URL: "https://example.com", EventTypes: "user.deleted", Secret: "secret"}
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "subscription updated",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "webhook_subscriptions" SET "active"=\$1,"event_types"=\$2,"secret"=\$3,"url"=\$4,"updated_at"=\$5 WHERE id = \$6`).
+					WithArgs(false, "user.deleted", "secret", "https://example.com", sqlmock.AnyArg(), sub.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "subscription not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "webhook_subscriptions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: ErrNotFound,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE "webhook_subscriptions"`).WillReturnError(sql.ErrConnDone)
+				sqlMock.ExpectRollback()
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := webhookRepo.UpdateSubscription(context.Background(), sub)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_DeleteSubscription(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "subscription deleted",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "webhook_subscriptions" WHERE id = \$1`).
+					WithArgs(id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "subscription not found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`DELETE FROM "webhook_subscriptions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectCommit()
+			},
+			errType: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			err := webhookRepo.DeleteSubscription(context.Background(), id)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_CreateDeliveries(t *testing.T) {
+	sqlMock, webhookRepo := setupRepositoryTest(t)
+	now := time.Now()
+	deliveries := []model.WebhookDelivery{
+		{SubscriptionID: uuid.New(), EventID: uuid.New(), EventType: "user.deleted", Payload: "{}", Status: StatusPending, NextAttemptAt: now},
+		{SubscriptionID: uuid.New(), EventID: uuid.New(), EventType: "user.deleted", Payload: "{}", Status: StatusPending, NextAttemptAt: now},
+	}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`INSERT INTO "webhook_deliveries" .* ON CONFLICT DO NOTHING RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+	sqlMock.ExpectCommit()
+
+	err := webhookRepo.CreateDeliveries(context.Background(), deliveries)
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_ClaimDeliveries(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantLen int
+		errType error
+	}{
+		{
+			name: "deliveries claimed",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "subscription_id", "attempts"}).
+					AddRow(uuid.New(), uuid.New(), 1).
+					AddRow(uuid.New(), uuid.New(), 4)
+				sqlMock.ExpectQuery(`UPDATE webhook_deliveries SET attempts = attempts \+ 1, next_attempt_at = \$1\s+WHERE id IN \(\s+SELECT id FROM webhook_deliveries\s+WHERE status = \$2 AND next_attempt_at <= \$3\s+ORDER BY next_attempt_at\s+LIMIT \$4\s+FOR UPDATE SKIP LOCKED\s+\)\s+RETURNING \*`).
+					WithArgs(now.Add(time.Minute), StatusPending, now, 10).
+					WillReturnRows(rows)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "database error",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`UPDATE webhook_deliveries`).WillReturnError(sql.ErrConnDone)
+			},
+			errType: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := webhookRepo.ClaimDeliveries(context.Background(), 10, now, time.Minute)
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkSucceeded(t *testing.T) {
+	id := uuid.New().String()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		rows    int64
+		errType error
+	}{
+		{name: "delivery succeeded", rows: 1},
+		{name: "delivery claimed again", rows: 0, errType: ErrLeaseLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec(`UPDATE "webhook_deliveries" SET "delivered_at"=\$1,"last_error"=\$2,"status"=\$3 WHERE id = \$4 AND attempts = \$5`).
+				WithArgs(now, "", StatusSucceeded, id, 2).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			sqlMock.ExpectCommit()
+
+			err := webhookRepo.MarkSucceeded(context.Background(), id, 2, now)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_MarkFailed(t *testing.T) {
+	id := uuid.New().String()
+	next := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name       string
+		failed     bool
+		rows       int64
+		wantStatus string
+		errType    error
+	}{
+		{name: "retry scheduled", rows: 1, wantStatus: StatusPending},
+		{name: "delivery failed", failed: true, rows: 1, wantStatus: StatusFailed},
+		{name: "delivery claimed again", rows: 0, wantStatus: StatusPending, errType: ErrLeaseLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec(`UPDATE "webhook_deliveries" SET "last_error"=\$1,"next_attempt_at"=\$2,"status"=\$3 WHERE id = \$4 AND attempts = \$5`).
+				WithArgs("unexpected response status 500", next, tt.wantStatus, id, 2).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			sqlMock.ExpectCommit()
+
+			err := webhookRepo.MarkFailed(context.Background(), id, 2, "unexpected response status 500", next, tt.failed)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ListDeliveries(t *testing.T) {
+	subID := uuid.New().String()
+
+	tests := []struct {
+		name   string
+		status string
+		mockFn func(sqlmock.Sqlmock)
+	}{
+		{
+			name: "every status",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_deliveries" WHERE subscription_id = \$1 ORDER BY created_at DESC LIMIT \$2`).
+					WithArgs(subID, 20).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+			},
+		},
+		{
+			name:   "one status",
+			status: StatusFailed,
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_deliveries" WHERE subscription_id = \$1 AND status = \$2 ORDER BY created_at DESC LIMIT \$3`).
+					WithArgs(subID, StatusFailed, 20).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := webhookRepo.ListDeliveries(context.Background(), subID, tt.status, 20)
+
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_FindDelivery(t *testing.T) {
+	subID, id := uuid.New().String(), uuid.New()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		errType error
+	}{
+		{
+			name: "delivery found",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_deliveries" WHERE id = \$1 AND subscription_id = \$2 ORDER BY "webhook_deliveries"."id" LIMIT \$3`).
+					WithArgs(id.String(), subID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+			},
+		},
+		{
+			name: "delivery of another subscription",
+			mockFn: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT \* FROM "webhook_deliveries"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			errType: ErrDeliveryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			tt.mockFn(sqlMock)
+
+			got, err := webhookRepo.FindDelivery(context.Background(), subID, id.String())
+
+			if tt.errType != nil {
+				assert.ErrorIs(t, err, tt.errType)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, id, got.ID)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_ResetDelivery(t *testing.T) {
+	subID, id := uuid.New().String(), uuid.New().String()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		rows    int64
+		count   int64
+		errType error
+	}{
+		{name: "delivery reset", rows: 1},
+		{name: "delivery not found", rows: 0, count: 0, errType: ErrDeliveryNotFound},
+		{name: "delivery pending", rows: 0, count: 1, errType: ErrDeliveryPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlMock, webhookRepo := setupRepositoryTest(t)
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec(`UPDATE "webhook_deliveries" SET "attempts"=\$1,"next_attempt_at"=\$2,"status"=\$3 WHERE \(id = \$4 AND subscription_id = \$5\) AND \(NOT \(status = \$6 AND next_attempt_at > \$7\)\)`).
+				WithArgs(0, now, StatusPending, id, subID, StatusPending, now).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			sqlMock.ExpectCommit()
+			if tt.rows == 0 {
+				sqlMock.ExpectQuery(`SELECT count\(\*\) FROM "webhook_deliveries" WHERE id = \$1 AND subscription_id = \$2`).
+					WithArgs(id, subID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			}
+
+			err := webhookRepo.ResetDelivery(context.Background(), subID, id, now)
+
+			assert.ErrorIs(t, err, tt.errType)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_repository_CreateAttempt(t *testing.T) {
+	sqlMock, webhookRepo := setupRepositoryTest(t)
+	deliveryID := uuid.New()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`INSERT INTO "webhook_attempts"`).
+		WithArgs(deliveryID, 200, "", int64(12)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), time.Now()))
+	sqlMock.ExpectCommit()
+
+	err := webhookRepo.CreateAttempt(context.Background(), &model.WebhookAttempt{DeliveryID: deliveryID, StatusCode: 200, DurationMS: 12})
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_repository_ListAttempts(t *testing.T) {
+	sqlMock, webhookRepo := setupRepositoryTest(t)
+	deliveryID := uuid.New().String()
+
+	sqlMock.ExpectQuery(`SELECT \* FROM "webhook_attempts" WHERE delivery_id = \$1 ORDER BY created_at`).
+		WithArgs(deliveryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status_code"}).AddRow(uuid.New(), 500).AddRow(uuid.New(), 200))
+
+	got, err := webhookRepo.ListAttempts(context.Background(), deliveryID)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,389 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/logger"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/common/tracing"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxURLLength is the length of the url column.
+const maxURLLength = 2048
+
+// secretBytes is the number of random bytes of generated secrets.
+const secretBytes = 32
+
+//go:generate mockgen -destination=./service_mock.go -package=webhook github.com/PakornBank/go-backend-example/internal/webhook Service
+
+// Service defines the methods that a service must implement.
+type Service interface {
+	Create(ctx context.Context, actorID string, input Input) (*model.WebhookSubscription, error)
+	List(ctx context.Context) ([]model.WebhookSubscription, error)
+	Get(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	Update(ctx context.Context, actorID, id string, input Input) (*model.WebhookSubscription, error)
+	Delete(ctx context.Context, actorID, id string) error
+	Deliveries(ctx context.Context, id, status string, limit int) ([]model.WebhookDelivery, error)
+	Delivery(ctx context.Context, id, deliveryID string) (*model.WebhookDelivery, []model.WebhookAttempt, error)
+	Redeliver(ctx context.Context, actorID, id, deliveryID string) error
+	Enqueue(ctx context.Context, event outbox.Event) error
+}
+
+// service is a struct that provides methods to interact with the webhook service.
+type service struct {
+	repository Repository
+	tx         database.TxManager
+	auditLog   audit.Service
+	now        func() time.Time
+}
+
+// NewService creates a new instance of service with the provided repository, transaction manager and audit service.
+func NewService(repository Repository, tx database.TxManager, auditLog audit.Service) Service {
+	return &service{repository: repository, tx: tx, auditLog: auditLog, now: time.Now}
+}
+
+// Create subscribes the endpoint described by input on behalf of the admin
+// with the given ID. The returned subscription holds the secret.
+func (s *service) Create(ctx context.Context, actorID string, input Input) (*model.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Create")
+	defer span.End()
+
+	eventTypes, err := validate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+	}
+
+	sub := &model.WebhookSubscription{
+		URL:        input.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     input.Active,
+	}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.CreateSubscription(ctx, sub); err != nil {
+			return err
+		}
+		return s.record(ctx, actorID, audit.ActionWebhookCreated, sub.ID.String(), subscriptionMetadata(sub))
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("webhook created", slog.String("webhook_id", sub.ID.String()))
+	return sub, nil
+}
+
+// List returns every webhook subscription, oldest first.
+func (s *service) List(ctx context.Context) ([]model.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.List")
+	defer span.End()
+
+	subs, err := s.repository.ListSubscriptions(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// Get returns the webhook subscription with the given ID.
+func (s *service) Get(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Get")
+	defer span.End()
+
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+
+	sub, err := s.repository.FindSubscription(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Update replaces the settings of the webhook subscription with the given ID
+// on behalf of the admin with the given ID. An empty secret keeps the current one.
+func (s *service) Update(ctx context.Context, actorID, id string, input Input) (*model.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Update")
+	defer span.End()
+	span.SetAttributes(attribute.String("webhook_id", id))
+
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	eventTypes, err := validate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub *model.WebhookSubscription
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = s.repository.FindSubscription(ctx, id); err != nil {
+			return err
+		}
+
+		metadata := map[string]interface{}{
+			"old":            subscriptionMetadata(sub),
+			"secret_rotated": input.Secret != "" && input.Secret != sub.Secret,
+		}
+		sub.URL, sub.EventTypes, sub.Active = input.URL, eventTypes, input.Active
+		if input.Secret != "" {
+			sub.Secret = input.Secret
+		}
+		if err := s.repository.UpdateSubscription(ctx, sub); err != nil {
+			return err
+		}
+		metadata["new"] = subscriptionMetadata(sub)
+		return s.record(ctx, actorID, audit.ActionWebhookUpdated, id, metadata)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("webhook updated", slog.String("webhook_id", id))
+	return sub, nil
+}
+
+// Delete removes the webhook subscription with the given ID, with its
+// deliveries, on behalf of the admin with the given ID.
+func (s *service) Delete(ctx context.Context, actorID, id string) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Delete")
+	defer span.End()
+	span.SetAttributes(attribute.String("webhook_id", id))
+
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		sub, err := s.repository.FindSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repository.DeleteSubscription(ctx, id); err != nil {
+			return err
+		}
+		return s.record(ctx, actorID, audit.ActionWebhookDeleted, id, subscriptionMetadata(sub))
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	logger.FromContext(ctx).Info("webhook deleted", slog.String("webhook_id", id))
+	return nil
+}
+
+// Deliveries returns the deliveries of the webhook subscription with the
+// given ID, newest first, at most limit of them. A non-empty status only
+// returns deliveries in that status.
+func (s *service) Deliveries(ctx context.Context, id, status string, limit int) ([]model.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Deliveries")
+	defer span.End()
+
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	if _, err := s.repository.FindSubscription(ctx, id); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	deliveries, err := s.repository.ListDeliveries(ctx, id, status, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Delivery returns the delivery with the given ID of the webhook subscription
+// with the given ID, and its attempts, oldest first.
+func (s *service) Delivery(ctx context.Context, id, deliveryID string) (*model.WebhookDelivery, []model.WebhookAttempt, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Delivery")
+	defer span.End()
+
+	if !validID(id) || !validID(deliveryID) {
+		return nil, nil, ErrDeliveryNotFound
+	}
+
+	delivery, err := s.repository.FindDelivery(ctx, id, deliveryID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, nil, err
+	}
+	attempts, err := s.repository.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, nil, err
+	}
+
+	return delivery, attempts, nil
+}
+
+// Redeliver schedules the delivery with the given ID of the webhook
+// subscription with the given ID to be sent again at once, on behalf of the
+// admin with the given ID. A pending delivery that is not due yet, because it
+// is being sent or waits for its next attempt, is left alone and
+// ErrDeliveryPending is returned.
+func (s *service) Redeliver(ctx context.Context, actorID, id, deliveryID string) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Redeliver")
+	defer span.End()
+	span.SetAttributes(attribute.String("webhook_id", id), attribute.String("delivery_id", deliveryID))
+
+	if !validID(id) || !validID(deliveryID) {
+		return ErrDeliveryNotFound
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.ResetDelivery(ctx, id, deliveryID, s.now()); err != nil {
+			return err
+		}
+		return s.record(ctx, actorID, audit.ActionWebhookResent, id, map[string]interface{}{"delivery_id": deliveryID})
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	logger.FromContext(ctx).Info("webhook delivery rescheduled", slog.String("webhook_id", id), slog.String("delivery_id", deliveryID))
+	return nil
+}
+
+// Enqueue schedules the delivery of event to every active subscription to its
+// type. It is idempotent, so that it can handle events of the outbox, which
+// may be delivered more than once.
+func (s *service) Enqueue(ctx context.Context, event outbox.Event) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Enqueue")
+	defer span.End()
+	span.SetAttributes(attribute.String("outbox.event_id", event.ID.String()), attribute.String("outbox.event_type", event.Type))
+
+	subs, err := s.repository.ActiveSubscriptions(ctx, event.Type)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Payload{
+		ID:        event.ID.String(),
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      event.Payload,
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	now := s.now()
+	deliveries := make([]model.WebhookDelivery, len(subs))
+	for i, sub := range subs {
+		deliveries[i] = model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(body),
+			Status:         StatusPending,
+			NextAttemptAt:  now,
+		}
+	}
+	if err := s.repository.CreateDeliveries(ctx, deliveries); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// record adds an action of the admin with the given ID on a webhook to the audit log.
+func (s *service) record(ctx context.Context, actorID, action, id string, metadata map[string]interface{}) error {
+	return s.auditLog.Record(ctx, audit.Event{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: audit.TargetWebhook,
+		TargetID:   id,
+		Metadata:   metadata,
+	})
+}
+
+// validate checks input and returns its event types in their stored form.
+func validate(input Input) (string, error) {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(input.URL) > maxURLLength {
+		return "", ErrInvalidURL
+	}
+	if !allowedHost(u.Hostname()) {
+		return "", ErrForbiddenURL
+	}
+
+	if len(input.EventTypes) == 0 {
+		return "", ErrInvalidEventTypes
+	}
+	var eventTypes []string
+	for _, t := range input.EventTypes {
+		if !slices.Contains(EventTypes, t) {
+			return "", ErrInvalidEventTypes
+		}
+		if !slices.Contains(eventTypes, t) {
+			eventTypes = append(eventTypes, t)
+		}
+	}
+
+	return strings.Join(eventTypes, ","), nil
+}
+
+// validID reports whether id is a UUID, as the IDs of subscriptions and deliveries are.
+func validID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// generateSecret returns a random hex secret.
+func generateSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// subscriptionMetadata returns the settings of sub, but its secret, to record with an action.
+func subscriptionMetadata(sub *model.WebhookSubscription) map[string]interface{} {
+	return map[string]interface{}{
+		"url":         sub.URL,
+		"event_types": sub.EventTypes,
+		"active":      sub.Active,
+	}
+}
@@ -0,0 +1,430 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/audit"
+	"github.com/PakornBank/go-backend-example/internal/common/database"
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupServiceTest(t *testing.T) (*service, *MockRepository, *audit.MockService) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockRepository(ctrl)
+	mockTx := database.NewMockTxManager(ctrl)
+	mockTx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	mockAudit := audit.NewMockService(ctrl)
+	webhookService := &service{
+		repository: mockRepo,
+		tx:         mockTx,
+		auditLog:   mockAudit,
+		now:        func() time.Time { return testNow },
+	}
+	return webhookService, mockRepo, mockAudit
+}
+
+func TestNewService(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTx := new(database.MockTxManager)
+	auditLog := new(audit.MockService)
+	webhookService := NewService(mockRepo, mockTx, auditLog)
+
+	assert.NotNil(t, webhookService)
+	assert.Equal(t, mockRepo, webhookService.(*service).repository)
+	assert.Equal(t, mockTx, webhookService.(*service).tx)
+	assert.Equal(t, auditLog, webhookService.(*service).auditLog)
+	assert.NotNil(t, webhookService.(*service).now)
+}
+
+func Test_service_Create(t *testing.T) {
+	actorID := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		input      Input
+		mockFn     func(*MockRepository, *audit.MockService)
+		wantTypes  string
+		wantSecret string
+		wantErr    error
+	}{
+		{
+			name:  "secret generated",
+			input: Input{URL: "https://example.com/hooks", EventTypes: []string{"user.registered", "user.deleted", "user.registered"}, Active: true},
+			mockFn: func(mr *MockRepository, ma *audit.MockService) {
+				mr.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Event) error {
+					assert.Equal(t, actorID, e.ActorID)
+					assert.Equal(t, audit.ActionWebhookCreated, e.Action)
+					assert.Equal(t, audit.TargetWebhook, e.TargetType)
+					assert.NotContains(t, e.Metadata, "secret")
+					return nil
+				})
+			},
+			wantTypes: "user.registered,user.deleted",
+		},
+		{
+			name:  "secret given",
+			input: Input{URL: "http://hooks.example.com:8081/hooks", EventTypes: []string{"auth.login_failed"}, Secret: "0123456789abcdef"},
+			mockFn: func(mr *MockRepository, ma *audit.MockService) {
+				mr.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantTypes:  "auth.login_failed",
+			wantSecret: "0123456789abcdef",
+		},
+		{
+			name:    "relative URL",
+			input:   Input{URL: "/hooks", EventTypes: []string{"user.registered"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrInvalidURL,
+		},
+		{
+			name:    "unsupported scheme",
+			input:   Input{URL: "ftp://example.com", EventTypes: []string{"user.registered"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrInvalidURL,
+		},
+		{
+			name:    "metadata service",
+			input:   Input{URL: "http://169.254.169.254/latest/meta-data", EventTypes: []string{"user.registered"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrForbiddenURL,
+		},
+		{
+			name:    "localhost",
+			input:   Input{URL: "http://localhost:8081/hooks", EventTypes: []string{"user.registered"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrForbiddenURL,
+		},
+		{
+			name:    "no event types",
+			input:   Input{URL: "https://example.com"},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrInvalidEventTypes,
+		},
+		{
+			name:    "unknown event type",
+			input:   Input{URL: "https://example.com", EventTypes: []string{"user.*"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrInvalidEventTypes,
+		},
+		{
+			name:  "audit error",
+			input: Input{URL: "https://example.com", EventTypes: []string{"user.registered"}},
+			mockFn: func(mr *MockRepository, ma *audit.MockService) {
+				mr.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("audit error"))
+			},
+			wantErr: errors.New("audit error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhookService, mockRepo, mockAudit := setupServiceTest(t)
+			tt.mockFn(mockRepo, mockAudit)
+
+			sub, err := webhookService.Create(context.Background(), actorID, tt.input)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Nil(t, sub)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input.URL, sub.URL)
+			assert.Equal(t, tt.wantTypes, sub.EventTypes)
+			assert.Equal(t, tt.input.Active, sub.Active)
+			if tt.wantSecret != "" {
+				assert.Equal(t, tt.wantSecret, sub.Secret)
+			} else {
+				assert.Len(t, sub.Secret, 2*secretBytes)
+			}
+		})
+	}
+}
+
+func Test_service_Update(t *testing.T) {
+	actorID := uuid.New().String()
+	id := uuid.New()
+	current := func() *model.WebhookSubscription {
+		return &model.WebhookSubscription{ID: id, URL: "https://old.example.com", EventTypes: "user.registered", Secret: "old-secret", Active: true}
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		input      Input
+		mockFn     func(*MockRepository, *audit.MockService)
+		wantSecret string
+		wantErr    error
+	}{
+		{
+			name:  "secret kept",
+			id:    id.String(),
+			input: Input{URL: "https://new.example.com", EventTypes: []string{"user.deleted"}},
+			mockFn: func(mr *MockRepository, ma *audit.MockService) {
+				mr.EXPECT().FindSubscription(gomock.Any(), id.String()).Return(current(), nil)
+				mr.EXPECT().UpdateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Event) error {
+					assert.Equal(t, audit.ActionWebhookUpdated, e.Action)
+					assert.Equal(t, id.String(), e.TargetID)
+					assert.Equal(t, false, e.Metadata["secret_rotated"])
+					assert.Equal(t, "https://old.example.com", e.Metadata["old"].(map[string]interface{})["url"])
+					assert.Equal(t, "https://new.example.com", e.Metadata["new"].(map[string]interface{})["url"])
+					return nil
+				})
+			},
+			wantSecret: "old-secret",
+		},
+		{
+			name:  "secret rotated",
+			id:    id.String(),
+			input: Input{URL: "https://old.example.com", EventTypes: []string{"user.registered"}, Secret: "new-secret"},
+			mockFn: func(mr *MockRepository, ma *audit.MockService) {
+				mr.EXPECT().FindSubscription(gomock.Any(), id.String()).Return(current(), nil)
+				mr.EXPECT().UpdateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Event) error {
+					assert.Equal(t, true, e.Metadata["secret_rotated"])
+					return nil
+				})
+			},
+			wantSecret: "new-secret",
+		},
+		{
+			name:    "invalid ID",
+			id:      "not-a-uuid",
+			input:   Input{URL: "https://example.com", EventTypes: []string{"user.registered"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:  "not found",
+			id:    id.String(),
+			input: Input{URL: "https://example.com", EventTypes: []string{"user.registered"}},
+			mockFn: func(mr *MockRepository, _ *audit.MockService) {
+				mr.EXPECT().FindSubscription(gomock.Any(), id.String()).Return(nil, ErrNotFound)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "invalid input",
+			id:      id.String(),
+			input:   Input{URL: "example.com", EventTypes: []string{"user.registered"}},
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrInvalidURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhookService, mockRepo, mockAudit := setupServiceTest(t)
+			tt.mockFn(mockRepo, mockAudit)
+
+			sub, err := webhookService.Update(context.Background(), actorID, tt.id, tt.input)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input.URL, sub.URL)
+			assert.Equal(t, tt.wantSecret, sub.Secret)
+		})
+	}
+}
+
+func Test_service_Delete(t *testing.T) {
+	actorID := uuid.New().String()
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		id      string
+		mockFn  func(*MockRepository, *audit.MockService)
+		wantErr error
+	}{
+		{
+			name: "deleted",
+			id:   id.String(),
+			mockFn: func(mr *MockRepository, ma *audit.MockService) {
+				mr.EXPECT().FindSubscription(gomock.Any(), id.String()).Return(&model.WebhookSubscription{ID: id, URL: "https://example.com"}, nil)
+				mr.EXPECT().DeleteSubscription(gomock.Any(), id.String()).Return(nil)
+				ma.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Event) error {
+					assert.Equal(t, audit.ActionWebhookDeleted, e.Action)
+					assert.Equal(t, "https://example.com", e.Metadata["url"])
+					return nil
+				})
+			},
+		},
+		{
+			name:    "invalid ID",
+			id:      "1",
+			mockFn:  func(*MockRepository, *audit.MockService) {},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "not found",
+			id:   id.String(),
+			mockFn: func(mr *MockRepository, _ *audit.MockService) {
+				mr.EXPECT().FindSubscription(gomock.Any(), id.String()).Return(nil, ErrNotFound)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhookService, mockRepo, mockAudit := setupServiceTest(t)
+			tt.mockFn(mockRepo, mockAudit)
+
+			err := webhookService.Delete(context.Background(), actorID, tt.id)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_service_Deliveries(t *testing.T) {
+	id := uuid.New().String()
+	deliveries := []model.WebhookDelivery{{ID: uuid.New()}}
+
+	t.Run("listed", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().FindSubscription(gomock.Any(), id).Return(&model.WebhookSubscription{}, nil)
+		mockRepo.EXPECT().ListDeliveries(gomock.Any(), id, StatusFailed, 10).Return(deliveries, nil)
+
+		got, err := webhookService.Deliveries(context.Background(), id, StatusFailed, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, deliveries, got)
+	})
+
+	t.Run("unknown subscription", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().FindSubscription(gomock.Any(), id).Return(nil, ErrNotFound)
+
+		_, err := webhookService.Deliveries(context.Background(), id, "", 10)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func Test_service_Delivery(t *testing.T) {
+	id, deliveryID := uuid.New().String(), uuid.New().String()
+	delivery := &model.WebhookDelivery{ID: uuid.MustParse(deliveryID)}
+	attempts := []model.WebhookAttempt{{StatusCode: 500}, {StatusCode: 200}}
+
+	t.Run("found", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().FindDelivery(gomock.Any(), id, deliveryID).Return(delivery, nil)
+		mockRepo.EXPECT().ListAttempts(gomock.Any(), deliveryID).Return(attempts, nil)
+
+		gotDelivery, gotAttempts, err := webhookService.Delivery(context.Background(), id, deliveryID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, delivery, gotDelivery)
+		assert.Equal(t, attempts, gotAttempts)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		webhookService, _, _ := setupServiceTest(t)
+
+		_, _, err := webhookService.Delivery(context.Background(), id, "x")
+
+		assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	})
+}
+
+func Test_service_Redeliver(t *testing.T) {
+	actorID := uuid.New().String()
+	id, deliveryID := uuid.New().String(), uuid.New().String()
+
+	t.Run("rescheduled", func(t *testing.T) {
+		webhookService, mockRepo, mockAudit := setupServiceTest(t)
+		mockRepo.EXPECT().ResetDelivery(gomock.Any(), id, deliveryID, testNow).Return(nil)
+		mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Event) error {
+			assert.Equal(t, actorID, e.ActorID)
+			assert.Equal(t, audit.ActionWebhookResent, e.Action)
+			assert.Equal(t, id, e.TargetID)
+			assert.Equal(t, deliveryID, e.Metadata["delivery_id"])
+			return nil
+		})
+
+		assert.NoError(t, webhookService.Redeliver(context.Background(), actorID, id, deliveryID))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().ResetDelivery(gomock.Any(), id, deliveryID, testNow).Return(ErrDeliveryNotFound)
+
+		assert.ErrorIs(t, webhookService.Redeliver(context.Background(), actorID, id, deliveryID), ErrDeliveryNotFound)
+	})
+}
+
+func Test_service_Enqueue(t *testing.T) {
+	event := outbox.Event{
+		ID:        uuid.New(),
+		Type:      outbox.EventUserDeleted,
+		Payload:   json.RawMessage(`{"user_id":"1","email":"user@example.com"}`),
+		CreatedAt: testNow.Add(-time.Second),
+		Attempt:   2,
+	}
+	subs := []model.WebhookSubscription{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	t.Run("delivery per subscription", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().ActiveSubscriptions(gomock.Any(), outbox.EventUserDeleted).Return(subs, nil)
+		mockRepo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deliveries []model.WebhookDelivery) error {
+			assert.Len(t, deliveries, 2)
+			for i, d := range deliveries {
+				assert.Equal(t, subs[i].ID, d.SubscriptionID)
+				assert.Equal(t, event.ID, d.EventID)
+				assert.Equal(t, event.Type, d.EventType)
+				assert.Equal(t, StatusPending, d.Status)
+				assert.Equal(t, testNow, d.NextAttemptAt)
+
+				var payload Payload
+				assert.NoError(t, json.Unmarshal([]byte(d.Payload), &payload))
+				assert.Equal(t, event.ID.String(), payload.ID)
+				assert.Equal(t, event.Type, payload.Type)
+				assert.True(t, event.CreatedAt.Equal(payload.CreatedAt))
+				assert.JSONEq(t, string(event.Payload), string(payload.Data))
+			}
+			return nil
+		})
+
+		assert.NoError(t, webhookService.Enqueue(context.Background(), event))
+	})
+
+	t.Run("no subscription", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().ActiveSubscriptions(gomock.Any(), outbox.EventUserDeleted).Return(nil, nil)
+
+		assert.NoError(t, webhookService.Enqueue(context.Background(), event))
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		webhookService, mockRepo, _ := setupServiceTest(t)
+		mockRepo.EXPECT().ActiveSubscriptions(gomock.Any(), outbox.EventUserDeleted).Return(subs, nil)
+		mockRepo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+
+		assert.Error(t, webhookService.Enqueue(context.Background(), event))
+	})
+}
+
+func TestEventTypesOf(t *testing.T) {
+	assert.Equal(t, []string{"user.registered", "user.deleted"}, EventTypesOf(&model.WebhookSubscription{EventTypes: "user.registered,user.deleted"}))
+	assert.Equal(t, []string{}, EventTypesOf(&model.WebhookSubscription{}))
+}
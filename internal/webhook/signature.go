@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of delivery requests.
+const (
+	// HeaderID holds the ID of the event, which receivers use to ignore duplicates.
+	HeaderID    = "X-Webhook-ID"
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderTimestamp holds the time the request was signed at, in Unix seconds.
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix identifies the signing scheme, so that it can change without ambiguity.
+const signaturePrefix = "v1="
+
+// Sign returns the signature of a request with the given body signed at
+// timestamp: "v1=" followed by the hex HMAC-SHA256, keyed with secret, of the
+// Unix timestamp in seconds, a dot and the body. Covering the timestamp lets
+// receivers reject replayed requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// Verify checks the timestamp and signature headers of a received request
+// with the given body. It returns ErrStaleTimestamp when the request was not
+// signed within tolerance of now and ErrInvalidSignature when the signature
+// does not match.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	sum, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) || !hmac.Equal(sum, mac(secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// mac returns the HMAC-SHA256 of the timestamp and body.
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1767268800.{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"v1=5fcb32a84c1b4b020135574d0694e29ec19d3d3cde01a491dc9adb0794c91a87",
+		Sign("secret", time.Unix(1767268800, 0), []byte(`{"id":"1"}`)),
+	)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1767268800, 0)
+	body := []byte(`{"id":"1"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		wantErr   error
+	}{
+		{name: "valid", secret: "secret", timestamp: timestamp, signature: signature, body: body, now: now},
+		{name: "within tolerance", secret: "secret", timestamp: timestamp, signature: signature, body: body, now: now.Add(5 * time.Minute)},
+		{name: "replayed", secret: "secret", timestamp: timestamp, signature: signature, body: body, now: now.Add(5*time.Minute + time.Second), wantErr: ErrStaleTimestamp},
+		{name: "from the future", secret: "secret", timestamp: timestamp, signature: signature, body: body, now: now.Add(-time.Hour), wantErr: ErrStaleTimestamp},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, signature: signature, body: body, now: now, wantErr: ErrInvalidSignature},
+		{name: "tampered body", secret: "secret", timestamp: timestamp, signature: signature, body: []byte(`{"id":"2"}`), now: now, wantErr: ErrInvalidSignature},
+		{name: "tampered timestamp", secret: "secret", timestamp: strconv.FormatInt(now.Unix()+1, 10), signature: signature, body: body, now: now, wantErr: ErrInvalidSignature},
+		{name: "invalid timestamp", secret: "secret", timestamp: "now", signature: signature, body: body, now: now, wantErr: ErrInvalidSignature},
+		{name: "missing version", secret: "secret", timestamp: timestamp, signature: signature[3:], body: body, now: now, wantErr: ErrInvalidSignature},
+		{name: "not hex", secret: "secret", timestamp: timestamp, signature: "v1=zz", body: body, now: now, wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute, tt.now)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errForbiddenTarget fails requests to addresses of the internal network.
+var errForbiddenTarget = errors.New("webhook target address is not allowed")
+
+// forbiddenPrefixes are the ranges, besides loopback, private, link-local
+// and multicast addresses, that webhooks must not reach: shared address
+// space (which holds some cloud metadata services), the "this network",
+// IETF protocol assignment, benchmarking and reserved blocks, and NAT64
+// addresses that translate to any of them.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// allowedAddr reports whether webhooks may be sent to addr. Loopback,
+// private, link-local, which includes the 169.254.169.254 metadata service,
+// unspecified and multicast addresses are refused.
+func allowedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, p := range forbiddenPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedHost reports whether the host of a subscription URL may be used. It
+// refuses addresses allowedAddr refuses and localhost. Other names are
+// checked once resolved, when connecting.
+func allowedHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return allowedAddr(addr)
+	}
+	return true
+}
+
+// checkTarget is the net.Dialer Control hook of webhook requests. It runs
+// once the host is resolved, for every address connected to, so that a name
+// resolving to an internal address is refused as well.
+func checkTarget(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !allowedAddr(addr) {
+		return errForbiddenTarget
+	}
+	return nil
+}
+
+// newClient returns the HTTP client of webhook requests, connecting through
+// a dialer with the given Control hook. Redirects are not followed, and
+// proxies from the environment are not used, so that the hook sees the
+// address of the endpoint itself.
+func newClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_allowedAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "100.100.100.200", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::", want: false},
+		{addr: "fd00:ec2::254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "64:ff9b::a9fe:a9fe", want: false},
+		{addr: "224.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, allowedAddr(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func Test_allowedHost(t *testing.T) {
+	assert.True(t, allowedHost("hooks.example.com"))
+	assert.True(t, allowedHost("93.184.216.34"))
+	assert.False(t, allowedHost("localhost"))
+	assert.False(t, allowedHost("LOCALHOST."))
+	assert.False(t, allowedHost("api.localhost"))
+	assert.False(t, allowedHost("10.0.0.1"))
+	assert.False(t, allowedHost("::1"))
+}
+
+func Test_checkTarget(t *testing.T) {
+	assert.NoError(t, checkTarget("tcp4", "93.184.216.34:443", nil))
+	assert.ErrorIs(t, checkTarget("tcp4", "127.0.0.1:8080", nil), errForbiddenTarget)
+	assert.ErrorIs(t, checkTarget("tcp6", "[fd00:ec2::254]:80", nil), errForbiddenTarget)
+	assert.Error(t, checkTarget("tcp", "no-port", nil))
+}
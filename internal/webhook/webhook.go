@@ -0,0 +1,55 @@
+// Package webhook delivers domain events to endpoints of other systems
+// subscribed by admins. Every request is signed with the secret of its
+// subscription and retried with an exponential backoff until the endpoint
+// accepts it.
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/PakornBank/go-backend-example/internal/common/model"
+	"github.com/PakornBank/go-backend-example/internal/outbox"
+)
+
+// Statuses of webhook deliveries.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// EventTypes lists the types of domain events that webhooks may subscribe to.
+var EventTypes = []string{
+	outbox.EventUserRegistered,
+	outbox.EventUserEmailChanged,
+	outbox.EventUserDeleted,
+	outbox.EventLoginFailed,
+}
+
+// Input describes a webhook subscription to create or update.
+type Input struct {
+	URL        string
+	EventTypes []string
+	// Secret signs the deliveries. When empty, Create generates one and Update keeps the current one.
+	Secret string
+	Active bool
+}
+
+// Payload is the JSON body of the requests of a delivery.
+type Payload struct {
+	// ID is the ID of the event, the same for every delivery of the event.
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// EventTypesOf returns the types of events delivered to the subscription.
+func EventTypesOf(sub *model.WebhookSubscription) []string {
+	if sub.EventTypes == "" {
+		return []string{}
+	}
+	return strings.Split(sub.EventTypes, ",")
+}